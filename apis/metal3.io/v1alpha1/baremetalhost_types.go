@@ -17,10 +17,15 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -44,6 +49,62 @@ const (
 	// annotation is present and status is empty, BMO will reconstruct BMH Status
 	// from the status annotation.
 	StatusAnnotation = "baremetalhost.metal3.io/status"
+
+	// DetachedAnnotation is the annotation that removes the host from
+	// the provisioner, without deprovisioning it, while keeping the
+	// host and its status. This is useful when the host is moved to
+	// another cluster. Removing the annotation adopts the host again.
+	DetachedAnnotation = "baremetalhost.metal3.io/detached"
+
+	// InspectAnnotation is the annotation that requests a new
+	// inspection of a host that is ready, to refresh its hardware
+	// details. It is removed once the inspection is complete. Setting
+	// it to InspectAnnotationDisabled instead prevents the host from
+	// being inspected at all.
+	InspectAnnotation = "inspect.metal3.io"
+
+	// InspectAnnotationDisabled is the value of InspectAnnotation that
+	// disables inspection, for hosts that cannot boot the inspection
+	// ramdisk.
+	InspectAnnotationDisabled = "disabled"
+
+	// HardwareDetailsAnnotation is the annotation holding hardware
+	// details, as JSON, supplied by the user instead of the results of
+	// an inspection. It is copied into the status and removed.
+	HardwareDetailsAnnotation = InspectAnnotation + "/hardwaredetails"
+
+	// TemplateAnnotation is the annotation that, set to "true" on a
+	// user data, network data or metadata secret, renders its content
+	// as a Go template of the attributes of the host using it.
+	TemplateAnnotation = "baremetalhost.metal3.io/template"
+
+	// NetworkDataFormatAnnotation is the annotation that, set to
+	// NetworkDataFormatNMState on a network data secret, marks its
+	// content as nmstate to be converted to network_data.json. Secrets
+	// with an NMStateNetworkDataKey key are treated the same way.
+	NetworkDataFormatAnnotation = "baremetalhost.metal3.io/network-data-format"
+
+	// NetworkDataFormatNMState is the value of
+	// NetworkDataFormatAnnotation for nmstate network data.
+	NetworkDataFormatNMState = "nmstate"
+
+	// NMStateNetworkDataKey is the key of network data secrets holding
+	// nmstate network data.
+	NMStateNetworkDataKey = "nmstate"
+
+	// IgnitionUserDataKey is the key of user data secrets holding an
+	// Ignition config.
+	IgnitionUserDataKey = "ignition"
+
+	// ConfigDataAnnotation is the annotation that, set to
+	// ConfigDataMetadataService, has the user data, network data and
+	// metadata of the host served by the metadata service of the
+	// operator instead of written to a config drive.
+	ConfigDataAnnotation = "baremetalhost.metal3.io/config-data"
+
+	// ConfigDataMetadataService is the value of ConfigDataAnnotation
+	// for hosts using the metadata service.
+	ConfigDataMetadataService = "metadata-service"
 )
 
 // RootDeviceHints holds the hints for specifying the storage location
@@ -89,6 +150,147 @@ type RootDeviceHints struct {
 	Rotational *bool `json:"rotational,omitempty"`
 }
 
+// MatchesDisk returns true if the disk meets all the hints. Any disk
+// meets nil hints.
+func (hints *RootDeviceHints) MatchesDisk(disk *Storage) bool {
+	if hints == nil {
+		return true
+	}
+	return (hints.DeviceName == "" || hints.DeviceName == disk.Name) &&
+		(hints.HCTL == "" || hints.HCTL == disk.HCTL) &&
+		strings.Contains(disk.Model, hints.Model) &&
+		strings.Contains(disk.Vendor, hints.Vendor) &&
+		(hints.SerialNumber == "" || hints.SerialNumber == disk.SerialNumber) &&
+		disk.SizeBytes >= Capacity(hints.MinSizeGigabytes)*GibiByte &&
+		(hints.WWN == "" || hints.WWN == disk.WWN) &&
+		(hints.WWNWithExtension == "" || hints.WWNWithExtension == disk.WWNWithExtension) &&
+		(hints.WWNVendorExtension == "" || hints.WWNVendorExtension == disk.WWNVendorExtension) &&
+		(hints.Rotational == nil || *hints.Rotational == disk.Rotational)
+}
+
+// RAIDConfig describes the RAID volumes to create on the host before
+// it becomes ready. Hardware and software RAID cannot be combined.
+type RAIDConfig struct {
+	// HardwareRAIDVolumes lists the logical disks to create with the
+	// RAID controller of the host.
+	// +optional
+	HardwareRAIDVolumes []HardwareRAIDVolume `json:"hardwareRAIDVolumes,omitempty"`
+
+	// SoftwareRAIDVolumes lists the software RAID devices to create.
+	// The first device holds the image, so it must be a RAID-1.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	SoftwareRAIDVolumes []SoftwareRAIDVolume `json:"softwareRAIDVolumes,omitempty"`
+}
+
+// HardwareRAIDVolume describes a logical disk created by the RAID
+// controller of the host.
+type HardwareRAIDVolume struct {
+	// Name of the volume. It should be unique on the host. A name is
+	// generated when it is not set.
+	// +kubebuilder:validation:MaxLength=64
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Level is the RAID level of the volume.
+	// +kubebuilder:validation:Enum="0";"1";"2";"5";"6";"1+0";"5+0";"6+0"
+	Level string `json:"level"`
+
+	// SizeGibibytes is the size of the volume. The volume takes all
+	// of the space available when it is not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SizeGibibytes *int `json:"sizeGibibytes,omitempty"`
+
+	// Rotational selects spinning disks when true and solid-state
+	// disks when false. Any kind of disk is used when it is not set.
+	// +optional
+	Rotational *bool `json:"rotational,omitempty"`
+
+	// Controller is the name of the RAID controller to use.
+	// +optional
+	Controller string `json:"controller,omitempty"`
+
+	// PhysicalDisks lists the names of the physical disks to use, as
+	// known to the RAID controller.
+	// +optional
+	PhysicalDisks []string `json:"physicalDisks,omitempty"`
+
+	// NumberOfPhysicalDisks is the number of physical disks to use.
+	// It defaults to the minimum number needed by the RAID level.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumberOfPhysicalDisks *int `json:"numberOfPhysicalDisks,omitempty"`
+}
+
+// SoftwareRAIDVolume describes a software RAID device.
+type SoftwareRAIDVolume struct {
+	// Level is the RAID level of the device.
+	// +kubebuilder:validation:Enum="0";"1";"1+0"
+	Level string `json:"level"`
+
+	// SizeGibibytes is the size of the device. The device takes all
+	// of the space available when it is not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SizeGibibytes *int `json:"sizeGibibytes,omitempty"`
+
+	// PhysicalDisks holds hints selecting the disks to use. All of
+	// the disks are used when it is not set.
+	// +optional
+	PhysicalDisks []RootDeviceHints `json:"physicalDisks,omitempty"`
+}
+
+// FirmwareConfig holds the BIOS settings to apply to the host before
+// it becomes ready.
+type FirmwareConfig struct {
+	// VirtualizationEnabled turns the virtualization extensions of
+	// the CPU on or off.
+	// +optional
+	VirtualizationEnabled *bool `json:"virtualizationEnabled,omitempty"`
+
+	// SimultaneousMultithreadingEnabled turns simultaneous
+	// multithreading, also known as hyperthreading, on or off.
+	// +optional
+	SimultaneousMultithreadingEnabled *bool `json:"simultaneousMultithreadingEnabled,omitempty"`
+
+	// SriovEnabled turns SR-IOV support on or off.
+	// +optional
+	SriovEnabled *bool `json:"sriovEnabled,omitempty"`
+
+	// Settings holds vendor specific BIOS settings by name. They are
+	// applied after the settings above, so they take precedence.
+	// +optional
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// FirmwareComponent is a part of the host with its own firmware.
+// +kubebuilder:validation:Enum=bios;bmc;nic
+type FirmwareComponent string
+
+// Firmware components that can be updated
+const (
+	BIOSFirmwareComponent FirmwareComponent = "bios"
+	BMCFirmwareComponent  FirmwareComponent = "bmc"
+	NICFirmwareComponent  FirmwareComponent = "nic"
+)
+
+// FirmwareUpdate describes a firmware image to install on a component
+// of the host.
+type FirmwareUpdate struct {
+	// Component is the part of the host the image is for.
+	Component FirmwareComponent `json:"component"`
+
+	// URL is the location of the firmware image.
+	URL string `json:"url"`
+
+	// Checksum is the checksum of the firmware image, kept for
+	// reference. The firmware update step of the provisioner does not
+	// take a checksum, the BMC checks the images it installs.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+}
+
 // BootMode is the boot mode of the system
 // +kubebuilder:validation:Enum=UEFI;legacy
 type BootMode string
@@ -100,6 +302,24 @@ const (
 	DefaultBootMode BootMode = UEFI
 )
 
+// AutomatedCleaningMode selects how the disks of a host are cleaned
+// +kubebuilder:validation:Enum=disabled;metadata;full
+type AutomatedCleaningMode string
+
+// Allowed automated cleaning modes
+const (
+	// CleaningModeDisabled skips cleaning the host entirely.
+	CleaningModeDisabled AutomatedCleaningMode = "disabled"
+	// CleaningModeMetadata runs the automated cleaning configured in
+	// the provisioning backend, which removes the partition tables
+	// of the disks.
+	CleaningModeMetadata AutomatedCleaningMode = "metadata"
+	// CleaningModeFull also erases the whole content of the disks.
+	CleaningModeFull AutomatedCleaningMode = "full"
+
+	DefaultCleaningMode AutomatedCleaningMode = CleaningModeMetadata
+)
+
 // OperationalStatus represents the state of the host
 type OperationalStatus string
 
@@ -116,6 +336,11 @@ const (
 	// OperationalStatusError is the status value for when the host
 	// has any sort of error.
 	OperationalStatusError OperationalStatus = "error"
+
+	// OperationalStatusDetached is the status value for when the host
+	// has been removed from the provisioner by the detached
+	// annotation, without being deprovisioned.
+	OperationalStatusDetached OperationalStatus = "detached"
 )
 
 // ErrorType indicates the class of problem that has caused the Host resource
@@ -136,6 +361,44 @@ const (
 	// PowerManagementError is an error condition occurring when the
 	// controller is unable to modify the power state of the Host.
 	PowerManagementError ErrorType = "power management error"
+	// PreparationError is an error condition occurring when the
+	// controller fails to apply the settings, such as the RAID
+	// configuration, that must be in place before the host is ready.
+	PreparationError ErrorType = "preparation error"
+	// FirmwareUpdateError is an error condition occurring when the
+	// controller fails to install the firmware images listed in the
+	// spec of the host.
+	FirmwareUpdateError ErrorType = "firmware update error"
+	// IgnitionError is an error condition occurring when the Ignition
+	// config in the user data of the Host is invalid.
+	IgnitionError ErrorType = "ignition error"
+)
+
+// Condition types reported in the Conditions field of the host
+// status.
+const (
+	// RegisteredCondition is true when the host is known to the
+	// provisioning backend.
+	RegisteredCondition string = "Registered"
+
+	// InspectedCondition is true when the hardware details of the
+	// host have been collected.
+	InspectedCondition string = "Inspected"
+
+	// ProvisionedCondition is true when an image has been written to
+	// the host, or when the host is externally provisioned.
+	ProvisionedCondition string = "Provisioned"
+
+	// PoweredOnCondition is true when the host is powered on.
+	PoweredOnCondition string = "PoweredOn"
+
+	// BMCReachableCondition is true when the controller has been
+	// able to talk to the BMC of the host using its credentials.
+	BMCReachableCondition string = "BMCReachable"
+
+	// ReadyCondition is true when the host is in a steady state
+	// without any errors.
+	ReadyCondition string = "Ready"
 )
 
 // ProvisioningState defines the states the provisioner will report
@@ -161,6 +424,15 @@ const (
 	// against known hardware profiles
 	StateMatchProfile ProvisioningState = "match profile"
 
+	// StateUpdatingFirmware means we are installing the firmware
+	// images listed in the host spec
+	StateUpdatingFirmware ProvisioningState = "updating firmware"
+
+	// StatePreparing means we are applying the settings, such as the
+	// RAID configuration, that must be in place before the host is
+	// ready
+	StatePreparing ProvisioningState = "preparing"
+
 	// StateReady means the host can be consumed
 	StateReady ProvisioningState = "ready"
 
@@ -227,7 +499,10 @@ type BareMetalHostSpec struct {
 
 	// Taints is the full, authoritative list of taints to apply to
 	// the corresponding Machine. This list will overwrite any
-	// modifications made to the Machine on an ongoing basis.
+	// modifications made to the Machine on an ongoing basis. Hosts
+	// with a NoSchedule or NoExecute taint are not available to
+	// consumers that do not tolerate it, and a NoExecute taint
+	// deprovisions a consumed host.
 	// +optional
 	Taints []corev1.Taint `json:"taints,omitempty"`
 
@@ -243,16 +518,62 @@ type BareMetalHostSpec struct {
 	// being provisioned.
 	RootDeviceHints *RootDeviceHints `json:"rootDeviceHints,omitempty"`
 
+	// RAID describes the RAID volumes to create on the host before it
+	// becomes ready. When it is not set, the RAID configuration of
+	// the host is left alone.
+	// +optional
+	RAID *RAIDConfig `json:"raid,omitempty"`
+
+	// Firmware holds the BIOS settings to apply to the host before it
+	// becomes ready. When it is not set, the BIOS settings of the host
+	// are left alone.
+	// +optional
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+
+	// FirmwareUpdates lists the firmware images to install on the
+	// host before it becomes ready. The images are installed again
+	// whenever the list changes while the host is not provisioned.
+	// +optional
+	FirmwareUpdates []FirmwareUpdate `json:"firmwareUpdates,omitempty"`
+
+	// CustomDeploySteps lists the deploy steps to run while the
+	// image is provisioned, in addition to the default steps of the
+	// provisioner.
+	// +optional
+	CustomDeploySteps []CustomDeployStep `json:"customDeploySteps,omitempty"`
+
+	// CustomCleanSteps lists the manual clean steps to run, in order,
+	// after the host is deprovisioned.
+	// +optional
+	CustomCleanSteps []CustomCleanStep `json:"customCleanSteps,omitempty"`
+
 	// Select the method of initializing the hardware during
 	// boot. Defaults to UEFI.
 	// +optional
 	BootMode BootMode `json:"bootMode,omitempty"`
 
+	// AutomatedCleaningMode selects how the disks of the host are
+	// cleaned when it is registered and after it is deprovisioned.
+	// Defaults to metadata.
+	// +optional
+	AutomatedCleaningMode AutomatedCleaningMode `json:"automatedCleaningMode,omitempty"`
+
 	// Which MAC address will PXE boot? This is optional for some
 	// types, but required for libvirt VMs driven by vbmc.
 	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
 	BootMACAddress string `json:"bootMACAddress,omitempty"`
 
+	// NICs lists network interfaces of the host, other than the one
+	// with the BootMACAddress, to register with the provisioner.
+	// +optional
+	NICs []NICConfig `json:"nics,omitempty"`
+
+	// PortGroups lists the bonds of network interfaces of the host.
+	// Their members are registered with the provisioner even when
+	// they are not listed in NICs.
+	// +optional
+	PortGroups []PortGroup `json:"portGroups,omitempty"`
+
 	// Should the server be online?
 	Online bool `json:"online"`
 
@@ -302,23 +623,175 @@ const (
 	SHA512 ChecksumType = "sha512"
 )
 
+// NICConfig is a network interface of a host registered with the
+// provisioner.
+type NICConfig struct {
+	// MACAddress is the MAC address of the interface.
+	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
+	MACAddress string `json:"macAddress"`
+
+	// PXEEnabled allows the host to PXE boot from the interface. The
+	// interface with the BootMACAddress always is.
+	// +optional
+	PXEEnabled bool `json:"pxeEnabled,omitempty"`
+}
+
+// BondMode is the Linux bonding mode of a port group.
+// +kubebuilder:validation:Enum=balance-rr;active-backup;balance-xor;broadcast;"802.3ad";balance-tlb;balance-alb
+type BondMode string
+
+const (
+	// BondModeActiveBackup uses one interface of the bond at a time.
+	BondModeActiveBackup BondMode = "active-backup"
+
+	// BondModeLACP aggregates the interfaces of the bond with LACP.
+	// The interfaces cannot be used on their own, so the host only
+	// PXE boots over the bond when the switch falls back to a single
+	// interface while LACP is not negotiated.
+	BondModeLACP BondMode = "802.3ad"
+)
+
+// PortGroup is a bond of network interfaces of a host.
+type PortGroup struct {
+	// Name identifies the port group among those of the host.
+	Name string `json:"name"`
+
+	// Mode is the bonding mode. Defaults to the mode configured in
+	// the provisioner.
+	// +optional
+	Mode BondMode `json:"mode,omitempty"`
+
+	// MACAddresses are the MAC addresses of the interfaces in the
+	// bond. The first one is used as the address of the bond.
+	// +kubebuilder:validation:MinItems=1
+	MACAddresses []string `json:"macAddresses"`
+
+	// Properties holds additional bonding options, such as miimon
+	// or xmit_hash_policy.
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
 // Image holds the details of an image either to provisioned or that
 // has been provisioned.
 type Image struct {
 	// URL is a location of an image to deploy.
 	URL string `json:"url"`
 
-	// Checksum is the checksum for the image.
-	Checksum string `json:"checksum"`
+	// Checksum is the checksum for the image, or the http(s) URL of
+	// a checksum file holding it. It is not used for live-iso images.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
 
 	// ChecksumType is the checksum algorithm for the image.
 	// e.g md5, sha256, sha512
 	ChecksumType ChecksumType `json:"checksumType,omitempty"`
 
 	// DiskFormat contains the format of the image (raw, qcow2, ...)
-	// Needs to be set to raw for raw images streaming
-	// +kubebuilder:validation:Enum=raw;qcow2;vdi;vmdk
+	// Needs to be set to raw for raw images streaming. A live-iso
+	// image is booted directly instead of being written to disk.
+	// +kubebuilder:validation:Enum=raw;qcow2;vdi;vmdk;live-iso
 	DiskFormat *string `json:"format,omitempty"`
+
+	// Kernel is the URL of the kernel of a partition image. It must
+	// be set together with Ramdisk, and is not used for whole-disk
+	// images.
+	// +optional
+	Kernel string `json:"kernel,omitempty"`
+
+	// Ramdisk is the URL of the initial ramdisk of a partition image.
+	// It must be set together with Kernel.
+	// +optional
+	Ramdisk string `json:"ramdisk,omitempty"`
+
+	// RootGB is the size, in GiB, of the root partition created for
+	// the image. The size set by the hardware profile of the host is
+	// used when it is not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RootGB int `json:"rootGB,omitempty"`
+}
+
+// LiveISODiskFormat is the disk format of images that are booted
+// directly instead of being written to disk.
+const LiveISODiskFormat = "live-iso"
+
+// IsLiveISO returns true when the image is booted directly instead of
+// being written to disk.
+func (image *Image) IsLiveISO() bool {
+	return image != nil && image.DiskFormat != nil && *image.DiskFormat == LiveISODiskFormat
+}
+
+// IsPartitionImage returns true when the image is a partition image
+// booted with a separate kernel and ramdisk.
+func (image *Image) IsPartitionImage() bool {
+	return image != nil && (image.Kernel != "" || image.Ramdisk != "")
+}
+
+// BootsWithKernelParams returns true when the provisioner boots the
+// kernel of the image, and can pass parameters on its command line,
+// which is the case of live-iso and partition images.
+func (image *Image) BootsWithKernelParams() bool {
+	return image.IsLiveISO() || image.IsPartitionImage()
+}
+
+// HasChecksumURL returns true when the checksum of the image is the
+// URL of a checksum file rather than the checksum itself.
+func (image *Image) HasChecksumURL() bool {
+	return image != nil && (strings.HasPrefix(image.Checksum, "http://") ||
+		strings.HasPrefix(image.Checksum, "https://"))
+}
+
+// CustomDeployStep is a deploy step run by the provisioner while an
+// image is provisioned.
+type CustomDeployStep struct {
+	// Interface is the driver interface implementing the step.
+	// +kubebuilder:validation:Enum=bios;boot;deploy;management;power;raid;vendor
+	Interface string `json:"interface"`
+
+	// Step is the name of the step.
+	Step string `json:"step"`
+
+	// Args holds the arguments passed to the step.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Args *runtime.RawExtension `json:"args,omitempty"`
+
+	// Priority orders the step among the other deploy steps. Steps
+	// with a higher priority run first.
+	// +kubebuilder:validation:Minimum=1
+	Priority int `json:"priority"`
+}
+
+// CustomCleanStep is a manual clean step run by the provisioner after
+// a host is deprovisioned.
+type CustomCleanStep struct {
+	// Interface is the driver interface implementing the step.
+	// +kubebuilder:validation:Enum=bios;deploy;management;power;raid;vendor
+	Interface string `json:"interface"`
+
+	// Step is the name of the step.
+	Step string `json:"step"`
+
+	// Args holds the arguments passed to the step.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Args *runtime.RawExtension `json:"args,omitempty"`
+}
+
+// StepsStatus reports the progress of the custom steps of a host.
+type StepsStatus struct {
+	// Current is the step being run, as interface.step. It is empty
+	// when no custom step is running.
+	Current string `json:"current,omitempty"`
+
+	// Completed is the number of custom steps that finished.
+	Completed int `json:"completed"`
+
+	// Total is the number of custom steps to run.
+	Total int `json:"total"`
 }
 
 // FIXME(dhellmann): We probably want some other module to own these
@@ -436,6 +909,40 @@ type NIC struct {
 type Firmware struct {
 	// The BIOS for this firmware
 	BIOS BIOS `json:"bios"`
+
+	// BIOSSettings holds the current values of the BIOS settings
+	// applied from the firmware settings of the host, by name.
+	// +optional
+	BIOSSettings map[string]string `json:"biosSettings,omitempty"`
+
+	// Components holds the firmware versions of the parts of the
+	// host, when the provisioning backend reports them.
+	// +optional
+	Components []FirmwareComponentStatus `json:"components,omitempty"`
+}
+
+// FirmwareComponentStatus describes the firmware version of a part of
+// the host.
+type FirmwareComponentStatus struct {
+	// Component is the part of the host the firmware is for.
+	Component string `json:"component"`
+
+	// InitialVersion is the version found when the host was
+	// registered.
+	// +optional
+	InitialVersion string `json:"initialVersion,omitempty"`
+
+	// CurrentVersion is the version currently installed.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// LastVersionFlashed is the version last installed by an update.
+	// +optional
+	LastVersionFlashed string `json:"lastVersionFlashed,omitempty"`
+
+	// UpdatedAt is when the firmware was last updated.
+	// +optional
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
 }
 
 // BIOS describes the BIOS version on the host.
@@ -462,6 +969,58 @@ type HardwareDetails struct {
 	Hostname     string               `json:"hostname"`
 }
 
+// CPUSummary describes the processors of the host, without their
+// flags.
+type CPUSummary struct {
+	Arch           string     `json:"arch"`
+	Model          string     `json:"model"`
+	ClockMegahertz ClockSpeed `json:"clockMegahertz"`
+	Count          int        `json:"count"`
+}
+
+// HardwareSummary holds the parts of the hardware details of a host
+// that are kept in its status. The full details are stored in the
+// HardwareData resource of the host.
+type HardwareSummary struct {
+	SystemVendor HardwareSystemVendor `json:"systemVendor"`
+	Firmware     Firmware             `json:"firmware"`
+	RAMMebibytes int                  `json:"ramMebibytes"`
+	CPU          CPUSummary           `json:"cpu"`
+	Hostname     string               `json:"hostname"`
+
+	// NICCount is the number of network interfaces.
+	NICCount int `json:"nicCount"`
+
+	// StorageCount is the number of storage devices.
+	StorageCount int `json:"storageCount"`
+
+	// StorageSizeBytes is the total size of the storage devices.
+	StorageSizeBytes Capacity `json:"storageSizeBytes"`
+}
+
+// Summary returns the summary of the hardware details kept in the
+// status of the host.
+func (details *HardwareDetails) Summary() *HardwareSummary {
+	summary := &HardwareSummary{
+		SystemVendor: details.SystemVendor,
+		Firmware:     *details.Firmware.DeepCopy(),
+		RAMMebibytes: details.RAMMebibytes,
+		CPU: CPUSummary{
+			Arch:           details.CPU.Arch,
+			Model:          details.CPU.Model,
+			ClockMegahertz: details.CPU.ClockMegahertz,
+			Count:          details.CPU.Count,
+		},
+		Hostname:     details.Hostname,
+		NICCount:     len(details.NIC),
+		StorageCount: len(details.Storage),
+	}
+	for _, disk := range details.Storage {
+		summary.StorageSizeBytes += disk.SizeBytes
+	}
+	return summary
+}
+
 // HardwareSystemVendor stores details about the whole hardware system.
 type HardwareSystemVendor struct {
 	Manufacturer string `json:"manufacturer"`
@@ -525,12 +1084,12 @@ type BareMetalHostStatus struct {
 	// after modifying this file
 
 	// OperationalStatus holds the status of the host
-	// +kubebuilder:validation:Enum="";OK;discovered;error
+	// +kubebuilder:validation:Enum="";OK;discovered;error;detached
 	OperationalStatus OperationalStatus `json:"operationalStatus"`
 
 	// ErrorType indicates the type of failure encountered when the
 	// OperationalStatus is OperationalStatusError
-	// +kubebuilder:validation:Enum=registration error;inspection error;provisioning error;power management error
+	// +kubebuilder:validation:Enum=registration error;inspection error;provisioning error;power management error;preparation error;firmware update error;ignition error
 	ErrorType ErrorType `json:"errorType,omitempty"`
 
 	// LastUpdated identifies when this status was last observed.
@@ -540,9 +1099,22 @@ type BareMetalHostStatus struct {
 	// The name of the profile matching the hardware details.
 	HardwareProfile string `json:"hardwareProfile"`
 
-	// The hardware discovered to exist on the host.
+	// HardwareDetails holds the hardware details of hosts inspected
+	// before they were kept in a HardwareData resource, until the
+	// controller moves them there.
+	// Deprecated: use the HardwareData resource of the host instead.
+	// +optional
 	HardwareDetails *HardwareDetails `json:"hardware,omitempty"`
 
+	// A summary of the hardware discovered to exist on the host.
+	// +optional
+	HardwareSummary *HardwareSummary `json:"hardwareSummary,omitempty"`
+
+	// HardwareData is the HardwareData resource holding the full
+	// hardware details and inspection data of the host.
+	// +optional
+	HardwareData *corev1.LocalObjectReference `json:"hardwareData,omitempty"`
+
 	// Information tracked by the provisioner.
 	Provisioning ProvisionStatus `json:"provisioning"`
 
@@ -564,6 +1136,20 @@ type BareMetalHostStatus struct {
 
 	// ErrorCount records how many times the host has encoutered an error since the last successful operation
 	ErrorCount int `json:"errorCount"`
+
+	// Conditions describe the current state of the host using the
+	// standard Kubernetes condition format.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Taints holds the taints of the spec that are in effect, with
+	// the time NoExecute taints were added.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
 }
 
 // ProvisionStatus holds the state information for a single target.
@@ -583,6 +1169,32 @@ type ProvisionStatus struct {
 
 	// BootMode indicates the boot mode used to provision the node
 	BootMode BootMode `json:"bootMode,omitempty"`
+
+	// RAID holds the RAID configuration last applied to the host.
+	RAID *RAIDConfig `json:"raid,omitempty"`
+
+	// Firmware holds the BIOS settings last applied to the host.
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+
+	// PendingRAID holds the RAID configuration being applied to the
+	// host, until the preparation completes.
+	PendingRAID *RAIDConfig `json:"pendingRAID,omitempty"`
+
+	// PendingFirmware holds the BIOS settings being applied to the
+	// host, until the preparation completes.
+	PendingFirmware *FirmwareConfig `json:"pendingFirmware,omitempty"`
+
+	// FirmwareUpdates holds the firmware images last installed on
+	// the host.
+	FirmwareUpdates []FirmwareUpdate `json:"firmwareUpdates,omitempty"`
+
+	// DeploySteps reports the progress of the custom deploy steps
+	// of the last provisioning.
+	DeploySteps *StepsStatus `json:"deploySteps,omitempty"`
+
+	// CleanSteps reports the progress of the custom clean steps of
+	// the last deprovisioning.
+	CleanSteps *StepsStatus `json:"cleanSteps,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -599,6 +1211,7 @@ type ProvisionStatus struct {
 // +kubebuilder:printcolumn:name="Online",type="string",JSONPath=".spec.online",description="Whether the host is online or not"
 // +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.errorMessage",description="Most recent error"
 // +kubebuilder:object:root=true
+// +kubebuilder:storageversion
 type BareMetalHost struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -616,17 +1229,63 @@ func (host *BareMetalHost) BootMode() BootMode {
 	return mode
 }
 
-// Available returns true if the host is available to be provisioned.
+// AutomatedCleaningMode returns the cleaning mode to use for the host.
+func (host *BareMetalHost) AutomatedCleaningMode() AutomatedCleaningMode {
+	mode := host.Spec.AutomatedCleaningMode
+	if mode == "" {
+		return DefaultCleaningMode
+	}
+	return mode
+}
+
+// Available returns true if the host is available to be provisioned
+// by a consumer without tolerations.
 func (host *BareMetalHost) Available() bool {
+	return host.AvailableFor(nil)
+}
+
+// AvailableFor returns true if the host is available to be provisioned
+// by a consumer with the given tolerations. Only hosts that are ready
+// or available can be provisioned.
+func (host *BareMetalHost) AvailableFor(tolerations []corev1.Toleration) bool {
 	if host.Spec.ConsumerRef != nil {
 		return false
 	}
+	if state := host.Status.Provisioning.State; state != StateReady && state != StateAvailable {
+		return false
+	}
 	if host.GetDeletionTimestamp() != nil {
 		return false
 	}
 	if host.HasError() {
 		return false
 	}
+	if !host.ToleratesTaints(tolerations, corev1.TaintEffectNoSchedule) ||
+		!host.ToleratesTaints(tolerations, corev1.TaintEffectNoExecute) {
+		return false
+	}
+	return true
+}
+
+// ToleratesTaints returns true if the tolerations tolerate all the
+// taints of the host with the given effect.
+func (host *BareMetalHost) ToleratesTaints(tolerations []corev1.Toleration, effect corev1.TaintEffect) bool {
+	for i := range host.Spec.Taints {
+		taint := &host.Spec.Taints[i]
+		if taint.Effect != effect {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
 	return true
 }
 
@@ -680,6 +1339,49 @@ func (host *BareMetalHost) getLabel(name string) string {
 	return host.Labels[name]
 }
 
+// HasDetachedAnnotation returns true if the detached annotation is set
+func (host *BareMetalHost) HasDetachedAnnotation() bool {
+	_, ok := host.Annotations[DetachedAnnotation]
+	return ok
+}
+
+// UsesMetadataService returns true if the configuration data of the
+// host is served by the metadata service instead of a config drive
+func (host *BareMetalHost) UsesMetadataService() bool {
+	return host.Annotations[ConfigDataAnnotation] == ConfigDataMetadataService
+}
+
+// HasInspectAnnotation returns true if a new inspection of the host
+// has been requested
+func (host *BareMetalHost) HasInspectAnnotation() bool {
+	value, ok := host.Annotations[InspectAnnotation]
+	return ok && value != InspectAnnotationDisabled
+}
+
+// InspectionDisabled returns true if the inspect annotation disables
+// inspection of the host
+func (host *BareMetalHost) InspectionDisabled() bool {
+	return host.Annotations[InspectAnnotation] == InspectAnnotationDisabled
+}
+
+// GetHardwareDetailsFromAnnotation returns the hardware details
+// supplied through the hardware details annotation, or nil if the
+// annotation is not set. Unknown fields are rejected so that typos are
+// not silently ignored.
+func (host *BareMetalHost) GetHardwareDetailsFromAnnotation() (*HardwareDetails, error) {
+	value, ok := host.Annotations[HardwareDetailsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	details := &HardwareDetails{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
 // HasBMCDetails returns true if the BMC details are set
 func (host *BareMetalHost) HasBMCDetails() bool {
 	return host.Spec.BMC.Address != "" || host.Spec.BMC.CredentialsName != ""
@@ -721,6 +1423,35 @@ func (host *BareMetalHost) OperationalStatus() OperationalStatus {
 	return host.Status.OperationalStatus
 }
 
+// GetCondition returns the condition of the given type, or nil if
+// the condition has not been set.
+func (host *BareMetalHost) GetCondition(condType string) *metav1.Condition {
+	return meta.FindStatusCondition(host.Status.Conditions, condType)
+}
+
+// SetCondition updates the condition of the given type and returns
+// true when a change is made or false when no change is made.
+func (host *BareMetalHost) SetCondition(condType string, status metav1.ConditionStatus, reason, message string) (dirty bool) {
+	existing := host.GetCondition(condType)
+	if existing != nil &&
+		existing.Status == status &&
+		existing.Reason == reason &&
+		existing.Message == message &&
+		existing.ObservedGeneration == host.Generation {
+		return false
+	}
+	meta.SetStatusCondition(&host.Status.Conditions, metav1.Condition{
+		Type:    condType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	// SetStatusCondition does not update the generation of an
+	// existing condition, so always do it here.
+	host.GetCondition(condType).ObservedGeneration = host.Generation
+	return true
+}
+
 // HasError returns a boolean indicating whether there is an error
 // set for the host.
 func (host *BareMetalHost) HasError() bool {
@@ -749,7 +1480,45 @@ func (host *BareMetalHost) NeedsHardwareInspection() bool {
 		// this host, because we don't want to reboot it.
 		return false
 	}
-	return host.Status.HardwareDetails == nil
+	if host.InspectionDisabled() {
+		return false
+	}
+	return host.Status.HardwareSummary == nil
+}
+
+// RAIDConfigChanged returns true when the RAID configuration in the
+// spec has not been applied to the host. A host without RAID settings
+// keeps the configuration it has.
+func (host *BareMetalHost) RAIDConfigChanged() bool {
+	if host.Spec.RAID == nil {
+		return false
+	}
+	return !reflect.DeepEqual(host.Spec.RAID, host.Status.Provisioning.RAID)
+}
+
+// FirmwareConfigChanged returns true when the firmware settings in the
+// spec have not been applied to the host. A host without firmware
+// settings keeps the settings it has.
+func (host *BareMetalHost) FirmwareConfigChanged() bool {
+	if host.Spec.Firmware == nil {
+		return false
+	}
+	return !reflect.DeepEqual(host.Spec.Firmware, host.Status.Provisioning.Firmware)
+}
+
+// FirmwareUpdatesChanged returns true when the firmware images listed
+// in the spec have not been installed on the host.
+func (host *BareMetalHost) FirmwareUpdatesChanged() bool {
+	if len(host.Spec.FirmwareUpdates) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(host.Spec.FirmwareUpdates, host.Status.Provisioning.FirmwareUpdates)
+}
+
+// NeedsPreparation returns true when some of the settings that must
+// be in place before the host is ready have not been applied.
+func (host *BareMetalHost) NeedsPreparation() bool {
+	return host.RAIDConfigChanged() || host.FirmwareConfigChanged()
 }
 
 // NeedsProvisioning compares the settings with the provisioning
@@ -857,7 +1626,8 @@ func (host *BareMetalHost) OperationMetricForState(operation ProvisioningState)
 	return
 }
 
-// GetImageChecksum returns the hash value and its algo.
+// GetImageChecksum returns the hash value and its algo. When the
+// checksum is a URL, the URL is returned instead of the hash value.
 func (host *BareMetalHost) GetImageChecksum() (string, string, bool) {
 	if host.Spec.Image == nil {
 		return "", "", false
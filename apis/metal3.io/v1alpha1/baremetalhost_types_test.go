@@ -30,10 +30,39 @@ func TestHostAvailable(t *testing.T) {
 					Name:      "myhost",
 					Namespace: "myns",
 				},
+				Status: BareMetalHostStatus{
+					Provisioning: ProvisionStatus{State: StateReady},
+				},
+			},
+			Expected:    true,
+			FailMessage: "ready host returned not available",
+		},
+		{
+			Host: BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Status: BareMetalHostStatus{
+					Provisioning: ProvisionStatus{State: StateAvailable},
+				},
 			},
 			Expected:    true,
 			FailMessage: "available host returned not available",
 		},
+		{
+			Host: BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Status: BareMetalHostStatus{
+					Provisioning: ProvisionStatus{State: StateInspecting},
+				},
+			},
+			Expected:    false,
+			FailMessage: "inspecting host returned as available",
+		},
 		{
 			Host:        hostWithError,
 			Expected:    false,
@@ -66,6 +95,39 @@ func TestHostAvailable(t *testing.T) {
 			Expected:    false,
 			FailMessage: "deleted host returned as available",
 		},
+		{
+			Host: BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Spec: BareMetalHostSpec{
+					Taints: []corev1.Taint{
+						{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule},
+					},
+				},
+			},
+			Expected:    false,
+			FailMessage: "tainted host returned as available",
+		},
+		{
+			Host: BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Spec: BareMetalHostSpec{
+					Taints: []corev1.Taint{
+						{Key: "slow", Effect: corev1.TaintEffectPreferNoSchedule},
+					},
+				},
+				Status: BareMetalHostStatus{
+					Provisioning: ProvisionStatus{State: StateReady},
+				},
+			},
+			Expected:    true,
+			FailMessage: "host with PreferNoSchedule taint returned not available",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -75,6 +137,79 @@ func TestHostAvailable(t *testing.T) {
 	}
 }
 
+func TestHostAvailableFor(t *testing.T) {
+	testCases := []struct {
+		Scenario    string
+		Taints      []corev1.Taint
+		Tolerations []corev1.Toleration
+		Expected    bool
+	}{
+		{
+			Scenario: "no taints",
+			Expected: true,
+		},
+		{
+			Scenario: "NoSchedule tolerated",
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			},
+			Tolerations: []corev1.Toleration{
+				{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			},
+			Expected: true,
+		},
+		{
+			Scenario: "NoSchedule not tolerated",
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			},
+			Tolerations: []corev1.Toleration{
+				{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "false", Effect: corev1.TaintEffectNoSchedule},
+			},
+			Expected: false,
+		},
+		{
+			Scenario: "NoExecute not tolerated",
+			Taints: []corev1.Taint{
+				{Key: "maintenance", Effect: corev1.TaintEffectNoExecute},
+			},
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+			Expected: false,
+		},
+		{
+			Scenario: "all tolerated",
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "maintenance", Effect: corev1.TaintEffectNoExecute},
+			},
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+			Expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Spec: BareMetalHostSpec{
+					Taints: tc.Taints,
+				},
+				Status: BareMetalHostStatus{
+					Provisioning: ProvisionStatus{State: StateReady},
+				},
+			}
+			assert.Equal(t, tc.Expected, host.AvailableFor(tc.Tolerations))
+		})
+	}
+}
+
 func TestHostNeedsHardwareInspection(t *testing.T) {
 
 	testCases := []struct {
@@ -101,7 +236,7 @@ func TestHostNeedsHardwareInspection(t *testing.T) {
 					Namespace: "myns",
 				},
 				Status: BareMetalHostStatus{
-					HardwareDetails: &HardwareDetails{},
+					HardwareSummary: &HardwareSummary{},
 				},
 			},
 			Expected: false,
@@ -121,6 +256,20 @@ func TestHostNeedsHardwareInspection(t *testing.T) {
 			Expected: true,
 		},
 
+		{
+			Scenario: "inspection disabled",
+			Host: BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+					Annotations: map[string]string{
+						InspectAnnotation: InspectAnnotationDisabled,
+					},
+				},
+			},
+			Expected: false,
+		},
+
 		{
 			Scenario: "provisioned host",
 			Host: BareMetalHost{
@@ -555,6 +704,322 @@ func TestBootMode(t *testing.T) {
 	}
 }
 
+func TestHostNeedsPreparation(t *testing.T) {
+	enabled := true
+	disabled := false
+	raid := &RAIDConfig{
+		HardwareRAIDVolumes: []HardwareRAIDVolume{{Level: "1"}},
+	}
+
+	for _, tc := range []struct {
+		Scenario string
+		Spec     BareMetalHostSpec
+		Status   ProvisionStatus
+		Expected bool
+	}{
+		{
+			Scenario: "no settings",
+			Expected: false,
+		},
+		{
+			Scenario: "new RAID configuration",
+			Spec:     BareMetalHostSpec{RAID: raid},
+			Expected: true,
+		},
+		{
+			Scenario: "applied RAID configuration",
+			Spec:     BareMetalHostSpec{RAID: raid},
+			Status:   ProvisionStatus{RAID: raid.DeepCopy()},
+			Expected: false,
+		},
+		{
+			Scenario: "new firmware settings",
+			Spec: BareMetalHostSpec{
+				Firmware: &FirmwareConfig{VirtualizationEnabled: &enabled},
+			},
+			Expected: true,
+		},
+		{
+			Scenario: "changed firmware settings",
+			Spec: BareMetalHostSpec{
+				Firmware: &FirmwareConfig{VirtualizationEnabled: &enabled},
+			},
+			Status: ProvisionStatus{
+				Firmware: &FirmwareConfig{VirtualizationEnabled: &disabled},
+			},
+			Expected: true,
+		},
+		{
+			Scenario: "applied firmware settings",
+			Spec: BareMetalHostSpec{
+				Firmware: &FirmwareConfig{VirtualizationEnabled: &enabled},
+			},
+			Status: ProvisionStatus{
+				Firmware: &FirmwareConfig{VirtualizationEnabled: &enabled},
+			},
+			Expected: false,
+		},
+		{
+			Scenario: "removed firmware settings",
+			Status: ProvisionStatus{
+				Firmware: &FirmwareConfig{VirtualizationEnabled: &enabled},
+			},
+			Expected: false,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{
+				Spec:   tc.Spec,
+				Status: BareMetalHostStatus{Provisioning: tc.Status},
+			}
+			assert.Equal(t, tc.Expected, host.NeedsPreparation())
+		})
+	}
+}
+
+func TestHostFirmwareUpdatesChanged(t *testing.T) {
+	updates := []FirmwareUpdate{
+		{Component: BIOSFirmwareComponent, URL: "http://example.com/bios.bin", Checksum: "abcd"},
+	}
+	newUpdates := []FirmwareUpdate{
+		{Component: BIOSFirmwareComponent, URL: "http://example.com/bios-2.bin", Checksum: "ef01"},
+	}
+
+	for _, tc := range []struct {
+		Scenario string
+		Spec     []FirmwareUpdate
+		Status   []FirmwareUpdate
+		Expected bool
+	}{
+		{
+			Scenario: "no updates",
+			Expected: false,
+		},
+		{
+			Scenario: "new updates",
+			Spec:     updates,
+			Expected: true,
+		},
+		{
+			Scenario: "installed updates",
+			Spec:     updates,
+			Status:   updates,
+			Expected: false,
+		},
+		{
+			Scenario: "changed updates",
+			Spec:     newUpdates,
+			Status:   updates,
+			Expected: true,
+		},
+		{
+			Scenario: "removed updates",
+			Status:   updates,
+			Expected: false,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{
+				Spec: BareMetalHostSpec{FirmwareUpdates: tc.Spec},
+				Status: BareMetalHostStatus{
+					Provisioning: ProvisionStatus{FirmwareUpdates: tc.Status},
+				},
+			}
+			assert.Equal(t, tc.Expected, host.FirmwareUpdatesChanged())
+		})
+	}
+}
+
+func TestAutomatedCleaningMode(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario  string
+		HostValue AutomatedCleaningMode
+		Expected  AutomatedCleaningMode
+	}{
+		{
+			Scenario:  "default",
+			HostValue: "",
+			Expected:  CleaningModeMetadata,
+		},
+		{
+			Scenario:  "disabled",
+			HostValue: CleaningModeDisabled,
+			Expected:  CleaningModeDisabled,
+		},
+		{
+			Scenario:  "full",
+			HostValue: CleaningModeFull,
+			Expected:  CleaningModeFull,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &BareMetalHost{
+				Spec: BareMetalHostSpec{
+					AutomatedCleaningMode: tc.HostValue,
+				},
+			}
+			assert.Equal(t, tc.Expected, host.AutomatedCleaningMode())
+		})
+	}
+}
+
+func TestImageIsLiveISO(t *testing.T) {
+	raw := "raw"
+	liveISO := LiveISODiskFormat
+
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Expected bool
+	}{
+		{
+			Scenario: "no image",
+			Image:    nil,
+			Expected: false,
+		},
+		{
+			Scenario: "no disk format",
+			Image:    &Image{URL: "http://example.test/image.qcow2"},
+			Expected: false,
+		},
+		{
+			Scenario: "raw",
+			Image:    &Image{URL: "http://example.test/image.raw", DiskFormat: &raw},
+			Expected: false,
+		},
+		{
+			Scenario: "live-iso",
+			Image:    &Image{URL: "http://example.test/image.iso", DiskFormat: &liveISO},
+			Expected: true,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, tc.Image.IsLiveISO())
+		})
+	}
+}
+
+func TestHardwareDetailsSummary(t *testing.T) {
+	details := &HardwareDetails{
+		SystemVendor: HardwareSystemVendor{Manufacturer: "QEMU"},
+		Firmware:     Firmware{BIOS: BIOS{Version: "1.0"}},
+		RAMMebibytes: 4096,
+		NIC: []NIC{
+			{Name: "eth0"},
+			{Name: "eth1"},
+		},
+		Storage: []Storage{
+			{Name: "/dev/sda", SizeBytes: 100 * GibiByte},
+			{Name: "/dev/sdb", SizeBytes: 200 * GibiByte},
+		},
+		CPU: CPU{
+			Arch:           "x86_64",
+			Model:          "Core 2 Duo",
+			ClockMegahertz: 3 * GigaHertz,
+			Flags:          []string{"lm", "vmx"},
+			Count:          2,
+		},
+		Hostname: "node-0",
+	}
+
+	assert.Equal(t, &HardwareSummary{
+		SystemVendor: HardwareSystemVendor{Manufacturer: "QEMU"},
+		Firmware:     Firmware{BIOS: BIOS{Version: "1.0"}},
+		RAMMebibytes: 4096,
+		CPU: CPUSummary{
+			Arch:           "x86_64",
+			Model:          "Core 2 Duo",
+			ClockMegahertz: 3 * GigaHertz,
+			Count:          2,
+		},
+		Hostname:         "node-0",
+		NICCount:         2,
+		StorageCount:     2,
+		StorageSizeBytes: 300 * GibiByte,
+	}, details.Summary())
+}
+
+func TestInspectAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario        string
+		Annotations     map[string]string
+		ExpectedInspect bool
+		ExpectedDisable bool
+	}{
+		{
+			Scenario: "no annotation",
+		},
+		{
+			Scenario:        "inspect",
+			Annotations:     map[string]string{InspectAnnotation: ""},
+			ExpectedInspect: true,
+		},
+		{
+			Scenario:        "disabled",
+			Annotations:     map[string]string{InspectAnnotation: "disabled"},
+			ExpectedDisable: true,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := BareMetalHost{ObjectMeta: metav1.ObjectMeta{Annotations: tc.Annotations}}
+			assert.Equal(t, tc.ExpectedInspect, host.HasInspectAnnotation())
+			assert.Equal(t, tc.ExpectedDisable, host.InspectionDisabled())
+		})
+	}
+}
+
+func TestGetHardwareDetailsFromAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario      string
+		Annotations   map[string]string
+		Expected      *HardwareDetails
+		ExpectedError string
+	}{
+		{
+			Scenario: "no annotation",
+		},
+		{
+			Scenario: "valid",
+			Annotations: map[string]string{
+				HardwareDetailsAnnotation: `{"hostname": "node-0", "ramMebibytes": 4096, "storage": [{"name": "/dev/sda", "rotational": true, "sizeBytes": 53687091200}]}`,
+			},
+			Expected: &HardwareDetails{
+				Hostname:     "node-0",
+				RAMMebibytes: 4096,
+				Storage: []Storage{
+					{Name: "/dev/sda", Rotational: true, SizeBytes: 53687091200},
+				},
+			},
+		},
+		{
+			Scenario: "not json",
+			Annotations: map[string]string{
+				HardwareDetailsAnnotation: `hostname: node-0`,
+			},
+			ExpectedError: "invalid character",
+		},
+		{
+			Scenario: "unknown field",
+			Annotations: map[string]string{
+				HardwareDetailsAnnotation: `{"ram": 4096}`,
+			},
+			ExpectedError: `unknown field "ram"`,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := BareMetalHost{ObjectMeta: metav1.ObjectMeta{Annotations: tc.Annotations}}
+			details, err := host.GetHardwareDetailsFromAnnotation()
+			if tc.ExpectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, details)
+		})
+	}
+}
+
 func TestErrorCountIncrementsAlways(t *testing.T) {
 
 	b := &BareMetalHost{}
@@ -590,3 +1055,32 @@ func TestClearErrorCountOnlyIfNotZero(t *testing.T) {
 	assert.True(t, b.ClearError())
 	assert.False(t, b.ClearError())
 }
+
+func TestSetCondition(t *testing.T) {
+	host := BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "myhost",
+			Namespace:  "myns",
+			Generation: 1,
+		},
+	}
+
+	assert.True(t, host.SetCondition(ReadyCondition, metav1.ConditionFalse, "Registering", ""))
+	cond := host.GetCondition(ReadyCondition)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, int64(1), cond.ObservedGeneration)
+		assert.False(t, cond.LastTransitionTime.IsZero())
+	}
+
+	assert.False(t, host.SetCondition(ReadyCondition, metav1.ConditionFalse, "Registering", ""))
+	assert.True(t, host.SetCondition(ReadyCondition, metav1.ConditionFalse, "Inspecting", ""))
+
+	host.Generation = 2
+	assert.True(t, host.SetCondition(ReadyCondition, metav1.ConditionFalse, "Inspecting", ""))
+	assert.Equal(t, int64(2), host.GetCondition(ReadyCondition).ObservedGeneration)
+
+	assert.True(t, host.SetCondition(ReadyCondition, metav1.ConditionTrue, "Ready", ""))
+	assert.Len(t, host.Status.Conditions, 1)
+	assert.Nil(t, host.GetCondition(RegisteredCondition))
+}
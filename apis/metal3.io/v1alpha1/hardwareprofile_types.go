@@ -0,0 +1,121 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+// HardwareProfileSpec defines the settings for a class of hardware.
+type HardwareProfileSpec struct {
+	// RootDeviceHints holds the suggestions for placing the storage
+	// for the root filesystem, used when a host does not provide its
+	// own hints.
+	RootDeviceHints RootDeviceHints `json:"rootDeviceHints,omitempty"`
+
+	// RootGB is the size of the root volume in GB.
+	// +kubebuilder:validation:Minimum=0
+	RootGB int `json:"rootGB"`
+
+	// LocalGB is the size of the local disk in GB.
+	// +kubebuilder:validation:Minimum=0
+	LocalGB int `json:"localGB"`
+
+	// CPUArch is the architecture of the CPU.
+	CPUArch string `json:"cpuArch"`
+
+	// MatchRules describe the hosts this profile applies to. A
+	// profile without rules is only used when a host names it
+	// explicitly, or as the default.
+	// +optional
+	MatchRules *HardwareMatchRules `json:"matchRules,omitempty"`
+
+	// Priority breaks ties between profiles whose rules match a host
+	// equally well. The profile with the highest priority is used.
+	// +optional
+	Priority int `json:"priority,omitempty"`
+}
+
+// HardwareMatchRules holds the conditions the inspected hardware
+// details of a host must meet for a profile to apply. Every rule that
+// is set must match, and each of them adds to the score of the
+// profile, so that the most specific profile is chosen.
+type HardwareMatchRules struct {
+	// Manufacturer is a regular expression matched against the
+	// manufacturer of the system.
+	// +optional
+	Manufacturer string `json:"manufacturer,omitempty"`
+
+	// ProductName is a regular expression matched against the
+	// product name of the system.
+	// +optional
+	ProductName string `json:"productName,omitempty"`
+
+	// MinRAMMebibytes is the minimum amount of memory.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinRAMMebibytes int `json:"minRAMMebibytes,omitempty"`
+
+	// CPUArch is the required architecture of the CPU.
+	// +optional
+	CPUArch string `json:"cpuArch,omitempty"`
+
+	// MinCPUCount is the minimum number of CPUs.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinCPUCount int `json:"minCPUCount,omitempty"`
+
+	// MinDiskCount is the minimum number of disks of at least
+	// MinDiskSizeBytes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinDiskCount int `json:"minDiskCount,omitempty"`
+
+	// MinDiskSizeBytes is the minimum size of the disks counted for
+	// MinDiskCount. When it is set without MinDiskCount, at least one
+	// disk of that size is required.
+	// +optional
+	MinDiskSizeBytes Capacity `json:"minDiskSizeBytes,omitempty"`
+}
+
+// HardwareProfile is the Schema for the hardwareprofiles API
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:scope=Cluster,shortName=hwp
+// +kubebuilder:printcolumn:name="Arch",type="string",JSONPath=".spec.cpuArch",description="CPU architecture"
+// +kubebuilder:printcolumn:name="Root GB",type="integer",JSONPath=".spec.rootGB",description="Size of the root volume"
+// +kubebuilder:printcolumn:name="Local GB",type="integer",JSONPath=".spec.localGB",description="Size of the local disk"
+// +kubebuilder:object:root=true
+type HardwareProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HardwareProfileSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HardwareProfileList contains a list of HardwareProfile
+type HardwareProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HardwareProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HardwareProfile{}, &HardwareProfileList{})
+}
@@ -0,0 +1,171 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+const (
+	// HostClaimFinalizer is the name of the finalizer added to
+	// claims so the host they are bound to can be released when they
+	// are deleted.
+	HostClaimFinalizer string = "hostclaim.metal3.io"
+)
+
+// HostClaimSpec defines the host a consumer asks for.
+type HostClaimSpec struct {
+	// HostSelector selects the hosts, in the namespace of the claim,
+	// that can be bound to it. All the hosts of the namespace can be
+	// bound when it is not set.
+	// +optional
+	HostSelector *metav1.LabelSelector `json:"hostSelector,omitempty"`
+
+	// HardwareRequirements holds the minimum hardware a host must
+	// have to be bound to the claim.
+	// +optional
+	HardwareRequirements HardwareRequirements `json:"hardwareRequirements,omitempty"`
+
+	// Tolerations lets the claim be bound to hosts with matching
+	// NoSchedule taints, and keeps the host bound to it provisioned
+	// when a matching NoExecute taint is added.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// HardwareRequirements holds the minimum hardware of a host, checked
+// against its inspected hardware details. A host that has not been
+// inspected only meets empty requirements.
+type HardwareRequirements struct {
+	// MinRAMMebibytes is the minimum amount of memory.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinRAMMebibytes int `json:"minRAMMebibytes,omitempty"`
+
+	// MinCPUCount is the minimum number of CPUs.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinCPUCount int `json:"minCPUCount,omitempty"`
+
+	// MinStorageSizeBytes is the minimum size of the disk the host
+	// is provisioned on. At least one of the disks meeting the root
+	// device hints of the host must be that large.
+	// +optional
+	MinStorageSizeBytes Capacity `json:"minStorageSizeBytes,omitempty"`
+}
+
+// HostClaimStatus defines the observed state of HostClaim
+type HostClaimStatus struct {
+	// Host is the host bound to the claim, in the same namespace.
+	// +optional
+	Host *corev1.LocalObjectReference `json:"host,omitempty"`
+}
+
+// HostClaim is the Schema for the hostclaims API
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:scope=Namespaced,shortName=hc
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Host",type="string",JSONPath=".status.host.name",description="Host bound to the claim"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of HostClaim"
+// +kubebuilder:object:root=true
+type HostClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostClaimSpec   `json:"spec,omitempty"`
+	Status HostClaimStatus `json:"status,omitempty"`
+}
+
+// Consumes returns true if the host is consumed by the claim.
+func (claim *HostClaim) Consumes(host *BareMetalHost) bool {
+	ref := host.Spec.ConsumerRef
+	return ref != nil &&
+		ref.Kind == "HostClaim" &&
+		ref.Name == claim.Name &&
+		ref.Namespace == claim.Namespace &&
+		ref.UID == claim.UID
+}
+
+// ConsumerRef returns the reference set on the hosts bound to the
+// claim.
+func (claim *HostClaim) ConsumerRef() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: GroupVersion.String(),
+		Kind:       "HostClaim",
+		Name:       claim.Name,
+		Namespace:  claim.Namespace,
+		UID:        claim.UID,
+	}
+}
+
+// Matches returns true if the host is selected by the claim and its
+// hardware details meet the hardware requirements of the claim. It
+// does not check whether the host is available.
+func (claim *HostClaim) Matches(host *BareMetalHost, details *HardwareDetails) (bool, error) {
+	if host.Namespace != claim.Namespace {
+		return false, nil
+	}
+
+	if claim.Spec.HostSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(claim.Spec.HostSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(labels.Set(host.Labels)) {
+			return false, nil
+		}
+	}
+
+	requirements := claim.Spec.HardwareRequirements
+	if requirements == (HardwareRequirements{}) {
+		return true, nil
+	}
+	if details == nil {
+		return false, nil
+	}
+	if details.RAMMebibytes < requirements.MinRAMMebibytes ||
+		details.CPU.Count < requirements.MinCPUCount {
+		return false, nil
+	}
+	if requirements.MinStorageSizeBytes == 0 {
+		return true, nil
+	}
+	for i := range details.Storage {
+		disk := &details.Storage[i]
+		if disk.SizeBytes >= requirements.MinStorageSizeBytes &&
+			host.Spec.RootDeviceHints.MatchesDisk(disk) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// +kubebuilder:object:root=true
+
+// HostClaimList contains a list of HostClaim
+type HostClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HostClaim{}, &HostClaimList{})
+}
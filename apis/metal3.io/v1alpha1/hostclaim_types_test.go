@@ -0,0 +1,207 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHostClaimMatches(t *testing.T) {
+	details := &HardwareDetails{
+		RAMMebibytes: 16384,
+		CPU:          CPU{Count: 8},
+		Storage: []Storage{
+			{Name: "/dev/sda", SizeBytes: 100 * GigaByte, Rotational: true},
+			{Name: "/dev/sdb", SizeBytes: 500 * GigaByte},
+		},
+	}
+	smallDisks := &HardwareDetails{
+		Storage: []Storage{
+			{Name: "/dev/sda", SizeBytes: 100 * GigaByte},
+			{Name: "/dev/sdb", SizeBytes: 100 * GigaByte},
+			{Name: "/dev/sdc", SizeBytes: 100 * GigaByte},
+			{Name: "/dev/sdd", SizeBytes: 100 * GigaByte},
+			{Name: "/dev/sde", SizeBytes: 100 * GigaByte},
+		},
+	}
+	rotational := true
+
+	testCases := []struct {
+		Scenario string
+		Spec     HostClaimSpec
+		Labels   map[string]string
+		Hints    *RootDeviceHints
+		Details  *HardwareDetails
+		Expected bool
+	}{
+		{
+			Scenario: "empty claim",
+			Expected: true,
+		},
+		{
+			Scenario: "selector matches",
+			Spec: HostClaimSpec{
+				HostSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"rack": "a"},
+				},
+			},
+			Labels:   map[string]string{"rack": "a"},
+			Expected: true,
+		},
+		{
+			Scenario: "selector does not match",
+			Spec: HostClaimSpec{
+				HostSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"rack": "a"},
+				},
+			},
+			Labels:   map[string]string{"rack": "b"},
+			Expected: false,
+		},
+		{
+			Scenario: "requirements met",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinRAMMebibytes:     8192,
+					MinCPUCount:         8,
+					MinStorageSizeBytes: 100 * GigaByte,
+				},
+			},
+			Details:  details,
+			Expected: true,
+		},
+		{
+			Scenario: "not enough memory",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinRAMMebibytes: 32768,
+				},
+			},
+			Details:  details,
+			Expected: false,
+		},
+		{
+			Scenario: "not enough CPUs",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinCPUCount: 16,
+				},
+			},
+			Details:  details,
+			Expected: false,
+		},
+		{
+			Scenario: "not enough storage",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinStorageSizeBytes: TeraByte,
+				},
+			},
+			Details:  details,
+			Expected: false,
+		},
+		{
+			Scenario: "storage on one disk",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinStorageSizeBytes: 500 * GigaByte,
+				},
+			},
+			Details:  details,
+			Expected: true,
+		},
+		{
+			Scenario: "storage spread over several disks",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinStorageSizeBytes: 500 * GigaByte,
+				},
+			},
+			Details:  smallDisks,
+			Expected: false,
+		},
+		{
+			Scenario: "large disk matches root device hints",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinStorageSizeBytes: 500 * GigaByte,
+				},
+			},
+			Hints:    &RootDeviceHints{DeviceName: "/dev/sdb"},
+			Details:  details,
+			Expected: true,
+		},
+		{
+			Scenario: "large disk does not match root device hints",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinStorageSizeBytes: 500 * GigaByte,
+				},
+			},
+			Hints:    &RootDeviceHints{Rotational: &rotational},
+			Details:  details,
+			Expected: false,
+		},
+		{
+			Scenario: "requirements without inspection",
+			Spec: HostClaimSpec{
+				HardwareRequirements: HardwareRequirements{
+					MinCPUCount: 1,
+				},
+			},
+			Expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			claim := HostClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "myclaim", Namespace: "myns"},
+				Spec:       tc.Spec,
+			}
+			host := BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+					Labels:    tc.Labels,
+				},
+				Spec: BareMetalHostSpec{RootDeviceHints: tc.Hints},
+			}
+
+			matches, err := claim.Matches(&host, tc.Details)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, matches)
+		})
+	}
+}
+
+func TestHostClaimMatchesOtherNamespace(t *testing.T) {
+	claim := HostClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "myclaim", Namespace: "myns"},
+	}
+	host := BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "myhost", Namespace: "otherns"},
+	}
+
+	matches, err := claim.Matches(&host, nil)
+	assert.NoError(t, err)
+	assert.False(t, matches)
+}
+
+func TestHostClaimConsumes(t *testing.T) {
+	claim := HostClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "myclaim", Namespace: "myns", UID: "1234"},
+	}
+	host := BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "myhost", Namespace: "myns"},
+	}
+	assert.False(t, claim.Consumes(&host))
+
+	host.Spec.ConsumerRef = claim.ConsumerRef()
+	assert.True(t, claim.Consumes(&host))
+
+	host.Spec.ConsumerRef.UID = "5678"
+	assert.False(t, claim.Consumes(&host), "claim recreated with the same name")
+}
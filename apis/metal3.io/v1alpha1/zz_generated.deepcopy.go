@@ -22,7 +22,8 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -130,6 +131,47 @@ func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
 		*out = new(RootDeviceHints)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RAID != nil {
+		in, out := &in.RAID, &out.RAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FirmwareUpdates != nil {
+		in, out := &in.FirmwareUpdates, &out.FirmwareUpdates
+		*out = make([]FirmwareUpdate, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomDeploySteps != nil {
+		in, out := &in.CustomDeploySteps, &out.CustomDeploySteps
+		*out = make([]CustomDeployStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CustomCleanSteps != nil {
+		in, out := &in.CustomCleanSteps, &out.CustomCleanSteps
+		*out = make([]CustomCleanStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]NICConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.PortGroups != nil {
+		in, out := &in.PortGroups, &out.PortGroups
+		*out = make([]PortGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ConsumerRef != nil {
 		in, out := &in.ConsumerRef, &out.ConsumerRef
 		*out = new(v1.ObjectReference)
@@ -179,10 +221,34 @@ func (in *BareMetalHostStatus) DeepCopyInto(out *BareMetalHostStatus) {
 		*out = new(HardwareDetails)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HardwareSummary != nil {
+		in, out := &in.HardwareSummary, &out.HardwareSummary
+		*out = new(HardwareSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HardwareData != nil {
+		in, out := &in.HardwareData, &out.HardwareData
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 	in.Provisioning.DeepCopyInto(&out.Provisioning)
 	in.GoodCredentials.DeepCopyInto(&out.GoodCredentials)
 	in.TriedCredentials.DeepCopyInto(&out.TriedCredentials)
 	in.OperationHistory.DeepCopyInto(&out.OperationHistory)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]v1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostStatus.
@@ -215,6 +281,21 @@ func (in *CPU) DeepCopy() *CPU {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CPUSummary) DeepCopyInto(out *CPUSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUSummary.
+func (in *CPUSummary) DeepCopy() *CPUSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CPUSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
 	*out = *in
@@ -235,10 +316,64 @@ func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomCleanStep) DeepCopyInto(out *CustomCleanStep) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomCleanStep.
+func (in *CustomCleanStep) DeepCopy() *CustomCleanStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomCleanStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDeployStep) DeepCopyInto(out *CustomDeployStep) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDeployStep.
+func (in *CustomDeployStep) DeepCopy() *CustomDeployStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDeployStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Firmware) DeepCopyInto(out *Firmware) {
 	*out = *in
 	out.BIOS = in.BIOS
+	if in.BIOSSettings != nil {
+		in, out := &in.BIOSSettings, &out.BIOSSettings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]FirmwareComponentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firmware.
@@ -251,11 +386,165 @@ func (in *Firmware) DeepCopy() *Firmware {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareComponentStatus) DeepCopyInto(out *FirmwareComponentStatus) {
+	*out = *in
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareComponentStatus.
+func (in *FirmwareComponentStatus) DeepCopy() *FirmwareComponentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareComponentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareConfig) DeepCopyInto(out *FirmwareConfig) {
+	*out = *in
+	if in.VirtualizationEnabled != nil {
+		in, out := &in.VirtualizationEnabled, &out.VirtualizationEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SimultaneousMultithreadingEnabled != nil {
+		in, out := &in.SimultaneousMultithreadingEnabled, &out.SimultaneousMultithreadingEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SriovEnabled != nil {
+		in, out := &in.SriovEnabled, &out.SriovEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareConfig.
+func (in *FirmwareConfig) DeepCopy() *FirmwareConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareUpdate) DeepCopyInto(out *FirmwareUpdate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareUpdate.
+func (in *FirmwareUpdate) DeepCopy() *FirmwareUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareData) DeepCopyInto(out *HardwareData) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareData.
+func (in *HardwareData) DeepCopy() *HardwareData {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HardwareData) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDataList) DeepCopyInto(out *HardwareDataList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HardwareData, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDataList.
+func (in *HardwareDataList) DeepCopy() *HardwareDataList {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDataList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HardwareDataList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDataSpec) DeepCopyInto(out *HardwareDataSpec) {
+	*out = *in
+	if in.HardwareDetails != nil {
+		in, out := &in.HardwareDetails, &out.HardwareDetails
+		*out = new(HardwareDetails)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDataSpec.
+func (in *HardwareDataSpec) DeepCopy() *HardwareDataSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDataSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
 	*out = *in
 	out.SystemVendor = in.SystemVendor
-	out.Firmware = in.Firmware
+	in.Firmware.DeepCopyInto(&out.Firmware)
 	if in.NIC != nil {
 		in, out := &in.NIC, &out.NIC
 		*out = make([]NIC, len(*in))
@@ -281,6 +570,168 @@ func (in *HardwareDetails) DeepCopy() *HardwareDetails {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareMatchRules) DeepCopyInto(out *HardwareMatchRules) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareMatchRules.
+func (in *HardwareMatchRules) DeepCopy() *HardwareMatchRules {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareMatchRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareProfile) DeepCopyInto(out *HardwareProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareProfile.
+func (in *HardwareProfile) DeepCopy() *HardwareProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HardwareProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareProfileList) DeepCopyInto(out *HardwareProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HardwareProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareProfileList.
+func (in *HardwareProfileList) DeepCopy() *HardwareProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HardwareProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareProfileSpec) DeepCopyInto(out *HardwareProfileSpec) {
+	*out = *in
+	in.RootDeviceHints.DeepCopyInto(&out.RootDeviceHints)
+	if in.MatchRules != nil {
+		in, out := &in.MatchRules, &out.MatchRules
+		*out = new(HardwareMatchRules)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareProfileSpec.
+func (in *HardwareProfileSpec) DeepCopy() *HardwareProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareRAIDVolume) DeepCopyInto(out *HardwareRAIDVolume) {
+	*out = *in
+	if in.SizeGibibytes != nil {
+		in, out := &in.SizeGibibytes, &out.SizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PhysicalDisks != nil {
+		in, out := &in.PhysicalDisks, &out.PhysicalDisks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NumberOfPhysicalDisks != nil {
+		in, out := &in.NumberOfPhysicalDisks, &out.NumberOfPhysicalDisks
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRAIDVolume.
+func (in *HardwareRAIDVolume) DeepCopy() *HardwareRAIDVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareRAIDVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareRequirements) DeepCopyInto(out *HardwareRequirements) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRequirements.
+func (in *HardwareRequirements) DeepCopy() *HardwareRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSummary) DeepCopyInto(out *HardwareSummary) {
+	*out = *in
+	out.SystemVendor = in.SystemVendor
+	in.Firmware.DeepCopyInto(&out.Firmware)
+	out.CPU = in.CPU
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSummary.
+func (in *HardwareSummary) DeepCopy() *HardwareSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HardwareSystemVendor) DeepCopyInto(out *HardwareSystemVendor) {
 	*out = *in
@@ -296,6 +747,113 @@ func (in *HardwareSystemVendor) DeepCopy() *HardwareSystemVendor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostClaim) DeepCopyInto(out *HostClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostClaim.
+func (in *HostClaim) DeepCopy() *HostClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(HostClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostClaimList) DeepCopyInto(out *HostClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostClaimList.
+func (in *HostClaimList) DeepCopy() *HostClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostClaimSpec) DeepCopyInto(out *HostClaimSpec) {
+	*out = *in
+	if in.HostSelector != nil {
+		in, out := &in.HostSelector, &out.HostSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.HardwareRequirements = in.HardwareRequirements
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostClaimSpec.
+func (in *HostClaimSpec) DeepCopy() *HostClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostClaimStatus) DeepCopyInto(out *HostClaimStatus) {
+	*out = *in
+	if in.Host != nil {
+		in, out := &in.Host, &out.Host
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostClaimStatus.
+func (in *HostClaimStatus) DeepCopy() *HostClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Image) DeepCopyInto(out *Image) {
 	*out = *in
@@ -336,6 +894,21 @@ func (in *NIC) DeepCopy() *NIC {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NICConfig) DeepCopyInto(out *NICConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICConfig.
+func (in *NICConfig) DeepCopy() *NICConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NICConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
 	*out = *in
@@ -372,6 +945,33 @@ func (in *OperationMetric) DeepCopy() *OperationMetric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortGroup) DeepCopyInto(out *PortGroup) {
+	*out = *in
+	if in.MACAddresses != nil {
+		in, out := &in.MACAddresses, &out.MACAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortGroup.
+func (in *PortGroup) DeepCopy() *PortGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(PortGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
 	*out = *in
@@ -381,6 +981,41 @@ func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
 		*out = new(RootDeviceHints)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RAID != nil {
+		in, out := &in.RAID, &out.RAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingRAID != nil {
+		in, out := &in.PendingRAID, &out.PendingRAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingFirmware != nil {
+		in, out := &in.PendingFirmware, &out.PendingFirmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FirmwareUpdates != nil {
+		in, out := &in.FirmwareUpdates, &out.FirmwareUpdates
+		*out = make([]FirmwareUpdate, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeploySteps != nil {
+		in, out := &in.DeploySteps, &out.DeploySteps
+		*out = new(StepsStatus)
+		**out = **in
+	}
+	if in.CleanSteps != nil {
+		in, out := &in.CleanSteps, &out.CleanSteps
+		*out = new(StepsStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionStatus.
@@ -393,6 +1028,35 @@ func (in *ProvisionStatus) DeepCopy() *ProvisionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RAIDConfig) DeepCopyInto(out *RAIDConfig) {
+	*out = *in
+	if in.HardwareRAIDVolumes != nil {
+		in, out := &in.HardwareRAIDVolumes, &out.HardwareRAIDVolumes
+		*out = make([]HardwareRAIDVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SoftwareRAIDVolumes != nil {
+		in, out := &in.SoftwareRAIDVolumes, &out.SoftwareRAIDVolumes
+		*out = make([]SoftwareRAIDVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAIDConfig.
+func (in *RAIDConfig) DeepCopy() *RAIDConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RAIDConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RootDeviceHints) DeepCopyInto(out *RootDeviceHints) {
 	*out = *in
@@ -413,6 +1077,48 @@ func (in *RootDeviceHints) DeepCopy() *RootDeviceHints {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SoftwareRAIDVolume) DeepCopyInto(out *SoftwareRAIDVolume) {
+	*out = *in
+	if in.SizeGibibytes != nil {
+		in, out := &in.SizeGibibytes, &out.SizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.PhysicalDisks != nil {
+		in, out := &in.PhysicalDisks, &out.PhysicalDisks
+		*out = make([]RootDeviceHints, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SoftwareRAIDVolume.
+func (in *SoftwareRAIDVolume) DeepCopy() *SoftwareRAIDVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(SoftwareRAIDVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepsStatus) DeepCopyInto(out *StepsStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepsStatus.
+func (in *StepsStatus) DeepCopy() *StepsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StepsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
@@ -0,0 +1,129 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// ConversionDataAnnotation holds the values of v1alpha1 fields that
+// cannot be represented in v1alpha2, so that converting a host to
+// v1alpha2 and back does not lose them.
+const ConversionDataAnnotation = "baremetalhost.metal3.io/conversion-data"
+
+// conversionData is the content of ConversionDataAnnotation.
+type conversionData struct {
+	// HardwareProfile is the deprecated spec.hardwareProfile field.
+	HardwareProfile string `json:"hardwareProfile,omitempty"`
+
+	// HardwareDetails is the deprecated status.hardware field,
+	// holding the hardware details of hosts inspected before they
+	// were kept in a HardwareData resource.
+	HardwareDetails *v1alpha1.HardwareDetails `json:"hardware,omitempty"`
+
+	// ProvisioningState is the v1alpha1 provisioning state when it is
+	// reported differently in v1alpha2, such as available.
+	ProvisioningState v1alpha1.ProvisioningState `json:"provisioningState,omitempty"`
+}
+
+// convertFields copies src to dst through their JSON representation,
+// relying on the fields the two versions have in common using the
+// same names.
+func convertFields(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// ConvertTo converts this BareMetalHost to the hub version.
+func (src *BareMetalHost) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.BareMetalHost)
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertFields(&src.Spec, &dst.Spec); err != nil {
+		return errors.Wrap(err, "failed to convert spec")
+	}
+	if err := convertFields(&src.Status, &dst.Status); err != nil {
+		return errors.Wrap(err, "failed to convert status")
+	}
+
+	raw, ok := dst.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return nil
+	}
+	delete(dst.Annotations, ConversionDataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	var restored conversionData
+	if err := json.Unmarshal([]byte(raw), &restored); err != nil {
+		return errors.Wrap(err, "failed to parse conversion data annotation")
+	}
+	dst.Spec.HardwareProfile = restored.HardwareProfile
+	dst.Status.HardwareDetails = restored.HardwareDetails
+	// Only restore the state when it was not changed through v1alpha2.
+	if restored.ProvisioningState == v1alpha1.StateAvailable &&
+		dst.Status.Provisioning.State == v1alpha1.StateReady {
+		dst.Status.Provisioning.State = restored.ProvisioningState
+	}
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this BareMetalHost.
+func (dst *BareMetalHost) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.BareMetalHost)
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	if err := convertFields(&src.Spec, &dst.Spec); err != nil {
+		return errors.Wrap(err, "failed to convert spec")
+	}
+	if err := convertFields(&src.Status, &dst.Status); err != nil {
+		return errors.Wrap(err, "failed to convert status")
+	}
+
+	saved := conversionData{
+		HardwareProfile: src.Spec.HardwareProfile,
+		HardwareDetails: src.Status.HardwareDetails,
+	}
+
+	// The available state was only ever a synonym for ready.
+	if src.Status.Provisioning.State == v1alpha1.StateAvailable {
+		dst.Status.Provisioning.State = StateReady
+		saved.ProvisioningState = src.Status.Provisioning.State
+	}
+
+	if saved.HardwareProfile == "" && saved.HardwareDetails == nil && saved.ProvisioningState == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(saved)
+	if err != nil {
+		return errors.Wrap(err, "failed to save conversion data")
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = make(map[string]string)
+	}
+	dst.Annotations[ConversionDataAnnotation] = string(raw)
+	return nil
+}
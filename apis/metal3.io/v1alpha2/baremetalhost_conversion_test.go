@@ -0,0 +1,290 @@
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func hubHost() *v1alpha1.BareMetalHost {
+	return &v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			Annotations: map[string]string{
+				v1alpha1.PausedAnnotation: "",
+			},
+		},
+		Spec: v1alpha1.BareMetalHostSpec{
+			BMC: v1alpha1.BMCDetails{
+				Address:         "ipmi://192.168.122.1:6233",
+				CredentialsName: "bmc-creds",
+			},
+			BootMode: v1alpha1.Legacy,
+			Online:   true,
+			Image: &v1alpha1.Image{
+				URL:          "http://example.com/image.qcow2",
+				Checksum:     "abcd",
+				ChecksumType: v1alpha1.SHA256,
+			},
+			UserData: &corev1.SecretReference{Name: "user-data"},
+		},
+		Status: v1alpha1.BareMetalHostStatus{
+			OperationalStatus: v1alpha1.OperationalStatusOK,
+			HardwareProfile:   "libvirt",
+			PoweredOn:         true,
+			Provisioning: v1alpha1.ProvisionStatus{
+				State: v1alpha1.StateProvisioned,
+				ID:    "node-id",
+			},
+			HardwareSummary: &v1alpha1.HardwareSummary{
+				CPU:              v1alpha1.CPUSummary{Arch: "x86_64", Count: 4},
+				RAMMebibytes:     4096,
+				NICCount:         1,
+				StorageCount:     1,
+				StorageSizeBytes: 50 * v1alpha1.GibiByte,
+			},
+			HardwareData: &corev1.LocalObjectReference{Name: "myhost"},
+		},
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Mutate   func(*v1alpha1.BareMetalHost)
+	}{
+		{
+			Scenario: "common fields",
+			Mutate:   func(*v1alpha1.BareMetalHost) {},
+		},
+		{
+			Scenario: "hardware profile",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Spec.HardwareProfile = "libvirt"
+			},
+		},
+		{
+			Scenario: "RAID",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Spec.RAID = &v1alpha1.RAIDConfig{
+					SoftwareRAIDVolumes: []v1alpha1.SoftwareRAIDVolume{
+						{
+							Level: "1",
+							PhysicalDisks: []v1alpha1.RootDeviceHints{
+								{DeviceName: "/dev/sda"},
+							},
+						},
+					},
+				}
+				h.Status.Provisioning.RAID = h.Spec.RAID.DeepCopy()
+			},
+		},
+		{
+			Scenario: "firmware",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				enabled := true
+				h.Spec.Firmware = &v1alpha1.FirmwareConfig{
+					SriovEnabled: &enabled,
+					Settings:     map[string]string{"BootMode": "Uefi"},
+				}
+				h.Status.Provisioning.Firmware = h.Spec.Firmware.DeepCopy()
+				h.Status.HardwareSummary.Firmware.BIOSSettings = map[string]string{
+					"SriovGlobalEnable": "Enabled",
+				}
+			},
+		},
+		{
+			Scenario: "firmware updates",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Spec.FirmwareUpdates = []v1alpha1.FirmwareUpdate{
+					{
+						Component: v1alpha1.BMCFirmwareComponent,
+						URL:       "http://example.com/bmc.bin",
+						Checksum:  "abcd",
+					},
+				}
+				h.Status.Provisioning.FirmwareUpdates = h.Spec.FirmwareUpdates
+				h.Status.HardwareSummary.Firmware.Components = []v1alpha1.FirmwareComponentStatus{
+					{Component: "bmc", InitialVersion: "1.0", CurrentVersion: "1.1"},
+				}
+			},
+		},
+		{
+			Scenario: "automated cleaning mode",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Spec.AutomatedCleaningMode = v1alpha1.CleaningModeDisabled
+			},
+		},
+		{
+			Scenario: "live ISO",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				liveISO := v1alpha1.LiveISODiskFormat
+				h.Spec.Image = &v1alpha1.Image{
+					URL:        "http://example.test/image.iso",
+					DiskFormat: &liveISO,
+				}
+			},
+		},
+		{
+			Scenario: "custom steps",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Spec.CustomDeploySteps = []v1alpha1.CustomDeployStep{
+					{
+						Interface: "deploy",
+						Step:      "install_vendor_tools",
+						Args:      &runtime.RawExtension{Raw: []byte(`{"version":"2.1"}`)},
+						Priority:  70,
+					},
+				}
+				h.Spec.CustomCleanSteps = []v1alpha1.CustomCleanStep{
+					{Interface: "deploy", Step: "burnin_memory"},
+				}
+				h.Status.Provisioning.DeploySteps = &v1alpha1.StepsStatus{
+					Current:   "deploy.install_vendor_tools",
+					Completed: 0,
+					Total:     1,
+				}
+			},
+		},
+		{
+			Scenario: "hardware details not yet moved to HardwareData",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Status.HardwareDetails = &v1alpha1.HardwareDetails{
+					RAMMebibytes: 4096,
+					NIC: []v1alpha1.NIC{
+						{Name: "eth0", MAC: "00:11:22:33:44:55", IP: "192.168.111.20", VLANID: 100},
+					},
+					Hostname: "myhost",
+				}
+				h.Status.HardwareData = nil
+			},
+		},
+		{
+			Scenario: "available state and NIC VLAN ID",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Status.Provisioning.State = v1alpha1.StateAvailable
+				h.Status.HardwareDetails = &v1alpha1.HardwareDetails{
+					NIC: []v1alpha1.NIC{
+						{Name: "eth0", MAC: "00:11:22:33:44:55", VLANID: 100},
+						{Name: "eth1", MAC: "00:11:22:33:44:66", VLANID: 200,
+							VLANs: []v1alpha1.VLAN{{ID: 300, Name: "storage"}}},
+					},
+				}
+			},
+		},
+		{
+			Scenario: "no annotations",
+			Mutate: func(h *v1alpha1.BareMetalHost) {
+				h.Annotations = nil
+				h.Spec.HardwareProfile = "libvirt"
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			hub := hubHost()
+			tc.Mutate(hub)
+
+			spoke := &BareMetalHost{}
+			if err := spoke.ConvertFrom(hub.DeepCopy()); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, hub.Spec.BMC.Address, spoke.Spec.BMC.Address)
+			if hub.Status.Provisioning.State != v1alpha1.StateAvailable {
+				assert.Equal(t, string(hub.Status.Provisioning.State), string(spoke.Status.Provisioning.State))
+			}
+
+			result := &v1alpha1.BareMetalHost{}
+			if err := spoke.ConvertTo(result); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, hub, result)
+		})
+	}
+}
+
+func TestConvertFromAvailable(t *testing.T) {
+	testCases := []struct {
+		Scenario      string
+		SpokeState    ProvisioningState
+		ExpectedState v1alpha1.ProvisioningState
+	}{
+		{
+			Scenario:      "unchanged",
+			SpokeState:    StateReady,
+			ExpectedState: v1alpha1.StateAvailable,
+		},
+		{
+			Scenario:      "changed through v1alpha2",
+			SpokeState:    StateProvisioning,
+			ExpectedState: v1alpha1.StateProvisioning,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			hub := hubHost()
+			hub.Status.Provisioning.State = v1alpha1.StateAvailable
+
+			spoke := &BareMetalHost{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, StateReady, spoke.Status.Provisioning.State)
+			assert.Contains(t, spoke.Annotations, ConversionDataAnnotation)
+
+			spoke.Status.Provisioning.State = tc.SpokeState
+			result := &v1alpha1.BareMetalHost{}
+			if err := spoke.ConvertTo(result); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.ExpectedState, result.Status.Provisioning.State)
+			assert.NotContains(t, result.Annotations, ConversionDataAnnotation)
+		})
+	}
+}
+
+func TestConvertToWithoutConversionData(t *testing.T) {
+	spoke := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{Name: "myhost"},
+		Spec: BareMetalHostSpec{
+			BMC: BMCDetails{
+				Address:         "redfish://192.168.122.1/redfish/v1/Systems/1",
+				CredentialsName: "bmc-creds",
+			},
+			Online: true,
+		},
+	}
+
+	hub := &v1alpha1.BareMetalHost{}
+	if err := spoke.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "myhost", hub.Name)
+	assert.Equal(t, spoke.Spec.BMC.Address, hub.Spec.BMC.Address)
+	assert.True(t, hub.Spec.Online)
+	assert.Equal(t, "", hub.Spec.HardwareProfile)
+	assert.Nil(t, hub.Annotations)
+}
+
+func TestConvertToInvalidConversionData(t *testing.T) {
+	spoke := &BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myhost",
+			Annotations: map[string]string{
+				ConversionDataAnnotation: "not json",
+			},
+		},
+	}
+
+	hub := &v1alpha1.BareMetalHost{}
+	assert.Error(t, spoke.ConvertTo(hub))
+}
@@ -0,0 +1,964 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have
+// json tags for the fields to be serialized.
+
+// NOTE(dhellmann): Update docs/api.md when changing these data structure.
+
+const (
+	// BareMetalHostFinalizer is the name of the finalizer added to
+	// hosts to block delete operations until the physical host can be
+	// deprovisioned.
+	BareMetalHostFinalizer string = "baremetalhost.metal3.io"
+
+	// PausedAnnotation is the annotation that pauses the reconciliation (triggers
+	// an immediate requeue)
+	PausedAnnotation = "baremetalhost.metal3.io/paused"
+
+	// StatusAnnotation is the annotation that keeps a copy of the Status of BMH
+	// This is particularly useful when we pivot BMH. If the status
+	// annotation is present and status is empty, BMO will reconstruct BMH Status
+	// from the status annotation.
+	StatusAnnotation = "baremetalhost.metal3.io/status"
+)
+
+// RootDeviceHints holds the hints for specifying the storage location
+// for the root filesystem for the image.
+type RootDeviceHints struct {
+	// A Linux device name like "/dev/vda". The hint must match the
+	// actual value exactly.
+	DeviceName string `json:"deviceName,omitempty"`
+
+	// A SCSI bus address like 0:0:0:0. The hint must match the actual
+	// value exactly.
+	HCTL string `json:"hctl,omitempty"`
+
+	// A vendor-specific device identifier. The hint can be a
+	// substring of the actual value.
+	Model string `json:"model,omitempty"`
+
+	// The name of the vendor or manufacturer of the device. The hint
+	// can be a substring of the actual value.
+	Vendor string `json:"vendor,omitempty"`
+
+	// Device serial number. The hint must match the actual value
+	// exactly.
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// The minimum size of the device in Gigabytes.
+	// +kubebuilder:validation:Minimum=0
+	MinSizeGigabytes int `json:"minSizeGigabytes,omitempty"`
+
+	// Unique storage identifier. The hint must match the actual value
+	// exactly.
+	WWN string `json:"wwn,omitempty"`
+
+	// Unique storage identifier with the vendor extension
+	// appended. The hint must match the actual value exactly.
+	WWNWithExtension string `json:"wwnWithExtension,omitempty"`
+
+	// Unique vendor storage identifier. The hint must match the
+	// actual value exactly.
+	WWNVendorExtension string `json:"wwnVendorExtension,omitempty"`
+
+	// True if the device should use spinning media, false otherwise.
+	Rotational *bool `json:"rotational,omitempty"`
+}
+
+// RAIDConfig describes the RAID volumes to create on the host before
+// it becomes ready. Hardware and software RAID cannot be combined.
+type RAIDConfig struct {
+	// HardwareRAIDVolumes lists the logical disks to create with the
+	// RAID controller of the host.
+	// +optional
+	HardwareRAIDVolumes []HardwareRAIDVolume `json:"hardwareRAIDVolumes,omitempty"`
+
+	// SoftwareRAIDVolumes lists the software RAID devices to create.
+	// The first device holds the image, so it must be a RAID-1.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	SoftwareRAIDVolumes []SoftwareRAIDVolume `json:"softwareRAIDVolumes,omitempty"`
+}
+
+// HardwareRAIDVolume describes a logical disk created by the RAID
+// controller of the host.
+type HardwareRAIDVolume struct {
+	// Name of the volume. It should be unique on the host. A name is
+	// generated when it is not set.
+	// +kubebuilder:validation:MaxLength=64
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Level is the RAID level of the volume.
+	// +kubebuilder:validation:Enum="0";"1";"2";"5";"6";"1+0";"5+0";"6+0"
+	Level string `json:"level"`
+
+	// SizeGibibytes is the size of the volume. The volume takes all
+	// of the space available when it is not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SizeGibibytes *int `json:"sizeGibibytes,omitempty"`
+
+	// Rotational selects spinning disks when true and solid-state
+	// disks when false. Any kind of disk is used when it is not set.
+	// +optional
+	Rotational *bool `json:"rotational,omitempty"`
+
+	// Controller is the name of the RAID controller to use.
+	// +optional
+	Controller string `json:"controller,omitempty"`
+
+	// PhysicalDisks lists the names of the physical disks to use, as
+	// known to the RAID controller.
+	// +optional
+	PhysicalDisks []string `json:"physicalDisks,omitempty"`
+
+	// NumberOfPhysicalDisks is the number of physical disks to use.
+	// It defaults to the minimum number needed by the RAID level.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumberOfPhysicalDisks *int `json:"numberOfPhysicalDisks,omitempty"`
+}
+
+// SoftwareRAIDVolume describes a software RAID device.
+type SoftwareRAIDVolume struct {
+	// Level is the RAID level of the device.
+	// +kubebuilder:validation:Enum="0";"1";"1+0"
+	Level string `json:"level"`
+
+	// SizeGibibytes is the size of the device. The device takes all
+	// of the space available when it is not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SizeGibibytes *int `json:"sizeGibibytes,omitempty"`
+
+	// PhysicalDisks holds hints selecting the disks to use. All of
+	// the disks are used when it is not set.
+	// +optional
+	PhysicalDisks []RootDeviceHints `json:"physicalDisks,omitempty"`
+}
+
+// FirmwareConfig holds the BIOS settings to apply to the host before
+// it becomes ready.
+type FirmwareConfig struct {
+	// VirtualizationEnabled turns the virtualization extensions of
+	// the CPU on or off.
+	// +optional
+	VirtualizationEnabled *bool `json:"virtualizationEnabled,omitempty"`
+
+	// SimultaneousMultithreadingEnabled turns simultaneous
+	// multithreading, also known as hyperthreading, on or off.
+	// +optional
+	SimultaneousMultithreadingEnabled *bool `json:"simultaneousMultithreadingEnabled,omitempty"`
+
+	// SriovEnabled turns SR-IOV support on or off.
+	// +optional
+	SriovEnabled *bool `json:"sriovEnabled,omitempty"`
+
+	// Settings holds vendor specific BIOS settings by name. They are
+	// applied after the settings above, so they take precedence.
+	// +optional
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// FirmwareComponent is a part of the host with its own firmware.
+// +kubebuilder:validation:Enum=bios;bmc;nic
+type FirmwareComponent string
+
+// Firmware components that can be updated
+const (
+	BIOSFirmwareComponent FirmwareComponent = "bios"
+	BMCFirmwareComponent  FirmwareComponent = "bmc"
+	NICFirmwareComponent  FirmwareComponent = "nic"
+)
+
+// FirmwareUpdate describes a firmware image to install on a component
+// of the host.
+type FirmwareUpdate struct {
+	// Component is the part of the host the image is for.
+	Component FirmwareComponent `json:"component"`
+
+	// URL is the location of the firmware image.
+	URL string `json:"url"`
+
+	// Checksum is the checksum of the firmware image, kept for
+	// reference. The firmware update step of the provisioner does not
+	// take a checksum, the BMC checks the images it installs.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// BootMode is the boot mode of the system
+// +kubebuilder:validation:Enum=UEFI;legacy
+type BootMode string
+
+// Allowed boot mode from metal3
+const (
+	UEFI            BootMode = "UEFI"
+	Legacy          BootMode = "legacy"
+	DefaultBootMode BootMode = UEFI
+)
+
+// AutomatedCleaningMode selects how the disks of a host are cleaned
+// +kubebuilder:validation:Enum=disabled;metadata;full
+type AutomatedCleaningMode string
+
+// Allowed automated cleaning modes
+const (
+	// CleaningModeDisabled skips cleaning the host entirely.
+	CleaningModeDisabled AutomatedCleaningMode = "disabled"
+	// CleaningModeMetadata runs the automated cleaning configured in
+	// the provisioning backend, which removes the partition tables
+	// of the disks.
+	CleaningModeMetadata AutomatedCleaningMode = "metadata"
+	// CleaningModeFull also erases the whole content of the disks.
+	CleaningModeFull AutomatedCleaningMode = "full"
+
+	DefaultCleaningMode AutomatedCleaningMode = CleaningModeMetadata
+)
+
+// OperationalStatus represents the state of the host
+type OperationalStatus string
+
+const (
+	// OperationalStatusOK is the status value for when the host is
+	// configured correctly and is manageable.
+	OperationalStatusOK OperationalStatus = "OK"
+
+	// OperationalStatusDiscovered is the status value for when the
+	// host is only partially configured, such as when when the BMC
+	// address is known but the login credentials are not.
+	OperationalStatusDiscovered OperationalStatus = "discovered"
+
+	// OperationalStatusError is the status value for when the host
+	// has any sort of error.
+	OperationalStatusError OperationalStatus = "error"
+
+	// OperationalStatusDetached is the status value for when the host
+	// has been removed from the provisioner by the detached
+	// annotation, without being deprovisioned.
+	OperationalStatusDetached OperationalStatus = "detached"
+)
+
+// ErrorType indicates the class of problem that has caused the Host resource
+// to enter an error state.
+type ErrorType string
+
+const (
+	// RegistrationError is an error condition occurring when the
+	// controller is unable to connect to the Host's baseboard management
+	// controller.
+	RegistrationError ErrorType = "registration error"
+	// InspectionError is an error condition occurring when an attempt to
+	// obtain hardware details from the Host fails.
+	InspectionError ErrorType = "inspection error"
+	// ProvisioningError is an error condition occuring when the controller
+	// fails to provision or deprovision the Host.
+	ProvisioningError ErrorType = "provisioning error"
+	// PowerManagementError is an error condition occurring when the
+	// controller is unable to modify the power state of the Host.
+	PowerManagementError ErrorType = "power management error"
+	// PreparationError is an error condition occurring when the
+	// controller fails to apply the settings, such as the RAID
+	// configuration, that must be in place before the host is ready.
+	PreparationError ErrorType = "preparation error"
+	// FirmwareUpdateError is an error condition occurring when the
+	// controller fails to install the firmware images listed in the
+	// spec of the host.
+	FirmwareUpdateError ErrorType = "firmware update error"
+	// IgnitionError is an error condition occurring when the Ignition
+	// config in the user data of the Host is invalid.
+	IgnitionError ErrorType = "ignition error"
+)
+
+// Condition types reported in the Conditions field of the host
+// status.
+const (
+	// RegisteredCondition is true when the host is known to the
+	// provisioning backend.
+	RegisteredCondition string = "Registered"
+
+	// InspectedCondition is true when the hardware details of the
+	// host have been collected.
+	InspectedCondition string = "Inspected"
+
+	// ProvisionedCondition is true when an image has been written to
+	// the host, or when the host is externally provisioned.
+	ProvisionedCondition string = "Provisioned"
+
+	// PoweredOnCondition is true when the host is powered on.
+	PoweredOnCondition string = "PoweredOn"
+
+	// BMCReachableCondition is true when the controller has been
+	// able to talk to the BMC of the host using its credentials.
+	BMCReachableCondition string = "BMCReachable"
+
+	// ReadyCondition is true when the host is in a steady state
+	// without any errors.
+	ReadyCondition string = "Ready"
+)
+
+// ProvisioningState defines the states the provisioner will report
+// the host has having.
+type ProvisioningState string
+
+const (
+	// StateNone means the state is unknown
+	StateNone ProvisioningState = ""
+
+	// StateUnmanaged means there is insufficient information available to
+	// register the host
+	StateUnmanaged ProvisioningState = "unmanaged"
+
+	// StateRegistrationError means there was an error registering the
+	// host with the backend
+	StateRegistrationError ProvisioningState = "registration error"
+
+	// StateRegistering means we are telling the backend about the host
+	StateRegistering ProvisioningState = "registering"
+
+	// StateMatchProfile means we are comparing the discovered details
+	// against known hardware profiles
+	StateMatchProfile ProvisioningState = "match profile"
+
+	// StateUpdatingFirmware means we are installing the firmware
+	// images listed in the host spec
+	StateUpdatingFirmware ProvisioningState = "updating firmware"
+
+	// StatePreparing means we are applying the settings, such as the
+	// RAID configuration, that must be in place before the host is
+	// ready
+	StatePreparing ProvisioningState = "preparing"
+
+	// StateReady means the host can be consumed
+	StateReady ProvisioningState = "ready"
+
+	// StateProvisioning means we are writing an image to the host's
+	// disk(s)
+	StateProvisioning ProvisioningState = "provisioning"
+
+	// StateProvisioningError means we are writing an image to the
+	// host's disk(s)
+	StateProvisioningError ProvisioningState = "provisioning error"
+
+	// StateProvisioned means we have written an image to the host's
+	// disk(s)
+	StateProvisioned ProvisioningState = "provisioned"
+
+	// StateExternallyProvisioned means something else is managing the
+	// image on the host
+	StateExternallyProvisioned ProvisioningState = "externally provisioned"
+
+	// StateDeprovisioning means we are removing an image from the
+	// host's disk(s)
+	StateDeprovisioning ProvisioningState = "deprovisioning"
+
+	// StateInspecting means we are running the agent on the host to
+	// learn about the hardware components available there
+	StateInspecting ProvisioningState = "inspecting"
+
+	// StatePowerManagementError means something went wrong trying to
+	// power the server on or off.
+	StatePowerManagementError ProvisioningState = "power management error"
+
+	// StateDeleting means we are in the process of cleaning up the host
+	// ready for deletion
+	StateDeleting ProvisioningState = "deleting"
+)
+
+// BMCDetails contains the information necessary to communicate with
+// the bare metal controller module on host.
+type BMCDetails struct {
+
+	// Address holds the URL for accessing the controller on the
+	// network.
+	Address string `json:"address"`
+
+	// The name of the secret containing the BMC credentials (requires
+	// keys "username" and "password").
+	CredentialsName string `json:"credentialsName"`
+
+	// DisableCertificateVerification disables verification of server
+	// certificates when using HTTPS to connect to the BMC. This is
+	// required when the server certificate is self-signed, but is
+	// insecure because it allows a man-in-the-middle to intercept the
+	// connection.
+	DisableCertificateVerification bool `json:"disableCertificateVerification,omitempty"`
+}
+
+// BareMetalHostSpec defines the desired state of BareMetalHost
+type BareMetalHostSpec struct {
+	// Important: Run "make generate manifests" to regenerate code
+	// after modifying this file
+
+	// Taints is the full, authoritative list of taints to apply to
+	// the corresponding Machine. This list will overwrite any
+	// modifications made to the Machine on an ongoing basis. Hosts
+	// with a NoSchedule or NoExecute taint are not available to
+	// consumers that do not tolerate it, and a NoExecute taint
+	// deprovisions a consumed host.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+
+	// How do we connect to the BMC?
+	BMC BMCDetails `json:"bmc,omitempty"`
+
+	// Provide guidance about how to choose the device for the image
+	// being provisioned.
+	RootDeviceHints *RootDeviceHints `json:"rootDeviceHints,omitempty"`
+
+	// RAID describes the RAID volumes to create on the host before it
+	// becomes ready. When it is not set, the RAID configuration of
+	// the host is left alone.
+	// +optional
+	RAID *RAIDConfig `json:"raid,omitempty"`
+
+	// Firmware holds the BIOS settings to apply to the host before it
+	// becomes ready. When it is not set, the BIOS settings of the host
+	// are left alone.
+	// +optional
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+
+	// FirmwareUpdates lists the firmware images to install on the
+	// host before it becomes ready. The images are installed again
+	// whenever the list changes while the host is not provisioned.
+	// +optional
+	FirmwareUpdates []FirmwareUpdate `json:"firmwareUpdates,omitempty"`
+
+	// CustomDeploySteps lists the deploy steps to run while the
+	// image is provisioned, in addition to the default steps of the
+	// provisioner.
+	// +optional
+	CustomDeploySteps []CustomDeployStep `json:"customDeploySteps,omitempty"`
+
+	// CustomCleanSteps lists the manual clean steps to run, in order,
+	// after the host is deprovisioned.
+	// +optional
+	CustomCleanSteps []CustomCleanStep `json:"customCleanSteps,omitempty"`
+
+	// Select the method of initializing the hardware during
+	// boot. Defaults to UEFI.
+	// +optional
+	BootMode BootMode `json:"bootMode,omitempty"`
+
+	// AutomatedCleaningMode selects how the disks of the host are
+	// cleaned when it is registered and after it is deprovisioned.
+	// Defaults to metadata.
+	// +optional
+	AutomatedCleaningMode AutomatedCleaningMode `json:"automatedCleaningMode,omitempty"`
+
+	// Which MAC address will PXE boot? This is optional for some
+	// types, but required for libvirt VMs driven by vbmc.
+	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
+	BootMACAddress string `json:"bootMACAddress,omitempty"`
+
+	// NICs lists network interfaces of the host, other than the one
+	// with the BootMACAddress, to register with the provisioner.
+	// +optional
+	NICs []NICConfig `json:"nics,omitempty"`
+
+	// PortGroups lists the bonds of network interfaces of the host.
+	// Their members are registered with the provisioner even when
+	// they are not listed in NICs.
+	// +optional
+	PortGroups []PortGroup `json:"portGroups,omitempty"`
+
+	// Should the server be online?
+	Online bool `json:"online"`
+
+	// ConsumerRef can be used to store information about something
+	// that is using a host. When it is not empty, the host is
+	// considered "in use".
+	ConsumerRef *corev1.ObjectReference `json:"consumerRef,omitempty"`
+
+	// Image holds the details of the image to be provisioned.
+	Image *Image `json:"image,omitempty"`
+
+	// UserData holds the reference to the Secret containing the user
+	// data to be passed to the host before it boots.
+	UserData *corev1.SecretReference `json:"userData,omitempty"`
+
+	// NetworkData holds the reference to the Secret containing network
+	// configuration (e.g content of network_data.json which is passed
+	// to Config Drive).
+	NetworkData *corev1.SecretReference `json:"networkData,omitempty"`
+
+	// MetaData holds the reference to the Secret containing host metadata
+	// (e.g. meta_data.json which is passed to Config Drive).
+	MetaData *corev1.SecretReference `json:"metaData,omitempty"`
+
+	// Description is a human-entered text used to help identify the host
+	Description string `json:"description,omitempty"`
+
+	// ExternallyProvisioned means something else is managing the
+	// image running on the host and the operator should only manage
+	// the power status and hardware inventory inspection. If the
+	// Image field is filled in, this field is ignored.
+	ExternallyProvisioned bool `json:"externallyProvisioned,omitempty"`
+}
+
+// ChecksumType holds the algorithm name for the checksum
+// +kubebuilder:validation:Enum=md5;sha256;sha512
+type ChecksumType string
+
+const (
+	// MD5 checksum type
+	MD5 ChecksumType = "md5"
+
+	// SHA256 checksum type
+	SHA256 ChecksumType = "sha256"
+
+	// SHA512 checksum type
+	SHA512 ChecksumType = "sha512"
+)
+
+// NICConfig is a network interface of a host registered with the
+// provisioner.
+type NICConfig struct {
+	// MACAddress is the MAC address of the interface.
+	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
+	MACAddress string `json:"macAddress"`
+
+	// PXEEnabled allows the host to PXE boot from the interface. The
+	// interface with the BootMACAddress always is.
+	// +optional
+	PXEEnabled bool `json:"pxeEnabled,omitempty"`
+}
+
+// BondMode is the Linux bonding mode of a port group.
+// +kubebuilder:validation:Enum=balance-rr;active-backup;balance-xor;broadcast;"802.3ad";balance-tlb;balance-alb
+type BondMode string
+
+const (
+	// BondModeActiveBackup uses one interface of the bond at a time.
+	BondModeActiveBackup BondMode = "active-backup"
+
+	// BondModeLACP aggregates the interfaces of the bond with LACP.
+	// The interfaces cannot be used on their own, so the host only
+	// PXE boots over the bond when the switch falls back to a single
+	// interface while LACP is not negotiated.
+	BondModeLACP BondMode = "802.3ad"
+)
+
+// PortGroup is a bond of network interfaces of a host.
+type PortGroup struct {
+	// Name identifies the port group among those of the host.
+	Name string `json:"name"`
+
+	// Mode is the bonding mode. Defaults to the mode configured in
+	// the provisioner.
+	// +optional
+	Mode BondMode `json:"mode,omitempty"`
+
+	// MACAddresses are the MAC addresses of the interfaces in the
+	// bond. The first one is used as the address of the bond.
+	// +kubebuilder:validation:MinItems=1
+	MACAddresses []string `json:"macAddresses"`
+
+	// Properties holds additional bonding options, such as miimon
+	// or xmit_hash_policy.
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// Image holds the details of an image either to provisioned or that
+// has been provisioned.
+type Image struct {
+	// URL is a location of an image to deploy.
+	URL string `json:"url"`
+
+	// Checksum is the checksum for the image, or the http(s) URL of
+	// a checksum file holding it. It is not used for live-iso images.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// ChecksumType is the checksum algorithm for the image.
+	// e.g md5, sha256, sha512
+	ChecksumType ChecksumType `json:"checksumType,omitempty"`
+
+	// DiskFormat contains the format of the image (raw, qcow2, ...)
+	// Needs to be set to raw for raw images streaming. A live-iso
+	// image is booted directly instead of being written to disk.
+	// +kubebuilder:validation:Enum=raw;qcow2;vdi;vmdk;live-iso
+	DiskFormat *string `json:"format,omitempty"`
+
+	// Kernel is the URL of the kernel of a partition image. It must
+	// be set together with Ramdisk, and is not used for whole-disk
+	// images.
+	// +optional
+	Kernel string `json:"kernel,omitempty"`
+
+	// Ramdisk is the URL of the initial ramdisk of a partition image.
+	// It must be set together with Kernel.
+	// +optional
+	Ramdisk string `json:"ramdisk,omitempty"`
+
+	// RootGB is the size, in GiB, of the root partition created for
+	// the image. The size set by the hardware profile of the host is
+	// used when it is not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RootGB int `json:"rootGB,omitempty"`
+}
+
+// CustomDeployStep is a deploy step run by the provisioner while an
+// image is provisioned.
+type CustomDeployStep struct {
+	// Interface is the driver interface implementing the step.
+	// +kubebuilder:validation:Enum=bios;boot;deploy;management;power;raid;vendor
+	Interface string `json:"interface"`
+
+	// Step is the name of the step.
+	Step string `json:"step"`
+
+	// Args holds the arguments passed to the step.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Args *runtime.RawExtension `json:"args,omitempty"`
+
+	// Priority orders the step among the other deploy steps. Steps
+	// with a higher priority run first.
+	// +kubebuilder:validation:Minimum=1
+	Priority int `json:"priority"`
+}
+
+// CustomCleanStep is a manual clean step run by the provisioner after
+// a host is deprovisioned.
+type CustomCleanStep struct {
+	// Interface is the driver interface implementing the step.
+	// +kubebuilder:validation:Enum=bios;deploy;management;power;raid;vendor
+	Interface string `json:"interface"`
+
+	// Step is the name of the step.
+	Step string `json:"step"`
+
+	// Args holds the arguments passed to the step.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Args *runtime.RawExtension `json:"args,omitempty"`
+}
+
+// StepsStatus reports the progress of the custom steps of a host.
+type StepsStatus struct {
+	// Current is the step being run, as interface.step. It is empty
+	// when no custom step is running.
+	Current string `json:"current,omitempty"`
+
+	// Completed is the number of custom steps that finished.
+	Completed int `json:"completed"`
+
+	// Total is the number of custom steps to run.
+	Total int `json:"total"`
+}
+
+// FIXME(dhellmann): We probably want some other module to own these
+// data structures.
+
+// ClockSpeed is a clock speed in MHz
+type ClockSpeed float64
+
+// ClockSpeed multipliers
+const (
+	MegaHertz ClockSpeed = 1.0
+	GigaHertz            = 1000 * MegaHertz
+)
+
+// Capacity is a disk size in Bytes
+type Capacity int64
+
+// Capacity multipliers
+const (
+	Byte     Capacity = 1
+	KibiByte          = Byte * 1024
+	KiloByte          = Byte * 1000
+	MebiByte          = KibiByte * 1024
+	MegaByte          = KiloByte * 1000
+	GibiByte          = MebiByte * 1024
+	GigaByte          = MegaByte * 1000
+	TebiByte          = GibiByte * 1024
+	TeraByte          = GigaByte * 1000
+)
+
+// Firmware describes the firmware on the host.
+type Firmware struct {
+	// The BIOS for this firmware
+	BIOS BIOS `json:"bios"`
+
+	// BIOSSettings holds the current values of the BIOS settings
+	// applied from the firmware settings of the host, by name.
+	// +optional
+	BIOSSettings map[string]string `json:"biosSettings,omitempty"`
+
+	// Components holds the firmware versions of the parts of the
+	// host, when the provisioning backend reports them.
+	// +optional
+	Components []FirmwareComponentStatus `json:"components,omitempty"`
+}
+
+// FirmwareComponentStatus describes the firmware version of a part of
+// the host.
+type FirmwareComponentStatus struct {
+	// Component is the part of the host the firmware is for.
+	Component string `json:"component"`
+
+	// InitialVersion is the version found when the host was
+	// registered.
+	// +optional
+	InitialVersion string `json:"initialVersion,omitempty"`
+
+	// CurrentVersion is the version currently installed.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// LastVersionFlashed is the version last installed by an update.
+	// +optional
+	LastVersionFlashed string `json:"lastVersionFlashed,omitempty"`
+
+	// UpdatedAt is when the firmware was last updated.
+	// +optional
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+}
+
+// BIOS describes the BIOS version on the host.
+type BIOS struct {
+	// The release/build date for this BIOS
+	Date string `json:"date"`
+
+	// The vendor name for this BIOS
+	Vendor string `json:"vendor"`
+
+	// The version of the BIOS
+	Version string `json:"version"`
+}
+
+// CPUSummary describes the processors of the host, without their
+// flags.
+type CPUSummary struct {
+	Arch           string     `json:"arch"`
+	Model          string     `json:"model"`
+	ClockMegahertz ClockSpeed `json:"clockMegahertz"`
+	Count          int        `json:"count"`
+}
+
+// HardwareSummary holds the parts of the hardware details of a host
+// that are kept in its status. The full details are stored in the
+// HardwareData resource of the host.
+type HardwareSummary struct {
+	SystemVendor HardwareSystemVendor `json:"systemVendor"`
+	Firmware     Firmware             `json:"firmware"`
+	RAMMebibytes int                  `json:"ramMebibytes"`
+	CPU          CPUSummary           `json:"cpu"`
+	Hostname     string               `json:"hostname"`
+
+	// NICCount is the number of network interfaces.
+	NICCount int `json:"nicCount"`
+
+	// StorageCount is the number of storage devices.
+	StorageCount int `json:"storageCount"`
+
+	// StorageSizeBytes is the total size of the storage devices.
+	StorageSizeBytes Capacity `json:"storageSizeBytes"`
+}
+
+// HardwareSystemVendor stores details about the whole hardware system.
+type HardwareSystemVendor struct {
+	Manufacturer string `json:"manufacturer"`
+	ProductName  string `json:"productName"`
+	SerialNumber string `json:"serialNumber"`
+}
+
+// CredentialsStatus contains the reference and version of the last
+// set of BMC credentials the controller was able to validate.
+type CredentialsStatus struct {
+	Reference *corev1.SecretReference `json:"credentials,omitempty"`
+	Version   string                  `json:"credentialsVersion,omitempty"`
+}
+
+// OperationMetric contains metadata about an operation (inspection,
+// provisioning, etc.) used for tracking metrics.
+type OperationMetric struct {
+	// +nullable
+	Start metav1.Time `json:"start,omitempty"`
+	// +nullable
+	End metav1.Time `json:"end,omitempty"`
+}
+
+// OperationHistory holds information about operations performed on a
+// host.
+type OperationHistory struct {
+	Register    OperationMetric `json:"register,omitempty"`
+	Inspect     OperationMetric `json:"inspect,omitempty"`
+	Provision   OperationMetric `json:"provision,omitempty"`
+	Deprovision OperationMetric `json:"deprovision,omitempty"`
+}
+
+// BareMetalHostStatus defines the observed state of BareMetalHost
+type BareMetalHostStatus struct {
+	// Important: Run "make generate manifests" to regenerate code
+	// after modifying this file
+
+	// OperationalStatus holds the status of the host
+	// +kubebuilder:validation:Enum="";OK;discovered;error;detached
+	OperationalStatus OperationalStatus `json:"operationalStatus"`
+
+	// ErrorType indicates the type of failure encountered when the
+	// OperationalStatus is OperationalStatusError
+	// +kubebuilder:validation:Enum=registration error;inspection error;provisioning error;power management error;preparation error;firmware update error;ignition error
+	ErrorType ErrorType `json:"errorType,omitempty"`
+
+	// LastUpdated identifies when this status was last observed.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// The name of the profile matching the hardware details.
+	HardwareProfile string `json:"hardwareProfile"`
+
+	// A summary of the hardware discovered to exist on the host.
+	// +optional
+	HardwareSummary *HardwareSummary `json:"hardwareSummary,omitempty"`
+
+	// HardwareData is the HardwareData resource holding the full
+	// hardware details and inspection data of the host.
+	// +optional
+	HardwareData *corev1.LocalObjectReference `json:"hardwareData,omitempty"`
+
+	// Information tracked by the provisioner.
+	Provisioning ProvisionStatus `json:"provisioning"`
+
+	// the last credentials we were able to validate as working
+	GoodCredentials CredentialsStatus `json:"goodCredentials,omitempty"`
+
+	// the last credentials we sent to the provisioning backend
+	TriedCredentials CredentialsStatus `json:"triedCredentials,omitempty"`
+
+	// the last error message reported by the provisioning subsystem
+	ErrorMessage string `json:"errorMessage"`
+
+	// indicator for whether or not the host is powered on
+	PoweredOn bool `json:"poweredOn"`
+
+	// OperationHistory holds information about operations performed
+	// on this host.
+	OperationHistory OperationHistory `json:"operationHistory"`
+
+	// ErrorCount records how many times the host has encoutered an error since the last successful operation
+	ErrorCount int `json:"errorCount"`
+
+	// Conditions describe the current state of the host using the
+	// standard Kubernetes condition format.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Taints holds the taints of the spec that are in effect, with
+	// the time NoExecute taints were added.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+}
+
+// ProvisionStatus holds the state information for a single target.
+type ProvisionStatus struct {
+	// An indiciator for what the provisioner is doing with the host.
+	State ProvisioningState `json:"state"`
+
+	// The machine's UUID from the underlying provisioning tool
+	ID string `json:"ID"`
+
+	// Image holds the details of the last image successfully
+	// provisioned to the host.
+	Image Image `json:"image,omitempty"`
+
+	// The RootDevicehints set by the user
+	RootDeviceHints *RootDeviceHints `json:"rootDeviceHints,omitempty"`
+
+	// BootMode indicates the boot mode used to provision the node
+	BootMode BootMode `json:"bootMode,omitempty"`
+
+	// RAID holds the RAID configuration last applied to the host.
+	RAID *RAIDConfig `json:"raid,omitempty"`
+
+	// Firmware holds the BIOS settings last applied to the host.
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+
+	// PendingRAID holds the RAID configuration being applied to the
+	// host, until the preparation completes.
+	PendingRAID *RAIDConfig `json:"pendingRAID,omitempty"`
+
+	// PendingFirmware holds the BIOS settings being applied to the
+	// host, until the preparation completes.
+	PendingFirmware *FirmwareConfig `json:"pendingFirmware,omitempty"`
+
+	// FirmwareUpdates holds the firmware images last installed on
+	// the host.
+	FirmwareUpdates []FirmwareUpdate `json:"firmwareUpdates,omitempty"`
+
+	// DeploySteps reports the progress of the custom deploy steps
+	// of the last provisioning.
+	DeploySteps *StepsStatus `json:"deploySteps,omitempty"`
+
+	// CleanSteps reports the progress of the custom clean steps of
+	// the last deprovisioning.
+	CleanSteps *StepsStatus `json:"cleanSteps,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BareMetalHost is the Schema for the baremetalhosts API
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=bmh;bmhost
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.operationalStatus",description="Operational status"
+// +kubebuilder:printcolumn:name="Provisioning Status",type="string",JSONPath=".status.provisioning.state",description="Provisioning status"
+// +kubebuilder:printcolumn:name="Consumer",type="string",JSONPath=".spec.consumerRef.name",description="Consumer using this host"
+// +kubebuilder:printcolumn:name="BMC",type="string",JSONPath=".spec.bmc.address",description="Address of management controller"
+// +kubebuilder:printcolumn:name="Hardware Profile",type="string",JSONPath=".status.hardwareProfile",description="The type of hardware detected"
+// +kubebuilder:printcolumn:name="Online",type="string",JSONPath=".spec.online",description="Whether the host is online or not"
+// +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.errorMessage",description="Most recent error"
+// +kubebuilder:object:root=true
+type BareMetalHost struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BareMetalHostSpec   `json:"spec,omitempty"`
+	Status BareMetalHostStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BareMetalHostList contains a list of BareMetalHost
+type BareMetalHostList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BareMetalHost `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BareMetalHost{}, &BareMetalHostList{})
+}
@@ -0,0 +1,746 @@
+// +build !ignore_autogenerated
+
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BIOS) DeepCopyInto(out *BIOS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BIOS.
+func (in *BIOS) DeepCopy() *BIOS {
+	if in == nil {
+		return nil
+	}
+	out := new(BIOS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMCDetails) DeepCopyInto(out *BMCDetails) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCDetails.
+func (in *BMCDetails) DeepCopy() *BMCDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(BMCDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalHost) DeepCopyInto(out *BareMetalHost) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHost.
+func (in *BareMetalHost) DeepCopy() *BareMetalHost {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BareMetalHost) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalHostList) DeepCopyInto(out *BareMetalHostList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BareMetalHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostList.
+func (in *BareMetalHostList) DeepCopy() *BareMetalHostList {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalHostList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BareMetalHostList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
+	*out = *in
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]v1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.BMC = in.BMC
+	if in.RootDeviceHints != nil {
+		in, out := &in.RootDeviceHints, &out.RootDeviceHints
+		*out = new(RootDeviceHints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RAID != nil {
+		in, out := &in.RAID, &out.RAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FirmwareUpdates != nil {
+		in, out := &in.FirmwareUpdates, &out.FirmwareUpdates
+		*out = make([]FirmwareUpdate, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomDeploySteps != nil {
+		in, out := &in.CustomDeploySteps, &out.CustomDeploySteps
+		*out = make([]CustomDeployStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CustomCleanSteps != nil {
+		in, out := &in.CustomCleanSteps, &out.CustomCleanSteps
+		*out = make([]CustomCleanStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]NICConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.PortGroups != nil {
+		in, out := &in.PortGroups, &out.PortGroups
+		*out = make([]PortGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConsumerRef != nil {
+		in, out := &in.ConsumerRef, &out.ConsumerRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(Image)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UserData != nil {
+		in, out := &in.UserData, &out.UserData
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+	if in.NetworkData != nil {
+		in, out := &in.NetworkData, &out.NetworkData
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+	if in.MetaData != nil {
+		in, out := &in.MetaData, &out.MetaData
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostSpec.
+func (in *BareMetalHostSpec) DeepCopy() *BareMetalHostSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalHostSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalHostStatus) DeepCopyInto(out *BareMetalHostStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+	if in.HardwareSummary != nil {
+		in, out := &in.HardwareSummary, &out.HardwareSummary
+		*out = new(HardwareSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HardwareData != nil {
+		in, out := &in.HardwareData, &out.HardwareData
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	in.Provisioning.DeepCopyInto(&out.Provisioning)
+	in.GoodCredentials.DeepCopyInto(&out.GoodCredentials)
+	in.TriedCredentials.DeepCopyInto(&out.TriedCredentials)
+	in.OperationHistory.DeepCopyInto(&out.OperationHistory)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]v1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostStatus.
+func (in *BareMetalHostStatus) DeepCopy() *BareMetalHostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalHostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CPUSummary) DeepCopyInto(out *CPUSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUSummary.
+func (in *CPUSummary) DeepCopy() *CPUSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CPUSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
+	*out = *in
+	if in.Reference != nil {
+		in, out := &in.Reference, &out.Reference
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsStatus.
+func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomCleanStep) DeepCopyInto(out *CustomCleanStep) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomCleanStep.
+func (in *CustomCleanStep) DeepCopy() *CustomCleanStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomCleanStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDeployStep) DeepCopyInto(out *CustomDeployStep) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDeployStep.
+func (in *CustomDeployStep) DeepCopy() *CustomDeployStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDeployStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Firmware) DeepCopyInto(out *Firmware) {
+	*out = *in
+	out.BIOS = in.BIOS
+	if in.BIOSSettings != nil {
+		in, out := &in.BIOSSettings, &out.BIOSSettings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]FirmwareComponentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firmware.
+func (in *Firmware) DeepCopy() *Firmware {
+	if in == nil {
+		return nil
+	}
+	out := new(Firmware)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareComponentStatus) DeepCopyInto(out *FirmwareComponentStatus) {
+	*out = *in
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareComponentStatus.
+func (in *FirmwareComponentStatus) DeepCopy() *FirmwareComponentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareComponentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareConfig) DeepCopyInto(out *FirmwareConfig) {
+	*out = *in
+	if in.VirtualizationEnabled != nil {
+		in, out := &in.VirtualizationEnabled, &out.VirtualizationEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SimultaneousMultithreadingEnabled != nil {
+		in, out := &in.SimultaneousMultithreadingEnabled, &out.SimultaneousMultithreadingEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SriovEnabled != nil {
+		in, out := &in.SriovEnabled, &out.SriovEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareConfig.
+func (in *FirmwareConfig) DeepCopy() *FirmwareConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareUpdate) DeepCopyInto(out *FirmwareUpdate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareUpdate.
+func (in *FirmwareUpdate) DeepCopy() *FirmwareUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareRAIDVolume) DeepCopyInto(out *HardwareRAIDVolume) {
+	*out = *in
+	if in.SizeGibibytes != nil {
+		in, out := &in.SizeGibibytes, &out.SizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PhysicalDisks != nil {
+		in, out := &in.PhysicalDisks, &out.PhysicalDisks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NumberOfPhysicalDisks != nil {
+		in, out := &in.NumberOfPhysicalDisks, &out.NumberOfPhysicalDisks
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRAIDVolume.
+func (in *HardwareRAIDVolume) DeepCopy() *HardwareRAIDVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareRAIDVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSummary) DeepCopyInto(out *HardwareSummary) {
+	*out = *in
+	out.SystemVendor = in.SystemVendor
+	in.Firmware.DeepCopyInto(&out.Firmware)
+	out.CPU = in.CPU
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSummary.
+func (in *HardwareSummary) DeepCopy() *HardwareSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSystemVendor) DeepCopyInto(out *HardwareSystemVendor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSystemVendor.
+func (in *HardwareSystemVendor) DeepCopy() *HardwareSystemVendor {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSystemVendor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Image) DeepCopyInto(out *Image) {
+	*out = *in
+	if in.DiskFormat != nil {
+		in, out := &in.DiskFormat, &out.DiskFormat
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
+func (in *Image) DeepCopy() *Image {
+	if in == nil {
+		return nil
+	}
+	out := new(Image)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NICConfig) DeepCopyInto(out *NICConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICConfig.
+func (in *NICConfig) DeepCopy() *NICConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NICConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
+	*out = *in
+	in.Register.DeepCopyInto(&out.Register)
+	in.Inspect.DeepCopyInto(&out.Inspect)
+	in.Provision.DeepCopyInto(&out.Provision)
+	in.Deprovision.DeepCopyInto(&out.Deprovision)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationHistory.
+func (in *OperationHistory) DeepCopy() *OperationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationMetric) DeepCopyInto(out *OperationMetric) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationMetric.
+func (in *OperationMetric) DeepCopy() *OperationMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortGroup) DeepCopyInto(out *PortGroup) {
+	*out = *in
+	if in.MACAddresses != nil {
+		in, out := &in.MACAddresses, &out.MACAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortGroup.
+func (in *PortGroup) DeepCopy() *PortGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(PortGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
+	*out = *in
+	in.Image.DeepCopyInto(&out.Image)
+	if in.RootDeviceHints != nil {
+		in, out := &in.RootDeviceHints, &out.RootDeviceHints
+		*out = new(RootDeviceHints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RAID != nil {
+		in, out := &in.RAID, &out.RAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingRAID != nil {
+		in, out := &in.PendingRAID, &out.PendingRAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingFirmware != nil {
+		in, out := &in.PendingFirmware, &out.PendingFirmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FirmwareUpdates != nil {
+		in, out := &in.FirmwareUpdates, &out.FirmwareUpdates
+		*out = make([]FirmwareUpdate, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeploySteps != nil {
+		in, out := &in.DeploySteps, &out.DeploySteps
+		*out = new(StepsStatus)
+		**out = **in
+	}
+	if in.CleanSteps != nil {
+		in, out := &in.CleanSteps, &out.CleanSteps
+		*out = new(StepsStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionStatus.
+func (in *ProvisionStatus) DeepCopy() *ProvisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RAIDConfig) DeepCopyInto(out *RAIDConfig) {
+	*out = *in
+	if in.HardwareRAIDVolumes != nil {
+		in, out := &in.HardwareRAIDVolumes, &out.HardwareRAIDVolumes
+		*out = make([]HardwareRAIDVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SoftwareRAIDVolumes != nil {
+		in, out := &in.SoftwareRAIDVolumes, &out.SoftwareRAIDVolumes
+		*out = make([]SoftwareRAIDVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAIDConfig.
+func (in *RAIDConfig) DeepCopy() *RAIDConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RAIDConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RootDeviceHints) DeepCopyInto(out *RootDeviceHints) {
+	*out = *in
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RootDeviceHints.
+func (in *RootDeviceHints) DeepCopy() *RootDeviceHints {
+	if in == nil {
+		return nil
+	}
+	out := new(RootDeviceHints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SoftwareRAIDVolume) DeepCopyInto(out *SoftwareRAIDVolume) {
+	*out = *in
+	if in.SizeGibibytes != nil {
+		in, out := &in.SizeGibibytes, &out.SizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.PhysicalDisks != nil {
+		in, out := &in.PhysicalDisks, &out.PhysicalDisks
+		*out = make([]RootDeviceHints, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SoftwareRAIDVolume.
+func (in *SoftwareRAIDVolume) DeepCopy() *SoftwareRAIDVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(SoftwareRAIDVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepsStatus) DeepCopyInto(out *StepsStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepsStatus.
+func (in *StepsStatus) DeepCopy() *StepsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StepsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
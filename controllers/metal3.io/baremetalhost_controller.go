@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -31,12 +32,16 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
@@ -48,8 +53,14 @@ import (
 const (
 	hostErrorRetryDelay           = time.Second * 10
 	unmanagedRetryDelay           = time.Minute * 10
+	detachedRetryDelay            = time.Minute * 10
 	provisionerNotReadyRetryDelay = time.Second * 30
+	configDataRetryDelay          = time.Minute * 10
 	rebootAnnotationPrefix        = "reboot.metal3.io"
+
+	// secretRefIndex indexes the hosts by the namespace and name of
+	// the Secrets they reference.
+	secretRefIndex = "secretRef"
 )
 
 func init() {
@@ -73,6 +84,9 @@ type reconcileInfo struct {
 	events            []corev1.Event
 	errorMessage      string
 	postSaveCallbacks []func()
+	// evictionRecheckAfter is the time left until the consumer of the
+	// host stops tolerating one of its NoExecute taints.
+	evictionRecheckAfter time.Duration
 }
 
 // match the provisioner.EventPublisher interface
@@ -84,6 +98,9 @@ func (info *reconcileInfo) publishEvent(reason, message string) {
 // +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=metal3.io,resources=hardwareprofiles,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=metal3.io,resources=hardwaredata,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=metal3.io,resources=hostclaims,verbs=get;list;watch
 
 // Reconcile handles changes to BareMetalHost resources
 func (r *BareMetalHostReconciler) Reconcile(request ctrl.Request) (result ctrl.Result, err error) {
@@ -155,6 +172,73 @@ func (r *BareMetalHostReconciler) Reconcile(request ctrl.Request) (result ctrl.R
 		}
 	}
 
+	// Hosts inspected before the hardware details were kept in a
+	// HardwareData resource have them in their status, move them
+	// there once.
+	if host.Status.HardwareDetails != nil {
+		if host.Status.HardwareData == nil {
+			reqLogger.Info("moving hardware details from status to HardwareData")
+			hardwareData := &metal3v1alpha1.HardwareDataSpec{
+				HardwareDetails: host.Status.HardwareDetails,
+			}
+			if err := r.saveHardwareData(host, hardwareData); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		host.Status.HardwareDetails = nil
+		if err := r.Status().Update(context.TODO(), host); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "could not move hardware details to HardwareData")
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Copy the hardware details supplied by the user into the status,
+	// unless they would replace the results of an inspection, and
+	// remove the annotation. Details that cannot be used are reported
+	// with an event, since the annotation is removed either way.
+	if _, present := annotations[metal3v1alpha1.HardwareDetailsAnnotation]; present {
+		if host.InspectionDisabled() || host.Status.HardwareSummary == nil {
+			details, err := host.GetHardwareDetailsFromAnnotation()
+			if err != nil {
+				reqLogger.Info("ignoring invalid hardware details annotation", "error", err.Error())
+				r.publishEvent(request, host.NewEvent("InvalidHardwareDetails",
+					fmt.Sprintf("Could not parse the hardware details annotation: %s", err)))
+			} else {
+				reqLogger.Info("setting hardware details from annotation")
+				hardwareData := &metal3v1alpha1.HardwareDataSpec{HardwareDetails: details}
+				if err := r.saveHardwareData(host, hardwareData); err != nil {
+					return ctrl.Result{}, err
+				}
+				if err := r.Status().Update(context.TODO(), host); err != nil {
+					return ctrl.Result{}, errors.Wrap(err, "could not save hardware details from annotation")
+				}
+			}
+		} else {
+			reqLogger.Info("ignoring hardware details annotation of inspected host")
+			r.publishEvent(request, host.NewEvent("HardwareDetailsIgnored",
+				"The hardware details annotation is ignored because the host has been inspected"))
+		}
+		delete(annotations, metal3v1alpha1.HardwareDetailsAnnotation)
+		if err := r.Update(context.TODO(), host); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "could not delete hardware details annotation")
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Inspecting a provisioned host would reboot it, so the inspect
+	// annotation is removed instead of being left for when the host is
+	// deprovisioned. The webhook rejects it, but may not be deployed.
+	if host.HasInspectAnnotation() && inspectionForbidden(host) {
+		reqLogger.Info("removing inspect annotation of provisioned host")
+		delete(annotations, metal3v1alpha1.InspectAnnotation)
+		if err := r.Update(context.TODO(), host); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "could not delete inspect annotation")
+		}
+		r.publishEvent(request, host.NewEvent("InspectionRejected",
+			"Hardware inspection is not possible while the host is provisioned"))
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// NOTE(dhellmann): Handle a few steps outside of the phase
 	// structure because they require extra data lookup (like the
 	// credential checks) or have to be done "first" (like delete
@@ -222,6 +306,7 @@ func (r *BareMetalHostReconciler) Reconcile(request ctrl.Request) (result ctrl.R
 	stateMachine := newHostStateMachine(host, r, prov)
 	actResult := stateMachine.ReconcileState(info)
 	result, err = actResult.Result()
+	result = requeueForEviction(result, info.evictionRecheckAfter)
 
 	if err != nil {
 		err = errors.Wrap(err, fmt.Sprintf("action %q failed", initialState))
@@ -232,7 +317,8 @@ func (r *BareMetalHostReconciler) Reconcile(request ctrl.Request) (result ctrl.R
 	// introduce an infinite loop reconciling the same object over and
 	// over when there is an unrecoverable error (tracked through the
 	// error state of the host).
-	if actResult.Dirty() {
+	_, deleted := actResult.(deleteComplete)
+	if actResult.Dirty() || (stateMachine.StatusChanged && !deleted) {
 
 		// Save Host
 		info.log.Info("saving host status",
@@ -275,12 +361,8 @@ func recordActionFailure(info *reconcileInfo, errorType metal3v1alpha1.ErrorType
 
 	info.host.SetErrorMessage(errorType, errorMessage)
 
-	eventType := map[metal3v1alpha1.ErrorType]string{
-		metal3v1alpha1.RegistrationError:    "RegistrationError",
-		metal3v1alpha1.InspectionError:      "InspectionError",
-		metal3v1alpha1.ProvisioningError:    "ProvisioningError",
-		metal3v1alpha1.PowerManagementError: "PowerManagementError",
-	}[errorType]
+	eventType := errorReasons[errorType]
+	updateHostConditions(info.host)
 
 	counter := actionFailureCounters.WithLabelValues(eventType)
 	info.postSaveCallbacks = append(info.postSaveCallbacks, counter.Inc)
@@ -327,6 +409,18 @@ func (r *BareMetalHostReconciler) credentialsErrorResult(err error, request ctrl
 	}
 }
 
+// inspectionForbidden returns true when inspecting the host would
+// disrupt what is provisioned on it.
+func inspectionForbidden(host *metal3v1alpha1.BareMetalHost) bool {
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateProvisioning,
+		metal3v1alpha1.StateProvisioned,
+		metal3v1alpha1.StateExternallyProvisioned:
+		return true
+	}
+	return false
+}
+
 // hasRebootAnnotation checks for existence of reboot annotations and returns true if at least one exist
 func hasRebootAnnotation(host *metal3v1alpha1.BareMetalHost) bool {
 	for annotation := range host.Annotations {
@@ -387,6 +481,27 @@ func (r *BareMetalHostReconciler) actionDeleting(prov provisioner.Provisioner, i
 	return deleteComplete{}
 }
 
+// Remove the host from the provisioner, without deprovisioning it,
+// while the detached annotation is set.
+func (r *BareMetalHostReconciler) actionDetaching(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	if info.host.OperationalStatus() == metal3v1alpha1.OperationalStatusDetached {
+		return actionContinueNoWrite{actionContinue{detachedRetryDelay}}
+	}
+
+	info.log.Info("detaching host from the provisioner")
+	provResult, err := prov.Detach()
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to detach")}
+	}
+	if provResult.Dirty {
+		return actionContinue{provResult.RequeueAfter}
+	}
+
+	info.host.SetOperationalStatus(metal3v1alpha1.OperationalStatusDetached)
+	info.publishEvent("Detached", "Host removed from the provisioner")
+	return actionComplete{}
+}
+
 func (r *BareMetalHostReconciler) actionUnmanaged(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 	if info.host.HasBMCDetails() {
 		return actionComplete{}
@@ -446,7 +561,17 @@ func (r *BareMetalHostReconciler) actionRegistering(prov provisioner.Provisioner
 func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 	info.log.Info("inspecting hardware")
 
-	provResult, details, err := prov.InspectHardware()
+	if info.host.InspectionDisabled() {
+		info.log.Info("inspection disabled by annotation")
+		info.publishEvent("InspectionSkipped", "Hardware inspection disabled by annotation")
+		return actionComplete{}
+	}
+
+	// The details of the previous inspection are cleared once a new
+	// one has been started, so only ask for it while they are set.
+	refresh := info.host.HasInspectAnnotation() && info.host.Status.HardwareSummary != nil
+
+	provResult, started, hardwareData, err := prov.InspectHardware(refresh)
 	if err != nil {
 		return actionError{errors.Wrap(err, "hardware inspection failed")}
 	}
@@ -455,8 +580,23 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 		return recordActionFailure(info, metal3v1alpha1.InspectionError, provResult.ErrorMessage)
 	}
 
-	if details != nil {
-		info.host.Status.HardwareDetails = details
+	if started && refresh {
+		info.log.Info("clearing stale hardware details")
+		info.host.Status.HardwareSummary = nil
+	}
+
+	if hardwareData != nil {
+		if info.host.HasInspectAnnotation() {
+			delete(info.host.Annotations, metal3v1alpha1.InspectAnnotation)
+			if err := r.Update(context.TODO(), info.host); err != nil {
+				return actionError{errors.Wrap(err, "failed to remove inspect annotation from host")}
+			}
+			// The details are saved on the next pass.
+			return actionContinueNoWrite{}
+		}
+		if err := r.saveHardwareData(info.host, hardwareData); err != nil {
+			return actionError{err}
+		}
 		return actionComplete{}
 	}
 
@@ -470,7 +610,7 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 
 func (r *BareMetalHostReconciler) actionMatchProfile(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 
-	var hardwareProfile string
+	var hardwareProfile, reason string
 
 	info.log.Info("determining hardware profile")
 
@@ -479,6 +619,7 @@ func (r *BareMetalHostReconciler) actionMatchProfile(prov provisioner.Provisione
 		info.log.Info("using spec value for profile name",
 			"name", info.host.Spec.HardwareProfile)
 		hardwareProfile = info.host.Spec.HardwareProfile
+		reason = "set in the host spec"
 		_, err := hardware.GetProfile(hardwareProfile)
 		if err != nil {
 			info.log.Info("invalid hardware profile", "profile", hardwareProfile)
@@ -486,13 +627,29 @@ func (r *BareMetalHostReconciler) actionMatchProfile(prov provisioner.Provisione
 		}
 	}
 
-	// Now do a bit of matching.
-	//
-	// FIXME(dhellmann): Insert more robust logic to match
-	// hardware profiles here.
+	// Compare the inspected hardware against the rules of the
+	// profiles.
+	if hardwareProfile == "" {
+		details, err := getHardwareDetails(r, info.host)
+		if err != nil {
+			return actionError{err}
+		}
+		match, ok, err := hardware.MatchProfile(details)
+		if err != nil {
+			return actionError{errors.Wrap(err, "failed to match hardware profile")}
+		}
+		if ok {
+			hardwareProfile = match.Name
+			reason = fmt.Sprintf("matched %s", match.Reason())
+			info.log.Info("determining from hardware details",
+				"name", hardwareProfile, "score", match.Score)
+		}
+	}
+
 	if hardwareProfile == "" {
 		if strings.HasPrefix(info.host.Spec.BMC.Address, "libvirt") {
 			hardwareProfile = "libvirt"
+			reason = "libvirt BMC address"
 			info.log.Info("determining from BMC address", "name", hardwareProfile)
 		}
 	}
@@ -500,18 +657,118 @@ func (r *BareMetalHostReconciler) actionMatchProfile(prov provisioner.Provisione
 	// Now default to a value just in case there is no match
 	if hardwareProfile == "" {
 		hardwareProfile = hardware.DefaultProfileName
+		reason = "no profile matched"
 		info.log.Info("using the default", "name", hardwareProfile)
 	}
 
 	if info.host.SetHardwareProfile(hardwareProfile) {
 		info.log.Info("updating hardware profile", "profile", hardwareProfile)
-		info.publishEvent("ProfileSet", fmt.Sprintf("Hardware profile set: %s", hardwareProfile))
+		info.publishEvent("ProfileSet", fmt.Sprintf("Hardware profile set: %s (%s)",
+			hardwareProfile, reason))
 	}
 
 	info.host.ClearError()
 	return actionComplete{}
 }
 
+// Apply the settings that must be in place before the host is ready.
+func (r *BareMetalHostReconciler) actionUpdatingFirmware(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	info.log.Info("updating firmware")
+
+	provResult, started, err := prov.UpdateFirmware(info.host.FirmwareUpdatesChanged())
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to update firmware")}
+	}
+
+	if provResult.ErrorMessage != "" {
+		info.log.Info("handling firmware update error in controller")
+		// Forget the images so that they are installed again once
+		// the error is fixed.
+		info.host.Status.Provisioning.FirmwareUpdates = nil
+		return recordActionFailure(info, metal3v1alpha1.FirmwareUpdateError, provResult.ErrorMessage)
+	}
+
+	if started {
+		info.log.Info("saving firmware updates")
+		info.host.Status.Provisioning.FirmwareUpdates = append(
+			[]metal3v1alpha1.FirmwareUpdate(nil), info.host.Spec.FirmwareUpdates...)
+	}
+
+	if provResult.Dirty {
+		info.host.ClearError()
+		return actionContinue{provResult.RequeueAfter}
+	}
+
+	if err := r.saveFirmwareStatus(info.host); err != nil {
+		return actionError{err}
+	}
+
+	info.host.ClearError()
+	return actionComplete{}
+}
+
+func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	info.log.Info("preparing")
+
+	// While settings are being applied, the provisioner only waits
+	// for them to be in place.
+	status := &info.host.Status.Provisioning
+	pending := status.PendingRAID != nil || status.PendingFirmware != nil
+	provResult, started, err := prov.Prepare(!pending && info.host.NeedsPreparation())
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to prepare host")}
+	}
+
+	if provResult.ErrorMessage != "" {
+		info.log.Info("handling preparation error in controller")
+		// Forget the settings being applied, so that they are applied
+		// again once the error backoff has passed.
+		status.PendingRAID = nil
+		status.PendingFirmware = nil
+		return recordActionFailure(info, metal3v1alpha1.PreparationError, provResult.ErrorMessage)
+	}
+
+	if started {
+		// The provisioner applies the RAID configuration first and
+		// the firmware settings afterwards, so that a failure only
+		// causes the settings that failed to be applied again.
+		if info.host.RAIDConfigChanged() {
+			info.log.Info("saving RAID configuration being applied")
+			status.PendingRAID = info.host.Spec.RAID.DeepCopy()
+		} else {
+			info.log.Info("saving firmware settings being applied")
+			status.PendingFirmware = info.host.Spec.Firmware.DeepCopy()
+		}
+	}
+
+	if provResult.Dirty {
+		info.host.ClearError()
+		return actionContinue{provResult.RequeueAfter}
+	}
+
+	if err := r.saveFirmwareStatus(info.host); err != nil {
+		return actionError{err}
+	}
+
+	// The settings are only recorded as applied once the provisioner
+	// is done with them.
+	if status.PendingRAID != nil {
+		info.log.Info("RAID configuration applied")
+		status.RAID, status.PendingRAID = status.PendingRAID, nil
+	}
+	if status.PendingFirmware != nil {
+		info.log.Info("firmware settings applied")
+		status.Firmware, status.PendingFirmware = status.PendingFirmware, nil
+	}
+
+	info.host.ClearError()
+	if info.host.NeedsPreparation() {
+		// Apply the remaining settings.
+		return actionContinue{}
+	}
+	return actionComplete{}
+}
+
 // Start/continue provisioning if we need to.
 func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 	hostConf := &hostConfigData{
@@ -530,6 +787,14 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 
 	provResult, err := prov.Provision(hostConf)
 	if err != nil {
+		// Configuration data that cannot be rendered or converted needs
+		// to be fixed by the user, retrying will not help.
+		switch cause := errors.Cause(err).(type) {
+		case ConfigDataTemplateError, NetworkDataConversionError:
+			return configDataFailure(info, metal3v1alpha1.ProvisioningError, cause.Error())
+		case IgnitionValidationError:
+			return configDataFailure(info, metal3v1alpha1.IgnitionError, cause.Error())
+		}
 		return actionError{errors.Wrap(err, "failed to provision")}
 	}
 
@@ -547,9 +812,16 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 	}
 
 	// If the provisioner had no work, ensure the image settings match.
-	if info.host.Status.Provisioning.Image != *(info.host.Spec.Image) {
+	image := *(info.host.Spec.Image)
+	if provisioned := info.host.Status.Provisioning.Image; image.HasChecksumURL() && provisioned.URL == image.URL {
+		// Keep the checksum the provisioner read from the checksum
+		// file.
+		image.Checksum = provisioned.Checksum
+		image.ChecksumType = provisioned.ChecksumType
+	}
+	if info.host.Status.Provisioning.Image != image {
 		info.log.Info("updating deployed image in status")
-		info.host.Status.Provisioning.Image = *(info.host.Spec.Image)
+		info.host.Status.Provisioning.Image = image
 	}
 
 	// After provisioning we always requeue to ensure we enter the
@@ -557,11 +829,26 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 	return actionComplete{}
 }
 
+// configDataFailure records an error in the configuration data of a
+// host being provisioned. Nothing was written to the host yet, so it
+// stays in the provisioning state, with the error set, until the
+// Secret or the host is changed, instead of being deprovisioned and
+// provisioned again with the same data. The error is only recorded
+// once.
+func configDataFailure(info *reconcileInfo, errorType metal3v1alpha1.ErrorType, errorMessage string) actionResult {
+	if info.host.Status.ErrorType == errorType && info.host.Status.ErrorMessage == errorMessage {
+		return actionContinueNoWrite{actionContinue{configDataRetryDelay}}
+	}
+	recordActionFailure(info, errorType, errorMessage)
+	return actionContinue{configDataRetryDelay}
+}
+
 // clearHostProvisioningSettings removes the values related to
 // provisioning that do not trigger re-provisioning from the status
 // fields of a host.
 func clearHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) {
 	host.Status.Provisioning.RootDeviceHints = nil
+	host.Status.Provisioning.DeploySteps = nil
 }
 
 func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
@@ -727,7 +1014,12 @@ func (r *BareMetalHostReconciler) actionManageReady(prov provisioner.Provisioner
 		return actionContinue{provResult.RequeueAfter}
 	}
 
-	if info.host.NeedsProvisioning() {
+	evicted, err := r.hostEvicted(info)
+	if err != nil {
+		return actionError{err}
+	}
+
+	if info.host.NeedsProvisioning() && !evicted {
 		// Ensure the provisioning settings we're going to use are stored.
 		dirty, err := saveHostProvisioningSettings(info.host)
 		if err != nil {
@@ -796,10 +1088,107 @@ func (r *BareMetalHostReconciler) getHostStatusFromAnnotation(host *metal3v1alph
 	return objStatus, nil
 }
 
+// saveHardwareData stores the results of an inspection in the
+// HardwareData resource of the host, creating it if needed, and
+// records a summary of the hardware and a reference to the resource
+// in the status of the host. Inspection does not find the firmware
+// versions and BIOS settings, so those already in the resource are
+// kept.
+func (r *BareMetalHostReconciler) saveHardwareData(host *metal3v1alpha1.BareMetalHost, spec *metal3v1alpha1.HardwareDataSpec) error {
+	hardwareData := &metal3v1alpha1.HardwareData{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      host.Name,
+			Namespace: host.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.Client, hardwareData, func() error {
+		previous := hardwareData.Spec.HardwareDetails
+		hardwareData.Spec = *spec.DeepCopy()
+		details := hardwareData.Spec.HardwareDetails
+		if previous != nil && details != nil {
+			if details.Firmware.BIOSSettings == nil {
+				details.Firmware.BIOSSettings = previous.Firmware.BIOSSettings
+			}
+			if details.Firmware.Components == nil {
+				details.Firmware.Components = previous.Firmware.Components
+			}
+		}
+		return controllerutil.SetControllerReference(host, hardwareData, r.Scheme)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to save hardware data")
+	}
+
+	host.Status.HardwareData = &corev1.LocalObjectReference{Name: hardwareData.Name}
+	host.Status.HardwareSummary = nil
+	if hardwareData.Spec.HardwareDetails != nil {
+		host.Status.HardwareSummary = hardwareData.Spec.HardwareDetails.Summary()
+	}
+	return nil
+}
+
+// saveFirmwareStatus stores the firmware versions and BIOS settings
+// the provisioner recorded in the hardware summary of the host in its
+// HardwareData resource, which holds them across inspections.
+func (r *BareMetalHostReconciler) saveFirmwareStatus(host *metal3v1alpha1.BareMetalHost) error {
+	if host.Status.HardwareSummary == nil || host.Status.HardwareData == nil {
+		return nil
+	}
+
+	hardwareData := &metal3v1alpha1.HardwareData{}
+	key := client.ObjectKey{
+		Name:      host.Status.HardwareData.Name,
+		Namespace: host.Namespace,
+	}
+	if err := r.Get(context.TODO(), key, hardwareData); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to load hardware data")
+	}
+	details := hardwareData.Spec.HardwareDetails
+	if details == nil {
+		return nil
+	}
+
+	firmware := host.Status.HardwareSummary.Firmware
+	if reflect.DeepEqual(details.Firmware.BIOSSettings, firmware.BIOSSettings) &&
+		reflect.DeepEqual(details.Firmware.Components, firmware.Components) {
+		return nil
+	}
+	details.Firmware.BIOSSettings = firmware.BIOSSettings
+	details.Firmware.Components = firmware.Components
+	if err := r.Update(context.TODO(), hardwareData); err != nil {
+		return errors.Wrap(err, "failed to save firmware status")
+	}
+	return nil
+}
+
+// getHardwareDetails returns the hardware details stored in the
+// HardwareData resource of the host, or nil when there are none.
+func getHardwareDetails(c client.Client, host *metal3v1alpha1.BareMetalHost) (*metal3v1alpha1.HardwareDetails, error) {
+	if host.Status.HardwareData == nil {
+		return nil, nil
+	}
+	hardwareData := &metal3v1alpha1.HardwareData{}
+	key := client.ObjectKey{
+		Name:      host.Status.HardwareData.Name,
+		Namespace: host.Namespace,
+	}
+	if err := c.Get(context.TODO(), key, hardwareData); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to load hardware data")
+	}
+	return hardwareData.Spec.HardwareDetails, nil
+}
+
 func (r *BareMetalHostReconciler) setErrorCondition(request ctrl.Request, host *metal3v1alpha1.BareMetalHost, errType metal3v1alpha1.ErrorType, message string) (err error) {
 	reqLogger := r.Log.WithValues("baremetalhost", request.NamespacedName)
 
 	host.SetErrorMessage(errType, message)
+	updateHostConditions(host)
 
 	reqLogger.Info(
 		"adding error message",
@@ -940,6 +1329,11 @@ func (r *BareMetalHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}
 
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(),
+		&metal3v1alpha1.BareMetalHost{}, secretRefIndex, secretRefs); err != nil {
+		return errors.Wrap(err, "failed to index hosts by secret")
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&metal3v1alpha1.BareMetalHost{}).
 		WithEventFilter(
@@ -964,5 +1358,89 @@ func (r *BareMetalHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}).
 		WithOptions(opts).
 		Owns(&corev1.Secret{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(r.hostsForSecret),
+			}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.HostClaim{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(hostForClaim),
+			}).
 		Complete(r)
 }
+
+// hostForClaim returns the request to reconcile the host bound to a
+// HostClaim, so that changes to the tolerations of the claim apply to
+// the taints of the host.
+func hostForClaim(obj handler.MapObject) []reconcile.Request {
+	claim, ok := obj.Object.(*metal3v1alpha1.HostClaim)
+	if !ok || claim.Status.Host == nil {
+		return nil
+	}
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Namespace: claim.Namespace,
+				Name:      claim.Status.Host.Name,
+			},
+		},
+	}
+}
+
+// secretRefs returns the namespace and name of the Secrets holding the
+// BMC credentials and configuration data of a host.
+func secretRefs(obj runtime.Object) []string {
+	host, ok := obj.(*metal3v1alpha1.BareMetalHost)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	if host.Spec.BMC.CredentialsName != "" {
+		keys = append(keys, host.Namespace+"/"+host.Spec.BMC.CredentialsName)
+	}
+	for _, ref := range []*corev1.SecretReference{host.Spec.UserData, host.Spec.MetaData, host.Spec.NetworkData} {
+		if ref == nil || ref.Name == "" {
+			continue
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = host.Namespace
+		}
+		keys = append(keys, namespace+"/"+ref.Name)
+	}
+	return keys
+}
+
+// hostsForSecret returns a request for each host whose BMC
+// credentials or configuration data are in a Secret, so that hosts
+// waiting for them to be fixed are reconciled when it changes. Hosts
+// are looked up through the secretRefIndex field index.
+func (r *BareMetalHostReconciler) hostsForSecret(obj handler.MapObject) []reconcile.Request {
+	secret, ok := obj.Object.(*corev1.Secret)
+	if !ok {
+		return nil
+	}
+	key := secret.Namespace + "/" + secret.Name
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(context.TODO(), hosts, client.MatchingFields{secretRefIndex: key}); err != nil {
+		r.Log.Error(err, "could not list hosts")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range hosts.Items {
+		host := &hosts.Items[i]
+		if !utils.StringInList(secretRefs(host), key) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: host.Namespace,
+				Name:      host.Name,
+			},
+		})
+	}
+	return requests
+}
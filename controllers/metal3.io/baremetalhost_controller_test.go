@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	corev1 "k8s.io/api/core/v1"
@@ -19,10 +20,12 @@ import (
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
 	"github.com/metal3-io/baremetal-operator/pkg/utils"
@@ -822,21 +825,229 @@ func TestSetHardwareProfile(t *testing.T) {
 	)
 }
 
-// TestCreateHardwareDetails ensures that the HardwareDetails portion
-// of the status block is filled in for new hosts.
+// TestMigrateHardwareDetails ensures that the hardware details kept in
+// the status of hosts inspected by older versions are moved to a
+// HardwareData resource, without inspecting the host again.
+func TestMigrateHardwareDetails(t *testing.T) {
+	host := newDefaultHost(t)
+	now := metav1.Now()
+	host.Status.LastUpdated = &now
+	host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{
+		Hostname:     "old-host",
+		RAMMebibytes: 4096,
+		NIC:          []metal3v1alpha1.NIC{{Name: "eth0", MAC: "00:11:22:33:44:55"}},
+	}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.HardwareDetails == nil
+		},
+	)
+
+	if assert.NotNil(t, host.Status.HardwareSummary) {
+		assert.Equal(t, "old-host", host.Status.HardwareSummary.Hostname)
+		assert.Equal(t, 1, host.Status.HardwareSummary.NICCount)
+	}
+	assert.False(t, host.NeedsHardwareInspection())
+
+	hardwareData := &metal3v1alpha1.HardwareData{}
+	key := types.NamespacedName{Namespace: host.Namespace, Name: host.Name}
+	err := r.Get(goctx.TODO(), key, hardwareData)
+	if assert.NoError(t, err) && assert.NotNil(t, hardwareData.Spec.HardwareDetails) {
+		assert.Equal(t, "old-host", hardwareData.Spec.HardwareDetails.Hostname)
+		assert.Len(t, hardwareData.Spec.HardwareDetails.NIC, 1)
+	}
+}
+
+// TestFirmwareStatusKeptInHardwareData ensures that the firmware
+// versions and BIOS settings recorded by the provisioner are stored in
+// the HardwareData resource, and survive a new inspection.
+func TestFirmwareStatusKeptInHardwareData(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+
+	inspected := &metal3v1alpha1.HardwareDataSpec{
+		HardwareDetails: &metal3v1alpha1.HardwareDetails{Hostname: "first"},
+	}
+	assert.NoError(t, r.saveHardwareData(host, inspected))
+
+	components := []metal3v1alpha1.FirmwareComponentStatus{
+		{Component: "bmc", InitialVersion: "1.0", CurrentVersion: "2.0"},
+	}
+	settings := map[string]string{"ProcVirtualization": "Enabled"}
+	host.Status.HardwareSummary.Firmware.Components = components
+	host.Status.HardwareSummary.Firmware.BIOSSettings = settings
+	assert.NoError(t, r.saveFirmwareStatus(host))
+
+	reinspected := &metal3v1alpha1.HardwareDataSpec{
+		HardwareDetails: &metal3v1alpha1.HardwareDetails{Hostname: "second"},
+	}
+	assert.NoError(t, r.saveHardwareData(host, reinspected))
+
+	assert.Equal(t, "second", host.Status.HardwareSummary.Hostname)
+	assert.Equal(t, components, host.Status.HardwareSummary.Firmware.Components)
+	assert.Equal(t, settings, host.Status.HardwareSummary.Firmware.BIOSSettings)
+	assert.Nil(t, reinspected.HardwareDetails.Firmware.Components)
+
+	details, err := getHardwareDetails(r, host)
+	if assert.NoError(t, err) && assert.NotNil(t, details) {
+		assert.Equal(t, "second", details.Hostname)
+		assert.Equal(t, components, details.Firmware.Components)
+		assert.Equal(t, settings, details.Firmware.BIOSSettings)
+	}
+}
+
+// TestCreateHardwareDetails ensures that the hardware summary portion
+// of the status block is filled in for new hosts, and that the full
+// details are stored in a HardwareData resource owned by the host.
 func TestCreateHardwareDetails(t *testing.T) {
 	host := newDefaultHost(t)
 	r := newTestReconciler(host)
 
 	tryReconcile(t, r, host,
 		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
-			t.Logf("new host details: %v", host.Status.HardwareDetails)
-			if host.Status.HardwareDetails != nil {
+			t.Logf("new host summary: %v", host.Status.HardwareSummary)
+			if host.Status.HardwareSummary != nil {
 				return true
 			}
 			return false
 		},
 	)
+
+	if assert.NotNil(t, host.Status.HardwareData) {
+		assert.Equal(t, host.Name, host.Status.HardwareData.Name)
+	}
+
+	hardwareData := &metal3v1alpha1.HardwareData{}
+	key := types.NamespacedName{Namespace: host.Namespace, Name: host.Name}
+	err := r.Get(goctx.TODO(), key, hardwareData)
+	assert.NoError(t, err)
+	assert.NotNil(t, hardwareData.Spec.HardwareDetails)
+	if assert.Len(t, hardwareData.OwnerReferences, 1) {
+		assert.Equal(t, host.Name, hardwareData.OwnerReferences[0].Name)
+		assert.Equal(t, "BareMetalHost", hardwareData.OwnerReferences[0].Kind)
+	}
+}
+
+// TestReinspect ensures that the inspect annotation returns a ready
+// host to inspection, replacing its hardware details, and that the
+// annotation is removed afterwards.
+func TestReinspect(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateReady)
+
+	host.Annotations = map[string]string{metal3v1alpha1.InspectAnnotation: ""}
+	host.Status.HardwareSummary.Hostname = "stale"
+	err := r.Update(goctx.TODO(), host)
+	assert.NoError(t, err)
+
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateInspecting)
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.HardwareSummary == nil
+		},
+	)
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateReady)
+
+	assert.False(t, host.HasInspectAnnotation())
+	assert.NotNil(t, host.Status.HardwareSummary)
+	assert.NotEqual(t, "stale", host.Status.HardwareSummary.Hostname)
+}
+
+// TestReinspectProvisioned ensures that the inspect annotation of a
+// provisioned host is removed with an event instead of inspecting it.
+func TestReinspectProvisioned(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Online = true
+	host.Spec.ExternallyProvisioned = true
+	r := newTestReconciler(host)
+
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateExternallyProvisioned)
+
+	host.Annotations = map[string]string{metal3v1alpha1.InspectAnnotation: ""}
+	err := r.Update(goctx.TODO(), host)
+	assert.NoError(t, err)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return !host.HasInspectAnnotation()
+		},
+	)
+
+	assert.Equal(t, metal3v1alpha1.StateExternallyProvisioned, host.Status.Provisioning.State)
+	events := &corev1.EventList{}
+	err = r.List(goctx.TODO(), events)
+	assert.NoError(t, err)
+	reasons := []string{}
+	for _, event := range events.Items {
+		reasons = append(reasons, event.Reason)
+	}
+	assert.Contains(t, reasons, "InspectionRejected")
+}
+
+// TestInspectionDisabled ensures that a host with inspection disabled
+// is never inspected and gets the hardware details supplied in its
+// annotations.
+func TestInspectionDisabled(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.InspectAnnotation:         metal3v1alpha1.InspectAnnotationDisabled,
+		metal3v1alpha1.HardwareDetailsAnnotation: `{"hostname": "node-0", "ramMebibytes": 4096}`,
+	}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			assert.NotEqual(t, metal3v1alpha1.StateInspecting, host.Status.Provisioning.State)
+			return host.Status.Provisioning.State == metal3v1alpha1.StateReady
+		},
+	)
+
+	assert.Equal(t, "node-0", host.Status.HardwareSummary.Hostname)
+	assert.Equal(t, 4096, host.Status.HardwareSummary.RAMMebibytes)
+	assert.NotContains(t, host.Annotations, metal3v1alpha1.HardwareDetailsAnnotation)
+}
+
+// TestInvalidHardwareDetails ensures that a hardware details annotation
+// that cannot be parsed is removed with an event, without keeping the
+// host from being reconciled.
+func TestInvalidHardwareDetails(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{
+		metal3v1alpha1.HardwareDetailsAnnotation: `{"hostname": `,
+	}
+	r := newTestReconciler(host)
+
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateReady)
+
+	assert.NotContains(t, host.Annotations, metal3v1alpha1.HardwareDetailsAnnotation)
+	assert.NotNil(t, host.Status.HardwareSummary)
+	events := &corev1.EventList{}
+	err := r.List(goctx.TODO(), events)
+	assert.NoError(t, err)
+	reasons := []string{}
+	for _, event := range events.Items {
+		reasons = append(reasons, event.Reason)
+	}
+	assert.Contains(t, reasons, "InvalidHardwareDetails")
+
+	// Details supplied once the host has been inspected are removed
+	// too, instead of being ignored on every pass.
+	host.Annotations = map[string]string{
+		metal3v1alpha1.HardwareDetailsAnnotation: `{"hostname": "node-0"}`,
+	}
+	err = r.Update(goctx.TODO(), host)
+	assert.NoError(t, err)
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			_, present := host.Annotations[metal3v1alpha1.HardwareDetailsAnnotation]
+			return !present
+		},
+	)
+	assert.NotEqual(t, "node-0", host.Status.HardwareSummary.Hostname)
 }
 
 // TestNeedsProvisioning verifies the logic for deciding when a host
@@ -894,6 +1105,261 @@ func TestProvision(t *testing.T) {
 	)
 }
 
+// TestProvisionChecksumURL ensures that the checksum read from a
+// checksum file is kept in the status instead of its URL.
+func TestProvisionChecksumURL(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).build()
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:      "https://example.com/image-name",
+		Checksum: "https://example.com/image-name.md5sum",
+	}
+	host.Status.Provisioning.Image = metal3v1alpha1.Image{
+		URL:          "https://example.com/image-name",
+		Checksum:     "d41d8cd98f00b204e9800998ecf8427e",
+		ChecksumType: metal3v1alpha1.MD5,
+	}
+	r := newTestReconciler()
+	info := makeDefaultReconcileInfo(host)
+
+	result := r.actionProvisioning(&mockProvisioner{}, info)
+
+	assert.Equal(t, actionComplete{}, result)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", host.Status.Provisioning.Image.Checksum)
+	assert.Equal(t, metal3v1alpha1.MD5, host.Status.Provisioning.Image.ChecksumType)
+}
+
+// userDataProvisioner reads the user data of the host when provisioning,
+// as the ironic provisioner does.
+type userDataProvisioner struct {
+	mockProvisioner
+}
+
+func (p *userDataProvisioner) Provision(configData provisioner.HostConfigData) (result provisioner.Result, err error) {
+	if _, err = configData.UserData(); err != nil {
+		return result, errors.Wrap(err, "could not retrieve user data")
+	}
+	return p.nextResult, nil
+}
+
+// TestProvisionConfigDataError ensures that user data that cannot be
+// used keeps the host provisioning with the error set and a single
+// event, instead of deprovisioning it and retrying.
+func TestProvisionConfigDataError(t *testing.T) {
+	testCases := []struct {
+		Scenario      string
+		Secret        *corev1.Secret
+		ExpectedType  metal3v1alpha1.ErrorType
+		ExpectedEvent string
+		ExpectedError string
+	}{
+		{
+			Scenario: "template",
+			Secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+				},
+				Data: map[string][]byte{"userData": []byte("{{ .Labels.missing }}")},
+			},
+			ExpectedType:  metal3v1alpha1.ProvisioningError,
+			ExpectedEvent: "ProvisioningError",
+			ExpectedError: "Failed to render template in key userData of secret user-data",
+		},
+		{
+			Scenario: "ignition",
+			Secret: &corev1.Secret{
+				Data: map[string][]byte{"ignition": []byte(`{"ignition": {"version": "1.0.0"}}`)},
+			},
+			ExpectedType:  metal3v1alpha1.IgnitionError,
+			ExpectedEvent: "IgnitionError",
+			ExpectedError: "Invalid Ignition config in key ignition of secret user-data",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateProvisioning).build()
+			host.Spec.Image = &metal3v1alpha1.Image{
+				URL:      "https://example.com/image-name",
+				Checksum: "12345",
+			}
+			host.Spec.UserData = &corev1.SecretReference{Name: "user-data", Namespace: namespace}
+			tc.Secret.Name = "user-data"
+			tc.Secret.Namespace = namespace
+			r := newTestReconciler(tc.Secret)
+			info := makeDefaultReconcileInfo(host)
+
+			result := r.actionProvisioning(&userDataProvisioner{}, info)
+
+			assert.IsType(t, actionContinue{}, result)
+			assert.Equal(t, tc.ExpectedType, host.Status.ErrorType)
+			assert.Contains(t, host.Status.ErrorMessage, tc.ExpectedError)
+			if assert.Len(t, info.events, 1) {
+				assert.Equal(t, tc.ExpectedEvent, info.events[0].Reason)
+			}
+
+			// The error is not recorded again while the data is
+			// unchanged.
+			info = makeDefaultReconcileInfo(host)
+			result = r.actionProvisioning(&userDataProvisioner{}, info)
+
+			assert.IsType(t, actionContinueNoWrite{}, result)
+			assert.Equal(t, 1, host.Status.ErrorCount)
+			assert.Empty(t, info.events)
+		})
+	}
+}
+
+// TestHostsForSecret ensures that a change to a Secret only triggers
+// the hosts whose BMC credentials or configuration data it holds.
+func TestHostsForSecret(t *testing.T) {
+	userData := newHost("user-data-host", &metal3v1alpha1.BareMetalHostSpec{
+		UserData: &corev1.SecretReference{Name: "config"},
+	})
+	networkData := newHost("network-data-host", &metal3v1alpha1.BareMetalHostSpec{
+		NetworkData: &corev1.SecretReference{Name: "config", Namespace: namespace},
+	})
+	otherNamespace := newHost("other-namespace-host", &metal3v1alpha1.BareMetalHostSpec{
+		UserData: &corev1.SecretReference{Name: "config", Namespace: "other"},
+	})
+	otherSecret := newHost("other-secret-host", &metal3v1alpha1.BareMetalHostSpec{
+		UserData: &corev1.SecretReference{Name: "other"},
+	})
+	credentials := newHost("credentials-host", &metal3v1alpha1.BareMetalHostSpec{
+		BMC: metal3v1alpha1.BMCDetails{CredentialsName: "config"},
+	})
+	r := newTestReconciler(userData, networkData, otherNamespace, otherSecret, credentials)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: namespace},
+	}
+
+	requests := r.hostsForSecret(handler.MapObject{Meta: secret, Object: secret})
+
+	var names []string
+	for _, request := range requests {
+		names = append(names, request.Name)
+	}
+	assert.ElementsMatch(t, []string{"user-data-host", "network-data-host", "credentials-host"}, names)
+}
+
+func TestHostForClaim(t *testing.T) {
+	claim := &metal3v1alpha1.HostClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: namespace},
+	}
+
+	assert.Empty(t, hostForClaim(handler.MapObject{Meta: claim, Object: claim}))
+
+	claim.Status.Host = &corev1.LocalObjectReference{Name: "myhost"}
+	assert.Equal(t,
+		[]reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "myhost"}}},
+		hostForClaim(handler.MapObject{Meta: claim, Object: claim}))
+}
+
+// TestPrepareRAID ensures that the RAID configuration is applied and
+// recorded in the status before the host becomes ready.
+func TestPrepareRAID(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.RAID = &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+			{Name: "root", Level: "1"},
+		},
+	}
+	r := newTestReconciler(host)
+
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StatePreparing)
+	waitForProvisioningState(t, r, host, metal3v1alpha1.StateReady)
+	assert.Equal(t, host.Spec.RAID, host.Status.Provisioning.RAID)
+}
+
+// preparingProvisioner applies the settings of the host in the
+// background, as the ironic provisioner does through cleaning.
+type preparingProvisioner struct {
+	mockProvisioner
+	unprepared bool
+}
+
+func (p *preparingProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
+	p.unprepared = unprepared
+	return p.nextResult, unprepared, nil
+}
+
+// TestPrepareRecordsSettingsOnCompletion ensures that the RAID
+// configuration and the firmware settings are applied one after the
+// other, and only recorded once the provisioner is done with them.
+func TestPrepareRecordsSettingsOnCompletion(t *testing.T) {
+	host := host(metal3v1alpha1.StatePreparing).build()
+	host.Spec.RAID = &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+			{Name: "root", Level: "1"},
+		},
+	}
+	host.Spec.Firmware = &metal3v1alpha1.FirmwareConfig{
+		Settings: map[string]string{"ProcVirtualization": "Enabled"},
+	}
+	r := newTestReconciler()
+	info := makeDefaultReconcileInfo(host)
+	prov := &preparingProvisioner{}
+	prov.setNextResult(true)
+
+	r.actionPreparing(prov, info)
+	assert.True(t, prov.unprepared)
+	assert.Equal(t, host.Spec.RAID, host.Status.Provisioning.PendingRAID)
+	assert.Nil(t, host.Status.Provisioning.PendingFirmware)
+	assert.Nil(t, host.Status.Provisioning.RAID)
+
+	r.actionPreparing(prov, info)
+	assert.False(t, prov.unprepared)
+	assert.Nil(t, host.Status.Provisioning.RAID)
+
+	prov.setNextResult(false)
+	result := r.actionPreparing(prov, info)
+	assert.IsType(t, actionContinue{}, result)
+	assert.False(t, prov.unprepared)
+	assert.Equal(t, host.Spec.RAID, host.Status.Provisioning.RAID)
+	assert.Nil(t, host.Status.Provisioning.PendingRAID)
+
+	prov.setNextResult(true)
+	r.actionPreparing(prov, info)
+	assert.True(t, prov.unprepared)
+	assert.Equal(t, host.Spec.Firmware, host.Status.Provisioning.PendingFirmware)
+	assert.Nil(t, host.Status.Provisioning.Firmware)
+
+	prov.setNextResult(false)
+	result = r.actionPreparing(prov, info)
+	assert.IsType(t, actionComplete{}, result)
+	assert.Equal(t, host.Spec.Firmware, host.Status.Provisioning.Firmware)
+	assert.Nil(t, host.Status.Provisioning.PendingFirmware)
+}
+
+// TestPrepareFailureKeepsAppliedSettings ensures that a failure to
+// apply the firmware settings does not cause the RAID configuration to
+// be applied again.
+func TestPrepareFailureKeepsAppliedSettings(t *testing.T) {
+	host := host(metal3v1alpha1.StatePreparing).build()
+	host.Spec.RAID = &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+			{Name: "root", Level: "1"},
+		},
+	}
+	host.Spec.Firmware = &metal3v1alpha1.FirmwareConfig{
+		Settings: map[string]string{"ProcVirtualization": "Enabled"},
+	}
+	host.Status.Provisioning.RAID = host.Spec.RAID.DeepCopy()
+	host.Status.Provisioning.PendingFirmware = host.Spec.Firmware.DeepCopy()
+	r := newTestReconciler()
+	info := makeDefaultReconcileInfo(host)
+	prov := &preparingProvisioner{}
+	prov.setNextError("invalid setting")
+
+	result := r.actionPreparing(prov, info)
+
+	assert.IsType(t, actionFailed{}, result)
+	assert.Equal(t, metal3v1alpha1.PreparationError, host.Status.ErrorType)
+	assert.Nil(t, host.Status.Provisioning.PendingFirmware)
+	assert.Equal(t, host.Spec.RAID, host.Status.Provisioning.RAID)
+	assert.False(t, host.RAIDConfigChanged())
+	assert.True(t, host.FirmwareConfigChanged())
+}
+
 // TestExternallyProvisionedTransitions ensures that host enters the
 // expected states when it looks like it has been provisioned by
 // another tool.
@@ -1011,14 +1477,14 @@ func TestDeleteHost(t *testing.T) {
 		},
 		func() *metal3v1alpha1.BareMetalHost {
 			host := newDefaultNamedHost("host-with-hw-details", t)
-			host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{}
+			host.Status.HardwareSummary = &metal3v1alpha1.HardwareSummary{}
 			host.Finalizers = append(host.Finalizers,
 				metal3v1alpha1.BareMetalHostFinalizer)
 			return host
 		},
 		func() *metal3v1alpha1.BareMetalHost {
 			host := newDefaultNamedHost("provisioned-host", t)
-			host.Status.HardwareDetails = &metal3v1alpha1.HardwareDetails{}
+			host.Status.HardwareSummary = &metal3v1alpha1.HardwareSummary{}
 			host.Status.Provisioning.Image = metal3v1alpha1.Image{
 				URL:      "image-url",
 				Checksum: "image-checksum",
@@ -1178,3 +1644,90 @@ func TestProvisionerIsReady(t *testing.T) {
 		},
 	)
 }
+
+func TestActionMatchProfile(t *testing.T) {
+	qemu := &metal3v1alpha1.HardwareProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "qemu"},
+		Spec: metal3v1alpha1.HardwareProfileSpec{
+			MatchRules: &metal3v1alpha1.HardwareMatchRules{
+				Manufacturer: "^QEMU$",
+			},
+		},
+	}
+	hardware.SetProfileReader(hardware.NewFakeProfileReader(qemu))
+	defer hardware.SetProfileReader(hardware.NewFakeProfileReader())
+
+	testCases := []struct {
+		Scenario     string
+		Host         *metal3v1alpha1.BareMetalHost
+		Details      *metal3v1alpha1.HardwareDetails
+		Expected     string
+		EventMessage string
+	}{
+		{
+			Scenario: "spec",
+			Host: func() *metal3v1alpha1.BareMetalHost {
+				h := host(metal3v1alpha1.StateMatchProfile).SetHardwareDetails().build()
+				h.Spec.HardwareProfile = "dell"
+				return h
+			}(),
+			Expected:     "dell",
+			EventMessage: "Hardware profile set: dell (set in the host spec)",
+		},
+		{
+			Scenario: "rules",
+			Host: func() *metal3v1alpha1.BareMetalHost {
+				h := host(metal3v1alpha1.StateMatchProfile).SetHardwareDetails().build()
+				h.Status.HardwareData = &corev1.LocalObjectReference{Name: h.Name}
+				return h
+			}(),
+			Details: &metal3v1alpha1.HardwareDetails{
+				SystemVendor: metal3v1alpha1.HardwareSystemVendor{Manufacturer: "QEMU"},
+			},
+			Expected:     "qemu",
+			EventMessage: `Hardware profile set: qemu (matched manufacturer "QEMU" matches "^QEMU$")`,
+		},
+		{
+			Scenario: "libvirt BMC",
+			Host: func() *metal3v1alpha1.BareMetalHost {
+				h := host(metal3v1alpha1.StateMatchProfile).SetHardwareDetails().build()
+				h.Spec.BMC.Address = "libvirt://192.168.122.1:6233/"
+				return h
+			}(),
+			Expected:     "libvirt",
+			EventMessage: "Hardware profile set: libvirt (libvirt BMC address)",
+		},
+		{
+			Scenario:     "default",
+			Host:         host(metal3v1alpha1.StateMatchProfile).SetHardwareDetails().build(),
+			Expected:     hardware.DefaultProfileName,
+			EventMessage: "Hardware profile set: unknown (no profile matched)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var objs []runtime.Object
+			if tc.Details != nil {
+				objs = append(objs, &metal3v1alpha1.HardwareData{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tc.Host.Name,
+						Namespace: tc.Host.Namespace,
+					},
+					Spec: metal3v1alpha1.HardwareDataSpec{HardwareDetails: tc.Details},
+				})
+			}
+			r := newTestReconciler(objs...)
+			info := makeDefaultReconcileInfo(tc.Host)
+
+			result := r.actionMatchProfile(nil, info)
+
+			assert.Equal(t, actionComplete{}, result)
+			assert.Equal(t, tc.Expected, tc.Host.Status.HardwareProfile)
+			if assert.Len(t, info.events, 1) {
+				assert.Equal(t, "ProfileSet", info.events[0].Reason)
+				assert.Equal(t, tc.EventMessage, info.events[0].Message)
+			}
+		})
+	}
+}
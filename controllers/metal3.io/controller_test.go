@@ -5,10 +5,14 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 )
 
 func init() {
 	logf.SetLogger(logf.ZapLogger(true))
 	// Register our package types with the global scheme
 	metal3v1alpha1.AddToScheme(scheme.Scheme)
+
+	// Make the default hardware profiles available
+	hardware.SetProfileReader(hardware.NewFakeProfileReader())
 }
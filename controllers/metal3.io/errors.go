@@ -58,3 +58,42 @@ type NoDataInSecretError struct {
 func (e NoDataInSecretError) Error() string {
 	return fmt.Sprintf("Secret %s does not contain key %s", e.secret, e.key)
 }
+
+// ConfigDataTemplateError is returned when host configuration
+// data marked as a template cannot be rendered
+type ConfigDataTemplateError struct {
+	secret string
+	key    string
+	err    error
+}
+
+func (e ConfigDataTemplateError) Error() string {
+	return fmt.Sprintf("Failed to render template in key %s of secret %s: %s",
+		e.key, e.secret, e.err)
+}
+
+// NetworkDataConversionError is returned when nmstate network data
+// cannot be converted to network_data.json
+type NetworkDataConversionError struct {
+	secret string
+	key    string
+	err    error
+}
+
+func (e NetworkDataConversionError) Error() string {
+	return fmt.Sprintf("Failed to convert nmstate in key %s of secret %s: %s",
+		e.key, e.secret, e.err)
+}
+
+// IgnitionValidationError is returned when the Ignition config in the
+// user data of a host is invalid
+type IgnitionValidationError struct {
+	secret string
+	key    string
+	err    error
+}
+
+func (e IgnitionValidationError) Error() string {
+	return fmt.Sprintf("Invalid Ignition config in key %s of secret %s: %s",
+		e.key, e.secret, e.err)
+}
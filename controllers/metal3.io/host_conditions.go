@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// errorReasons maps the error types recorded in the host status to
+// the reasons used for events and conditions.
+var errorReasons = map[metal3v1alpha1.ErrorType]string{
+	metal3v1alpha1.RegistrationError:    "RegistrationError",
+	metal3v1alpha1.InspectionError:      "InspectionError",
+	metal3v1alpha1.ProvisioningError:    "ProvisioningError",
+	metal3v1alpha1.PowerManagementError: "PowerManagementError",
+	metal3v1alpha1.PreparationError:     "PreparationError",
+	metal3v1alpha1.FirmwareUpdateError:  "FirmwareUpdateError",
+	metal3v1alpha1.IgnitionError:        "IgnitionError",
+}
+
+// stateReasons maps the provisioning states to the reasons used for
+// conditions derived from the state.
+var stateReasons = map[metal3v1alpha1.ProvisioningState]string{
+	metal3v1alpha1.StateNone:                  "Discovered",
+	metal3v1alpha1.StateUnmanaged:             "Unmanaged",
+	metal3v1alpha1.StateRegistrationError:     "RegistrationError",
+	metal3v1alpha1.StateRegistering:           "Registering",
+	metal3v1alpha1.StateMatchProfile:          "MatchProfile",
+	metal3v1alpha1.StateUpdatingFirmware:      "UpdatingFirmware",
+	metal3v1alpha1.StatePreparing:             "Preparing",
+	metal3v1alpha1.StateReady:                 "Ready",
+	metal3v1alpha1.StateAvailable:             "Available",
+	metal3v1alpha1.StateProvisioning:          "Provisioning",
+	metal3v1alpha1.StateProvisioningError:     "ProvisioningError",
+	metal3v1alpha1.StateProvisioned:           "Provisioned",
+	metal3v1alpha1.StateExternallyProvisioned: "ExternallyProvisioned",
+	metal3v1alpha1.StateDeprovisioning:        "Deprovisioning",
+	metal3v1alpha1.StateInspecting:            "Inspecting",
+	metal3v1alpha1.StatePowerManagementError:  "PowerManagementError",
+	metal3v1alpha1.StateDeleting:              "Deleting",
+}
+
+func stateReason(state metal3v1alpha1.ProvisioningState) string {
+	if reason, ok := stateReasons[state]; ok {
+		return reason
+	}
+	return "Unknown"
+}
+
+func conditionStatus(value bool) metav1.ConditionStatus {
+	if value {
+		return metav1.ConditionTrue
+	}
+	return metav1.ConditionFalse
+}
+
+// hostIsRegistered returns true when the state of the host means it
+// has been registered with the provisioner.
+func hostIsRegistered(state metal3v1alpha1.ProvisioningState) bool {
+	switch state {
+	case metal3v1alpha1.StateNone,
+		metal3v1alpha1.StateUnmanaged,
+		metal3v1alpha1.StateRegistering,
+		metal3v1alpha1.StateRegistrationError,
+		metal3v1alpha1.StateDeleting:
+		return false
+	}
+	return true
+}
+
+// updateHostConditions recomputes the conditions in the host status
+// from the provisioning state and the other status fields. The
+// existing OperationalStatus, ErrorType and ErrorMessage fields
+// remain the source of truth, so this function may be called any
+// time those change. It returns true when any condition changed.
+func updateHostConditions(host *metal3v1alpha1.BareMetalHost) (dirty bool) {
+	state := host.Status.Provisioning.State
+	errType := host.Status.ErrorType
+	errReason, ok := errorReasons[errType]
+	if !ok {
+		errReason = "Error"
+	}
+	errMessage := host.Status.ErrorMessage
+	if !host.HasError() {
+		errType = ""
+	}
+	detached := host.OperationalStatus() == metal3v1alpha1.OperationalStatusDetached
+
+	set := func(condType string, status metav1.ConditionStatus, reason, message string) {
+		if host.SetCondition(condType, status, reason, message) {
+			dirty = true
+		}
+	}
+
+	// Registered
+	switch {
+	case detached:
+		set(metal3v1alpha1.RegisteredCondition, metav1.ConditionFalse, "Detached", "")
+	case errType == metal3v1alpha1.RegistrationError:
+		set(metal3v1alpha1.RegisteredCondition, metav1.ConditionFalse, errReason, errMessage)
+	case hostIsRegistered(state):
+		set(metal3v1alpha1.RegisteredCondition, metav1.ConditionTrue, "Registered", "")
+	default:
+		set(metal3v1alpha1.RegisteredCondition, metav1.ConditionFalse, stateReason(state), "")
+	}
+
+	// BMCReachable
+	switch {
+	case errType == metal3v1alpha1.RegistrationError,
+		errType == metal3v1alpha1.PowerManagementError:
+		set(metal3v1alpha1.BMCReachableCondition, metav1.ConditionFalse, errReason, errMessage)
+	case host.Status.GoodCredentials.Reference != nil:
+		set(metal3v1alpha1.BMCReachableCondition, metav1.ConditionTrue, "BMCAccessValidated", "")
+	default:
+		set(metal3v1alpha1.BMCReachableCondition, metav1.ConditionUnknown, "NotValidated", "")
+	}
+
+	// Inspected
+	switch {
+	case host.Status.HardwareSummary != nil:
+		set(metal3v1alpha1.InspectedCondition, metav1.ConditionTrue, "InspectionComplete", "")
+	case errType == metal3v1alpha1.InspectionError:
+		set(metal3v1alpha1.InspectedCondition, metav1.ConditionFalse, errReason, errMessage)
+	case state == metal3v1alpha1.StateInspecting:
+		set(metal3v1alpha1.InspectedCondition, metav1.ConditionFalse, stateReason(state), "")
+	case host.InspectionDisabled():
+		set(metal3v1alpha1.InspectedCondition, metav1.ConditionFalse, "InspectionDisabled", "")
+	default:
+		set(metal3v1alpha1.InspectedCondition, metav1.ConditionFalse, "NotInspected", "")
+	}
+
+	// Provisioned
+	switch {
+	case state == metal3v1alpha1.StateProvisioned,
+		state == metal3v1alpha1.StateExternallyProvisioned:
+		set(metal3v1alpha1.ProvisionedCondition, metav1.ConditionTrue, stateReason(state), "")
+	case errType == metal3v1alpha1.ProvisioningError:
+		set(metal3v1alpha1.ProvisionedCondition, metav1.ConditionFalse, errReason, errMessage)
+	case state == metal3v1alpha1.StateProvisioning,
+		state == metal3v1alpha1.StateDeprovisioning:
+		set(metal3v1alpha1.ProvisionedCondition, metav1.ConditionFalse, stateReason(state), "")
+	default:
+		set(metal3v1alpha1.ProvisionedCondition, metav1.ConditionFalse, "NotProvisioned", "")
+	}
+
+	// PoweredOn
+	switch {
+	case detached:
+		set(metal3v1alpha1.PoweredOnCondition, metav1.ConditionUnknown, "Detached", "")
+	case !hostIsRegistered(state):
+		set(metal3v1alpha1.PoweredOnCondition, metav1.ConditionUnknown, stateReason(state), "")
+	case errType == metal3v1alpha1.PowerManagementError:
+		set(metal3v1alpha1.PoweredOnCondition, conditionStatus(host.Status.PoweredOn), errReason, errMessage)
+	case host.Status.PoweredOn:
+		set(metal3v1alpha1.PoweredOnCondition, metav1.ConditionTrue, "PoweredOn", "")
+	default:
+		set(metal3v1alpha1.PoweredOnCondition, metav1.ConditionFalse, "PoweredOff", "")
+	}
+
+	// Ready
+	switch {
+	case detached:
+		set(metal3v1alpha1.ReadyCondition, metav1.ConditionFalse, "Detached",
+			"host is detached from the provisioner")
+	case errType != "":
+		set(metal3v1alpha1.ReadyCondition, metav1.ConditionFalse, errReason, errMessage)
+	case state == metal3v1alpha1.StateReady,
+		state == metal3v1alpha1.StateAvailable,
+		state == metal3v1alpha1.StateProvisioned,
+		state == metal3v1alpha1.StateExternallyProvisioned:
+		set(metal3v1alpha1.ReadyCondition, metav1.ConditionTrue, stateReason(state), "")
+	default:
+		set(metal3v1alpha1.ReadyCondition, metav1.ConditionFalse, stateReason(state),
+			fmt.Sprintf("host is in provisioning state %q", state))
+	}
+
+	return dirty
+}
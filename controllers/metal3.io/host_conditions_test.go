@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestUpdateHostConditions(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Host     *metal3v1alpha1.BareMetalHost
+		Expected map[string]metav1.ConditionStatus
+	}{
+		{
+			Scenario: "registering",
+			Host:     host(metal3v1alpha1.StateRegistering).build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionFalse,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionTrue,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionFalse,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionFalse,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionUnknown,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionFalse,
+			},
+		},
+		{
+			Scenario: "inspection disabled",
+			Host: host(metal3v1alpha1.StateMatchProfile).
+				SetInspectionDisabled().build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionTrue,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionTrue,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionFalse,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionFalse,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionFalse,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionFalse,
+			},
+		},
+		{
+			Scenario: "ready",
+			Host: host(metal3v1alpha1.StateReady).
+				SetHardwareDetails().build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionTrue,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionTrue,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionFalse,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionFalse,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionTrue,
+			},
+		},
+		{
+			Scenario: "provisioned",
+			Host: host(metal3v1alpha1.StateProvisioned).
+				SetHardwareDetails().SetPoweredOn().build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionTrue,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionTrue,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionTrue,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionTrue,
+			},
+		},
+		{
+			Scenario: "externally provisioned",
+			Host: host(metal3v1alpha1.StateExternallyProvisioned).
+				SetExternallyProvisioned().SetPoweredOn().build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionTrue,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionTrue,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionFalse,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionTrue,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionTrue,
+			},
+		},
+		{
+			Scenario: "detached",
+			Host: host(metal3v1alpha1.StateProvisioned).
+				SetHardwareDetails().SetPoweredOn().
+				SetOperationalStatus(metal3v1alpha1.OperationalStatusDetached).build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionFalse,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionTrue,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionTrue,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionUnknown,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionFalse,
+			},
+		},
+		{
+			Scenario: "registration error",
+			Host: host(metal3v1alpha1.StateRegistrationError).
+				SetError(metal3v1alpha1.RegistrationError).build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionFalse,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionFalse,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionFalse,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionFalse,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionUnknown,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionFalse,
+			},
+		},
+		{
+			Scenario: "power management error",
+			Host: host(metal3v1alpha1.StateProvisioned).
+				SetHardwareDetails().SetPoweredOn().
+				SetError(metal3v1alpha1.PowerManagementError).build(),
+			Expected: map[string]metav1.ConditionStatus{
+				metal3v1alpha1.RegisteredCondition:   metav1.ConditionTrue,
+				metal3v1alpha1.BMCReachableCondition: metav1.ConditionFalse,
+				metal3v1alpha1.InspectedCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ProvisionedCondition:  metav1.ConditionTrue,
+				metal3v1alpha1.PoweredOnCondition:    metav1.ConditionTrue,
+				metal3v1alpha1.ReadyCondition:        metav1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			dirty := updateHostConditions(tc.Host)
+			assert.True(t, dirty)
+			for condType, expected := range tc.Expected {
+				cond := tc.Host.GetCondition(condType)
+				if assert.NotNil(t, cond, condType) {
+					assert.Equal(t, expected, cond.Status, condType)
+					assert.NotEmpty(t, cond.Reason, condType)
+				}
+			}
+
+			// A second pass over an unchanged host is a no-op.
+			assert.False(t, updateHostConditions(tc.Host))
+		})
+	}
+}
+
+func TestUpdateHostConditionsErrorMessage(t *testing.T) {
+	host := host(metal3v1alpha1.StateInspecting).
+		SetError(metal3v1alpha1.InspectionError).build()
+
+	updateHostConditions(host)
+
+	cond := host.GetCondition(metal3v1alpha1.InspectedCondition)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "InspectionError", cond.Reason)
+	assert.Equal(t, "some error", cond.Message)
+
+	host.ClearError()
+	assert.True(t, updateHostConditions(host))
+
+	cond = host.GetCondition(metal3v1alpha1.InspectedCondition)
+	assert.Equal(t, "Inspecting", cond.Reason)
+	assert.Equal(t, "", cond.Message)
+}
+
+func TestConditionsUpdatedOnActionFailure(t *testing.T) {
+	host := host(metal3v1alpha1.StateInspecting).build()
+	prov := &mockProvisioner{}
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{}, prov)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.setNextError("inspection failed")
+	hsm.ReconcileState(info)
+
+	cond := host.GetCondition(metal3v1alpha1.InspectedCondition)
+	if assert.NotNil(t, cond) {
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, "InspectionError", cond.Reason)
+		assert.Equal(t, "inspection failed", cond.Message)
+	}
+}
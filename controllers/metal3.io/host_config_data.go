@@ -3,10 +3,14 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"text/template"
 
 	"github.com/pkg/errors"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/ignition"
+	"github.com/metal3-io/baremetal-operator/pkg/nmstate"
 
 	"github.com/go-logr/logr"
 
@@ -15,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 // hostConfigData is an implementation of host configuration data interface.
@@ -25,10 +30,8 @@ type hostConfigData struct {
 	client client.Client
 }
 
-// Generic method for data extraction from a Secret. Function uses dataKey
-// parameter to detirmine which data to return in case secret contins multiple
-// keys
-func (hcd *hostConfigData) getSecretData(name, namespace, dataKey string) (string, error) {
+// getSecret fetches a Secret holding host configuration data
+func (hcd *hostConfigData) getSecret(name, namespace string) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
 	key := types.NamespacedName{
 		Name:      name,
@@ -36,21 +39,133 @@ func (hcd *hostConfigData) getSecretData(name, namespace, dataKey string) (strin
 	}
 	if err := hcd.client.Get(context.TODO(), key, secret); err != nil {
 		errMsg := fmt.Sprintf("failed to fetch user data from secret %s defined in namespace %s", name, namespace)
-		return "", errors.Wrap(err, errMsg)
+		return nil, errors.Wrap(err, errMsg)
 	}
+	return secret, nil
+}
 
+// Generic method for data extraction from a Secret. Function uses dataKey
+// parameter to detirmine which data to return in case secret contins multiple
+// keys
+func (hcd *hostConfigData) getSecretData(name, namespace, dataKey string) (string, error) {
+	secret, err := hcd.getSecret(name, namespace)
+	if err != nil {
+		return "", err
+	}
+	return hcd.secretData(secret, dataKey)
+}
+
+// secretData returns the data under dataKey in a Secret, rendered if
+// the Secret is a template
+func (hcd *hostConfigData) secretData(secret *corev1.Secret, dataKey string) (string, error) {
 	data, ok := secret.Data[dataKey]
-	if ok {
+	if !ok {
+		// There is no data under dataKey (userData or networkData).
+		// Tring to falback to 'value' key
+		if data, ok = secret.Data["value"]; !ok {
+			hostConfigDataError.WithLabelValues(dataKey).Inc()
+			return "", NoDataInSecretError{secret: secret.Name, key: dataKey}
+		}
+	}
+
+	if secret.Annotations[metal3v1alpha1.TemplateAnnotation] != "true" {
 		return string(data), nil
 	}
-	// There is no data under dataKey (userData or networkData).
-	// Tring to falback to 'value' key
-	if data, ok = secret.Data["value"]; !ok {
+	rendered, err := hcd.renderTemplate(string(data))
+	if err != nil {
 		hostConfigDataError.WithLabelValues(dataKey).Inc()
-		return "", NoDataInSecretError{secret: name, key: dataKey}
+		return "", ConfigDataTemplateError{secret: secret.Name, key: dataKey, err: err}
+	}
+	return rendered, nil
+}
+
+// isNMState returns whether a network data Secret holds nmstate
+func isNMState(secret *corev1.Secret) bool {
+	if _, ok := secret.Data[metal3v1alpha1.NMStateNetworkDataKey]; ok {
+		return true
+	}
+	return secret.Annotations[metal3v1alpha1.NetworkDataFormatAnnotation] == metal3v1alpha1.NetworkDataFormatNMState
+}
+
+// nmstateData returns the nmstate network data of a Secret and the key
+// it was found under
+func (hcd *hostConfigData) nmstateData(secret *corev1.Secret) (data, dataKey string, err error) {
+	dataKey = "networkData"
+	if _, ok := secret.Data[metal3v1alpha1.NMStateNetworkDataKey]; ok {
+		dataKey = metal3v1alpha1.NMStateNetworkDataKey
+	}
+	data, err = hcd.secretData(secret, dataKey)
+	return data, dataKey, err
+}
+
+// nmstateNetworkData converts the nmstate network data of a Secret to
+// network_data.json. Ethernet interfaces without a MAC address are
+// matched by name to the NICs found by inspection.
+func (hcd *hostConfigData) nmstateNetworkData(secret *corev1.Secret) (string, error) {
+	data, dataKey, err := hcd.nmstateData(secret)
+	if err != nil {
+		return "", err
+	}
+
+	details, err := getHardwareDetails(hcd.client, hcd.host)
+	if err != nil {
+		return "", err
+	}
+	macs := map[string]string{}
+	if details != nil {
+		for _, nic := range details.NIC {
+			macs[nic.Name] = nic.MAC
+		}
+	}
+
+	networkData, err := nmstate.ToNetworkData([]byte(data), macs)
+	if err != nil {
+		hostConfigDataError.WithLabelValues(dataKey).Inc()
+		return "", NetworkDataConversionError{secret: secret.Name, key: dataKey, err: err}
+	}
+	return string(networkData), nil
+}
+
+// templateValues are the attributes of a host that templates of host
+// configuration data are rendered with.
+type templateValues struct {
+	Name            string
+	Namespace       string
+	UID             string
+	Labels          map[string]string
+	Annotations     map[string]string
+	BootMACAddress  string
+	HardwareDetails *metal3v1alpha1.HardwareDetails
+}
+
+// renderTemplate renders host configuration data marked as a template.
+// Referring to a label or annotation the host does not have, or to
+// hardware details of a host that has not been inspected, is an error.
+func (hcd *hostConfigData) renderTemplate(text string) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
 	}
 
-	return string(data), nil
+	details, err := getHardwareDetails(hcd.client, hcd.host)
+	if err != nil {
+		return "", err
+	}
+	values := templateValues{
+		Name:            hcd.host.Name,
+		Namespace:       hcd.host.Namespace,
+		UID:             string(hcd.host.UID),
+		Labels:          hcd.host.Labels,
+		Annotations:     hcd.host.Annotations,
+		BootMACAddress:  hcd.host.Spec.BootMACAddress,
+		HardwareDetails: details,
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, values); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
 }
 
 // UserData get Operating System configuration data
@@ -63,12 +178,78 @@ func (hcd *hostConfigData) UserData() (string, error) {
 	if namespace == "" {
 		namespace = hcd.host.Namespace
 	}
-	return hcd.getSecretData(
-		hcd.host.Spec.UserData.Name,
-		namespace,
-		"userData",
-	)
+	secret, err := hcd.getSecret(hcd.host.Spec.UserData.Name, namespace)
+	if err != nil {
+		return "", err
+	}
 
+	dataKey := "userData"
+	_, isIgnition := secret.Data[metal3v1alpha1.IgnitionUserDataKey]
+	if isIgnition {
+		dataKey = metal3v1alpha1.IgnitionUserDataKey
+	}
+	data, err := hcd.secretData(secret, dataKey)
+	if err != nil || !(isIgnition || ignition.IsConfig([]byte(data))) {
+		return data, err
+	}
+	return hcd.ignitionUserData(secret.Name, dataKey, data)
+}
+
+// ignitionUserData validates an Ignition config and adds the hostname
+// of the host to it, as well as its network data when written for
+// nmstate, since Ignition does not apply the metadata and network data
+// of config drives.
+func (hcd *hostConfigData) ignitionUserData(secretName, dataKey, data string) (string, error) {
+	hostname, err := hcd.hostname()
+	if err != nil {
+		return "", err
+	}
+	files := []ignition.File{
+		{Path: "/etc/hostname", Mode: 0644, Contents: hostname + "\n"},
+	}
+
+	if hcd.host.Spec.NetworkData != nil {
+		secret, err := hcd.networkDataSecret()
+		if err != nil {
+			return "", err
+		}
+		if isNMState(secret) {
+			networkData, _, err := hcd.nmstateData(secret)
+			if err != nil {
+				return "", err
+			}
+			// Applied at boot by the nmstate service of CoreOS.
+			files = append(files, ignition.File{
+				Path: "/etc/nmstate/metal3.yml", Mode: 0600, Contents: networkData,
+			})
+		}
+	}
+
+	merged, err := ignition.Merge([]byte(data), files)
+	if err != nil {
+		hostConfigDataError.WithLabelValues(dataKey).Inc()
+		return "", IgnitionValidationError{secret: secretName, key: dataKey, err: err}
+	}
+	return string(merged), nil
+}
+
+// hostname returns the hostname of the host, which the metadata can
+// set with local-hostname
+func (hcd *hostConfigData) hostname() (string, error) {
+	metaDataRaw, err := hcd.MetaData()
+	if err != nil {
+		return "", err
+	}
+	var metaData struct {
+		LocalHostname string `json:"local-hostname"`
+	}
+	if err := yaml.Unmarshal([]byte(metaDataRaw), &metaData); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal metadata from secret")
+	}
+	if metaData.LocalHostname != "" {
+		return metaData.LocalHostname, nil
+	}
+	return hcd.host.Name, nil
 }
 
 // NetworkData get network configuration
@@ -77,15 +258,23 @@ func (hcd *hostConfigData) NetworkData() (string, error) {
 		hcd.log.Info("NetworkData is not set returning epmty(nil) data")
 		return "", nil
 	}
+	secret, err := hcd.networkDataSecret()
+	if err != nil {
+		return "", err
+	}
+	if isNMState(secret) {
+		return hcd.nmstateNetworkData(secret)
+	}
+	return hcd.secretData(secret, "networkData")
+}
+
+// networkDataSecret fetches the network data Secret of the host
+func (hcd *hostConfigData) networkDataSecret() (*corev1.Secret, error) {
 	namespace := hcd.host.Spec.NetworkData.Namespace
 	if namespace == "" {
 		namespace = hcd.host.Namespace
 	}
-	return hcd.getSecretData(
-		hcd.host.Spec.NetworkData.Name,
-		namespace,
-		"networkData",
-	)
+	return hcd.getSecret(hcd.host.Spec.NetworkData.Name, namespace)
 }
 
 // MetaData get host metatdata
@@ -4,9 +4,13 @@ import (
 	goctx "context"
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -260,3 +264,307 @@ func TestProvisionWithHostConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestHostConfigDataTemplate(t *testing.T) {
+	testCases := []struct {
+		Scenario    string
+		Template    string
+		Annotations map[string]string
+		Details     *metal3v1alpha1.HardwareDetails
+		Expected    string
+		Err         bool
+	}{
+		{
+			Scenario: "not a template",
+			Template: "hostname: {{ .Name }}",
+			Expected: "hostname: {{ .Name }}",
+		},
+		{
+			Scenario:    "host attributes",
+			Template:    "hostname: {{ .Name }}.{{ .Namespace }}\nrole: {{ .Labels.role }}\nmac: {{ .BootMACAddress }}",
+			Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+			Expected:    "hostname: host-template.test-namespace\nrole: worker\nmac: 00:11:22:33:44:55",
+		},
+		{
+			Scenario:    "hardware details",
+			Template:    "mac: {{ (index .HardwareDetails.NIC 0).MAC }}\nserial: {{ .HardwareDetails.SystemVendor.SerialNumber }}",
+			Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+			Details: &metal3v1alpha1.HardwareDetails{
+				NIC:          []metal3v1alpha1.NIC{{MAC: "00:11:22:33:44:66"}},
+				SystemVendor: metal3v1alpha1.HardwareSystemVendor{SerialNumber: "ABC123"},
+			},
+			Expected: "mac: 00:11:22:33:44:66\nserial: ABC123",
+		},
+		{
+			Scenario:    "missing label",
+			Template:    "zone: {{ .Labels.zone }}",
+			Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+			Err:         true,
+		},
+		{
+			Scenario:    "not inspected",
+			Template:    "serial: {{ .HardwareDetails.SystemVendor.SerialNumber }}",
+			Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+			Err:         true,
+		},
+		{
+			Scenario:    "invalid template",
+			Template:    "hostname: {{ .Name",
+			Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+			Err:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newHost("host-template", &metal3v1alpha1.BareMetalHostSpec{
+				BootMACAddress: "00:11:22:33:44:55",
+				UserData: &corev1.SecretReference{
+					Name:      "user-data",
+					Namespace: namespace,
+				},
+			})
+			host.Labels = map[string]string{"role": "worker"}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "user-data",
+					Namespace:   namespace,
+					Annotations: tc.Annotations,
+				},
+				Data: map[string][]byte{"userData": []byte(tc.Template)},
+			}
+			objs := []runtime.Object{host, secret}
+			if tc.Details != nil {
+				host.Status.HardwareData = &corev1.LocalObjectReference{Name: host.Name}
+				objs = append(objs, &metal3v1alpha1.HardwareData{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      host.Name,
+						Namespace: namespace,
+					},
+					Spec: metal3v1alpha1.HardwareDataSpec{HardwareDetails: tc.Details},
+				})
+			}
+
+			hcd := &hostConfigData{
+				host:   host,
+				log:    ctrl.Log.WithName("Test").WithName(tc.Scenario),
+				client: fakeclient.NewFakeClient(objs...),
+			}
+			userData, err := hcd.UserData()
+
+			if tc.Err {
+				assert.Error(t, err)
+				assert.IsType(t, ConfigDataTemplateError{}, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, userData)
+		})
+	}
+}
+
+func TestHostConfigDataNMState(t *testing.T) {
+	nmstate := `
+interfaces:
+- name: eth0
+  type: ethernet
+  ipv4:
+    enabled: true
+    dhcp: true
+`
+	networkData := `{"links":[{"id":"eth0","type":"phy","ethernet_mac_address":"00:11:22:33:44:55"}],` +
+		`"networks":[{"id":"network0","type":"ipv4_dhcp","link":"eth0"}]}`
+
+	testCases := []struct {
+		Scenario    string
+		Data        map[string][]byte
+		Annotations map[string]string
+		Inspected   bool
+		Expected    string
+		Err         bool
+	}{
+		{
+			Scenario: "network_data.json",
+			Data:     map[string][]byte{"networkData": []byte(networkData)},
+			Expected: networkData,
+		},
+		{
+			Scenario:  "nmstate key",
+			Data:      map[string][]byte{"nmstate": []byte(nmstate)},
+			Inspected: true,
+			Expected:  networkData,
+		},
+		{
+			Scenario: "nmstate annotation",
+			Data:     map[string][]byte{"networkData": []byte(nmstate)},
+			Annotations: map[string]string{
+				metal3v1alpha1.NetworkDataFormatAnnotation: metal3v1alpha1.NetworkDataFormatNMState,
+			},
+			Inspected: true,
+			Expected:  networkData,
+		},
+		{
+			Scenario: "nmstate template",
+			Data: map[string][]byte{"nmstate": []byte(
+				"interfaces:\n- name: eth0\n  type: ethernet\n  mac-address: {{ .BootMACAddress }}\n" +
+					"  ipv4:\n    enabled: true\n    dhcp: true\n")},
+			Annotations: map[string]string{metal3v1alpha1.TemplateAnnotation: "true"},
+			Expected:    networkData,
+		},
+		{
+			Scenario: "not inspected",
+			Data:     map[string][]byte{"nmstate": []byte(nmstate)},
+			Err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newHost("host-nmstate", &metal3v1alpha1.BareMetalHostSpec{
+				BootMACAddress: "00:11:22:33:44:55",
+				NetworkData: &corev1.SecretReference{
+					Name:      "network-data",
+					Namespace: namespace,
+				},
+			})
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "network-data",
+					Namespace:   namespace,
+					Annotations: tc.Annotations,
+				},
+				Data: tc.Data,
+			}
+			objs := []runtime.Object{host, secret}
+			if tc.Inspected {
+				host.Status.HardwareData = &corev1.LocalObjectReference{Name: host.Name}
+				objs = append(objs, &metal3v1alpha1.HardwareData{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      host.Name,
+						Namespace: namespace,
+					},
+					Spec: metal3v1alpha1.HardwareDataSpec{
+						HardwareDetails: &metal3v1alpha1.HardwareDetails{
+							NIC: []metal3v1alpha1.NIC{{Name: "eth0", MAC: "00:11:22:33:44:55"}},
+						},
+					},
+				})
+			}
+
+			hcd := &hostConfigData{
+				host:   host,
+				log:    ctrl.Log.WithName("Test").WithName(tc.Scenario),
+				client: fakeclient.NewFakeClient(objs...),
+			}
+			actual, err := hcd.NetworkData()
+
+			if tc.Err {
+				assert.Error(t, err)
+				assert.IsType(t, NetworkDataConversionError{}, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestHostConfigDataIgnition(t *testing.T) {
+	hostnameFile := func(spec2 bool, source string) string {
+		if spec2 {
+			return `{"path": "/etc/hostname", "mode": 420, "filesystem": "root", "contents": {"source": "` + source + `"}}`
+		}
+		return `{"path": "/etc/hostname", "mode": 420, "overwrite": true, "contents": {"source": "` + source + `"}}`
+	}
+
+	testCases := []struct {
+		Scenario    string
+		UserData    map[string][]byte
+		MetaData    string
+		NetworkData map[string][]byte
+		Expected    string
+		Err         bool
+	}{
+		{
+			Scenario: "cloud-config",
+			UserData: map[string][]byte{"userData": []byte("#cloud-config\n")},
+			Expected: "#cloud-config\n",
+		},
+		{
+			Scenario: "ignition content",
+			UserData: map[string][]byte{"userData": []byte(`{"ignition": {"version": "3.2.0"}}`)},
+			Expected: `{"ignition": {"version": "3.2.0"}, "storage": {"files": [` +
+				hostnameFile(false, "data:;base64,aG9zdC1pZ25pdGlvbgo=") + `]}}`,
+		},
+		{
+			Scenario:    "ignition key with metadata and nmstate",
+			UserData:    map[string][]byte{"ignition": []byte(`{"ignition": {"version": "2.2.0"}}`)},
+			MetaData:    "local-hostname: worker-0\n",
+			NetworkData: map[string][]byte{"nmstate": []byte("interfaces: []\n")},
+			Expected: `{"ignition": {"version": "2.2.0"}, "storage": {"files": [` +
+				hostnameFile(true, "data:;base64,d29ya2VyLTAK") + `,` +
+				`{"path": "/etc/nmstate/metal3.yml", "mode": 384, "filesystem": "root",` +
+				` "contents": {"source": "data:;base64,aW50ZXJmYWNlczogW10K"}}]}}`,
+		},
+		{
+			Scenario:    "network_data.json",
+			UserData:    map[string][]byte{"userData": []byte(`{"ignition": {"version": "3.0.0"}}`)},
+			NetworkData: map[string][]byte{"networkData": []byte(`{"links": []}`)},
+			Expected: `{"ignition": {"version": "3.0.0"}, "storage": {"files": [` +
+				hostnameFile(false, "data:;base64,aG9zdC1pZ25pdGlvbgo=") + `]}}`,
+		},
+		{
+			Scenario: "unsupported version",
+			UserData: map[string][]byte{"userData": []byte(`{"ignition": {"version": "2.1.0"}}`)},
+			Err:      true,
+		},
+		{
+			Scenario: "ignition key without a config",
+			UserData: map[string][]byte{"ignition": []byte("#cloud-config\n")},
+			Err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newHost("host-ignition", &metal3v1alpha1.BareMetalHostSpec{
+				UserData: &corev1.SecretReference{Name: "user-data", Namespace: namespace},
+			})
+			secret := func(name string, data map[string][]byte) *corev1.Secret {
+				return &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Data:       data,
+				}
+			}
+			objs := []runtime.Object{host, secret("user-data", tc.UserData)}
+			if tc.MetaData != "" {
+				host.Spec.MetaData = &corev1.SecretReference{Name: "meta-data", Namespace: namespace}
+				objs = append(objs, secret("meta-data", map[string][]byte{"metaData": []byte(tc.MetaData)}))
+			}
+			if tc.NetworkData != nil {
+				host.Spec.NetworkData = &corev1.SecretReference{Name: "network-data", Namespace: namespace}
+				objs = append(objs, secret("network-data", tc.NetworkData))
+			}
+
+			hcd := &hostConfigData{
+				host:   host,
+				log:    ctrl.Log.WithName("Test").WithName(tc.Scenario),
+				client: fakeclient.NewFakeClient(objs...),
+			}
+			actual, err := hcd.UserData()
+
+			if tc.Err {
+				assert.Error(t, err)
+				assert.IsType(t, IgnitionValidationError{}, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				if strings.HasPrefix(tc.Expected, "{") {
+					assert.JSONEq(t, tc.Expected, actual)
+				} else {
+					assert.Equal(t, tc.Expected, actual)
+				}
+			}
+		})
+	}
+}
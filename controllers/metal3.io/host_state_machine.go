@@ -16,6 +16,9 @@ type hostStateMachine struct {
 	NextState   metal3v1alpha1.ProvisioningState
 	Reconciler  *BareMetalHostReconciler
 	Provisioner provisioner.Provisioner
+	// StatusChanged is set when reconciling modified the conditions
+	// or the taints in the host status.
+	StatusChanged bool
 }
 
 func newHostStateMachine(host *metal3v1alpha1.BareMetalHost,
@@ -42,6 +45,8 @@ func (hsm *hostStateMachine) handlers() map[metal3v1alpha1.ProvisioningState]sta
 		metal3v1alpha1.StateInspecting:            hsm.handleInspecting,
 		metal3v1alpha1.StateExternallyProvisioned: hsm.handleExternallyProvisioned,
 		metal3v1alpha1.StateMatchProfile:          hsm.handleMatchProfile,
+		metal3v1alpha1.StateUpdatingFirmware:      hsm.handleUpdatingFirmware,
+		metal3v1alpha1.StatePreparing:             hsm.handlePreparing,
 		metal3v1alpha1.StateAvailable:             hsm.handleReady,
 		metal3v1alpha1.StateReady:                 hsm.handleReady,
 		metal3v1alpha1.StateProvisioning:          hsm.handleProvisioning,
@@ -106,6 +111,23 @@ func (hsm *hostStateMachine) updateHostStateFrom(initialState metal3v1alpha1.Pro
 					"new mode", hsm.Host.Status.Provisioning.BootMode)
 			}
 		}
+		if initialState == metal3v1alpha1.StatePreparing {
+			// Settings still being applied when the preparation
+			// is interrupted are applied again next time.
+			hsm.Host.Status.Provisioning.PendingRAID = nil
+			hsm.Host.Status.Provisioning.PendingFirmware = nil
+		}
+	}
+
+	// Keep the conditions in sync with the state and the other
+	// status fields, whether or not the state changed.
+	if updateHostConditions(hsm.Host) {
+		info.log.Info("updating conditions")
+		hsm.StatusChanged = true
+	}
+	if updateHostTaints(hsm.Host) {
+		info.log.Info("updating taints")
+		hsm.StatusChanged = true
 	}
 }
 
@@ -117,6 +139,9 @@ func (hsm *hostStateMachine) ReconcileState(info *reconcileInfo) actionResult {
 		info.log.Info("Initiating host deletion")
 		return actionComplete{}
 	}
+	if actResult, handled := hsm.checkDetachedHost(info); handled {
+		return actResult
+	}
 	// TODO: In future we should always re-register the host if required,
 	// rather than initiate a transistion back to the Registering state.
 	if hsm.shouldInitiateRegister(info) {
@@ -153,6 +178,12 @@ func (hsm *hostStateMachine) checkInitiateDelete() bool {
 	default:
 		hsm.NextState = metal3v1alpha1.StateDeleting
 	case metal3v1alpha1.StateProvisioning, metal3v1alpha1.StateProvisioningError, metal3v1alpha1.StateProvisioned:
+		if hsm.Host.OperationalStatus() == metal3v1alpha1.OperationalStatusDetached {
+			// A detached host is no longer known to the
+			// provisioner, so it cannot be deprovisioned.
+			hsm.NextState = metal3v1alpha1.StateDeleting
+			break
+		}
 		hsm.NextState = metal3v1alpha1.StateDeprovisioning
 	case metal3v1alpha1.StateDeprovisioning:
 		// Allow state machine to run to continue deprovisioning.
@@ -164,6 +195,35 @@ func (hsm *hostStateMachine) checkInitiateDelete() bool {
 	return true
 }
 
+// checkDetachedHost removes the host from the provisioner while the
+// detached annotation is set, once nothing is in progress, and
+// registers the host again once the annotation is removed. It returns
+// true when it handled the host.
+func (hsm *hostStateMachine) checkDetachedHost(info *reconcileInfo) (actResult actionResult, handled bool) {
+	if !hsm.Host.HasDetachedAnnotation() {
+		if hsm.Host.OperationalStatus() != metal3v1alpha1.OperationalStatusDetached {
+			return nil, false
+		}
+		// Registering the host again creates it in the provisioner,
+		// which is then told whether it was provisioned.
+		info.log.Info("Initiating host reattachment")
+		info.publishEvent("Reattached", "Host is managed by the provisioner again")
+		hsm.Host.SetOperationalStatus(metal3v1alpha1.OperationalStatusOK)
+		hsm.NextState = metal3v1alpha1.StateRegistering
+		return actionComplete{}, true
+	}
+
+	switch hsm.NextState {
+	case metal3v1alpha1.StateReady,
+		metal3v1alpha1.StateAvailable,
+		metal3v1alpha1.StateProvisioned,
+		metal3v1alpha1.StateExternallyProvisioned:
+		return hsm.Reconciler.actionDetaching(hsm.Provisioner, info), true
+	}
+	// Let the operation in progress finish first.
+	return nil, false
+}
+
 func (hsm *hostStateMachine) shouldInitiateRegister(info *reconcileInfo) bool {
 	changeState := false
 	if hsm.Host.DeletionTimestamp.IsZero() {
@@ -248,6 +308,35 @@ func (hsm *hostStateMachine) handleInspecting(info *reconcileInfo) actionResult
 
 func (hsm *hostStateMachine) handleMatchProfile(info *reconcileInfo) actionResult {
 	actResult := hsm.Reconciler.actionMatchProfile(hsm.Provisioner, info)
+	if _, complete := actResult.(actionComplete); complete {
+		switch {
+		case hsm.Host.FirmwareUpdatesChanged():
+			hsm.NextState = metal3v1alpha1.StateUpdatingFirmware
+		case hsm.Host.NeedsPreparation():
+			hsm.NextState = metal3v1alpha1.StatePreparing
+		default:
+			hsm.NextState = metal3v1alpha1.StateReady
+		}
+	}
+	return actResult
+}
+
+func (hsm *hostStateMachine) handleUpdatingFirmware(info *reconcileInfo) actionResult {
+	actResult := hsm.Reconciler.actionUpdatingFirmware(hsm.Provisioner, info)
+	if _, complete := actResult.(actionComplete); complete {
+		// New firmware may reset the BIOS settings, so apply them
+		// afterwards.
+		if hsm.Host.NeedsPreparation() {
+			hsm.NextState = metal3v1alpha1.StatePreparing
+		} else {
+			hsm.NextState = metal3v1alpha1.StateReady
+		}
+	}
+	return actResult
+}
+
+func (hsm *hostStateMachine) handlePreparing(info *reconcileInfo) actionResult {
+	actResult := hsm.Reconciler.actionPreparing(hsm.Provisioner, info)
 	if _, complete := actResult.(actionComplete); complete {
 		hsm.NextState = metal3v1alpha1.StateReady
 	}
@@ -285,6 +374,24 @@ func (hsm *hostStateMachine) handleReady(info *reconcileInfo) actionResult {
 		return actionComplete{}
 	}
 
+	if hsm.Host.HasInspectAnnotation() {
+		info.log.Info("Initiating hardware inspection")
+		hsm.NextState = metal3v1alpha1.StateInspecting
+		return actionComplete{}
+	}
+
+	// Firmware and settings such as the RAID configuration and the
+	// BIOS settings can only be changed while nothing is provisioned,
+	// so apply them before anything else.
+	if hsm.Host.FirmwareUpdatesChanged() {
+		hsm.NextState = metal3v1alpha1.StateUpdatingFirmware
+		return actionComplete{}
+	}
+	if hsm.Host.NeedsPreparation() {
+		hsm.NextState = metal3v1alpha1.StatePreparing
+		return actionComplete{}
+	}
+
 	actResult := hsm.Reconciler.actionManageReady(hsm.Provisioner, info)
 
 	switch r := actResult.(type) {
@@ -317,11 +424,31 @@ func (hsm *hostStateMachine) provisioningCancelled() bool {
 	return false
 }
 
+// checkEvicted moves a host with a NoExecute taint that its consumer
+// does not tolerate to deprovisioning. It returns true when it handled
+// the host.
+func (hsm *hostStateMachine) checkEvicted(info *reconcileInfo) (actResult actionResult, handled bool) {
+	evicted, err := hsm.Reconciler.hostEvicted(info)
+	if err != nil {
+		return actionError{err}, true
+	}
+	if !evicted {
+		return nil, false
+	}
+	info.log.Info("Initiating deprovisioning of tainted host")
+	info.publishEvent("Evicted", "Host has a NoExecute taint its consumer does not tolerate")
+	hsm.NextState = metal3v1alpha1.StateDeprovisioning
+	return actionComplete{}, true
+}
+
 func (hsm *hostStateMachine) handleProvisioning(info *reconcileInfo) actionResult {
 	if hsm.provisioningCancelled() {
 		hsm.NextState = metal3v1alpha1.StateDeprovisioning
 		return actionComplete{}
 	}
+	if actResult, handled := hsm.checkEvicted(info); handled {
+		return actResult
+	}
 
 	actResult := hsm.Reconciler.actionProvisioning(hsm.Provisioner, info)
 	switch actResult.(type) {
@@ -348,6 +475,9 @@ func (hsm *hostStateMachine) handleProvisioned(info *reconcileInfo) actionResult
 		hsm.NextState = metal3v1alpha1.StateDeprovisioning
 		return actionComplete{}
 	}
+	if actResult, handled := hsm.checkEvicted(info); handled {
+		return actResult
+	}
 
 	actResult := hsm.Reconciler.actionManageSteadyState(hsm.Provisioner, info)
 	if r, f := actResult.(actionFailed); f {
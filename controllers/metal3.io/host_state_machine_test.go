@@ -193,6 +193,14 @@ func TestErrorCountIncreasedWhenProvisionerFails(t *testing.T) {
 			Scenario: "ready",
 			Host:     host(metal3v1alpha1.StateReady).build(),
 		},
+		{
+			Scenario: "preparing",
+			Host:     host(metal3v1alpha1.StatePreparing).SetRAID().build(),
+		},
+		{
+			Scenario: "updating firmware",
+			Host:     host(metal3v1alpha1.StateUpdatingFirmware).SetFirmwareUpdates().build(),
+		},
 		{
 			Scenario: "deprovisioning",
 			Host:     host(metal3v1alpha1.StateDeprovisioning).build(),
@@ -239,6 +247,14 @@ func TestErrorCountCleared(t *testing.T) {
 			Scenario: "ready",
 			Host:     host(metal3v1alpha1.StateReady).build(),
 		},
+		{
+			Scenario: "preparing",
+			Host:     host(metal3v1alpha1.StatePreparing).SetRAID().build(),
+		},
+		{
+			Scenario: "updating firmware",
+			Host:     host(metal3v1alpha1.StateUpdatingFirmware).SetFirmwareUpdates().build(),
+		},
 		{
 			Scenario: "deprovisioning",
 			Host:     host(metal3v1alpha1.StateDeprovisioning).build(),
@@ -268,6 +284,282 @@ func TestErrorCountCleared(t *testing.T) {
 	}
 }
 
+func TestPreparingTransitions(t *testing.T) {
+	tests := []struct {
+		Scenario      string
+		Host          *metal3v1alpha1.BareMetalHost
+		Error         string
+		ExpectedState metal3v1alpha1.ProvisioningState
+	}{
+		{
+			Scenario:      "ready without RAID",
+			Host:          host(metal3v1alpha1.StateReady).build(),
+			ExpectedState: metal3v1alpha1.StateReady,
+		},
+		{
+			Scenario:      "ready with new RAID",
+			Host:          host(metal3v1alpha1.StateReady).SetRAID().build(),
+			ExpectedState: metal3v1alpha1.StatePreparing,
+		},
+		{
+			Scenario:      "preparing complete",
+			Host:          host(metal3v1alpha1.StatePreparing).SetRAID().SetPendingRAID().build(),
+			ExpectedState: metal3v1alpha1.StateReady,
+		},
+		{
+			Scenario:      "preparing failed",
+			Host:          host(metal3v1alpha1.StatePreparing).SetRAID().SetPendingRAID().build(),
+			Error:         "cleaning failed",
+			ExpectedState: metal3v1alpha1.StatePreparing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Scenario, func(t *testing.T) {
+			prov := &mockProvisioner{}
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov)
+			info := makeDefaultReconcileInfo(tt.Host)
+
+			preparing := tt.Host.Status.Provisioning.State == metal3v1alpha1.StatePreparing
+			if tt.Error != "" {
+				prov.setNextError(tt.Error)
+			}
+			hsm.ReconcileState(info)
+
+			assert.Equal(t, tt.ExpectedState, tt.Host.Status.Provisioning.State)
+			assert.Nil(t, tt.Host.Status.Provisioning.PendingRAID)
+			switch {
+			case tt.Error != "":
+				assert.Equal(t, metal3v1alpha1.PreparationError, tt.Host.Status.ErrorType)
+				assert.Nil(t, tt.Host.Status.Provisioning.RAID)
+			case preparing:
+				assert.Equal(t, tt.Host.Spec.RAID, tt.Host.Status.Provisioning.RAID)
+			}
+		})
+	}
+}
+
+func TestUpdatingFirmwareTransitions(t *testing.T) {
+	tests := []struct {
+		Scenario      string
+		Host          *metal3v1alpha1.BareMetalHost
+		Error         string
+		ExpectedState metal3v1alpha1.ProvisioningState
+	}{
+		{
+			Scenario:      "ready with new firmware",
+			Host:          host(metal3v1alpha1.StateReady).SetFirmwareUpdates().build(),
+			ExpectedState: metal3v1alpha1.StateUpdatingFirmware,
+		},
+		{
+			Scenario:      "ready with new firmware and RAID",
+			Host:          host(metal3v1alpha1.StateReady).SetFirmwareUpdates().SetRAID().build(),
+			ExpectedState: metal3v1alpha1.StateUpdatingFirmware,
+		},
+		{
+			Scenario:      "match profile with new firmware",
+			Host:          host(metal3v1alpha1.StateMatchProfile).SetFirmwareUpdates().build(),
+			ExpectedState: metal3v1alpha1.StateUpdatingFirmware,
+		},
+		{
+			Scenario:      "updating firmware complete",
+			Host:          host(metal3v1alpha1.StateUpdatingFirmware).SetFirmwareUpdates().build(),
+			ExpectedState: metal3v1alpha1.StateReady,
+		},
+		{
+			Scenario:      "updating firmware complete with new RAID",
+			Host:          host(metal3v1alpha1.StateUpdatingFirmware).SetFirmwareUpdates().SetRAID().build(),
+			ExpectedState: metal3v1alpha1.StatePreparing,
+		},
+		{
+			Scenario:      "updating firmware failed",
+			Host:          host(metal3v1alpha1.StateUpdatingFirmware).SetFirmwareUpdates().build(),
+			Error:         "cleaning failed",
+			ExpectedState: metal3v1alpha1.StateUpdatingFirmware,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Scenario, func(t *testing.T) {
+			prov := &mockProvisioner{}
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov)
+			info := makeDefaultReconcileInfo(tt.Host)
+
+			if tt.Error != "" {
+				tt.Host.Status.Provisioning.FirmwareUpdates = tt.Host.Spec.FirmwareUpdates
+				prov.setNextError(tt.Error)
+			}
+			hsm.ReconcileState(info)
+
+			assert.Equal(t, tt.ExpectedState, tt.Host.Status.Provisioning.State)
+			if tt.Error != "" {
+				assert.Equal(t, metal3v1alpha1.FirmwareUpdateError, tt.Host.Status.ErrorType)
+				assert.Nil(t, tt.Host.Status.Provisioning.FirmwareUpdates)
+			}
+		})
+	}
+}
+
+func TestDetachedHost(t *testing.T) {
+	tests := []struct {
+		Scenario          string
+		Host              *metal3v1alpha1.BareMetalHost
+		Dirty             bool
+		ExpectedState     metal3v1alpha1.ProvisioningState
+		ExpectedStatus    metal3v1alpha1.OperationalStatus
+		ExpectedNoWrite   bool
+		ExpectedContinued bool
+	}{
+		{
+			Scenario: "provisioned detach",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetDetachedAnnotation().build(),
+			ExpectedState:  metal3v1alpha1.StateProvisioned,
+			ExpectedStatus: metal3v1alpha1.OperationalStatusDetached,
+		},
+		{
+			Scenario: "ready detach in progress",
+			Host: host(metal3v1alpha1.StateReady).
+				SetOperationalStatus(metal3v1alpha1.OperationalStatusOK).
+				SetDetachedAnnotation().build(),
+			Dirty:             true,
+			ExpectedState:     metal3v1alpha1.StateReady,
+			ExpectedStatus:    metal3v1alpha1.OperationalStatusOK,
+			ExpectedContinued: true,
+		},
+		{
+			Scenario: "externally provisioned detach",
+			Host: host(metal3v1alpha1.StateExternallyProvisioned).SetExternallyProvisioned().
+				SetDetachedAnnotation().build(),
+			ExpectedState:  metal3v1alpha1.StateExternallyProvisioned,
+			ExpectedStatus: metal3v1alpha1.OperationalStatusDetached,
+		},
+		{
+			Scenario: "already detached",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetDetachedAnnotation().
+				SetOperationalStatus(metal3v1alpha1.OperationalStatusDetached).build(),
+			ExpectedState:   metal3v1alpha1.StateProvisioned,
+			ExpectedStatus:  metal3v1alpha1.OperationalStatusDetached,
+			ExpectedNoWrite: true,
+		},
+		{
+			Scenario: "preparing waits",
+			Host: host(metal3v1alpha1.StatePreparing).SetRAID().SetPendingRAID().
+				SetDetachedAnnotation().build(),
+			ExpectedState:  metal3v1alpha1.StateReady,
+			ExpectedStatus: metal3v1alpha1.OperationalStatusOK,
+		},
+		{
+			Scenario: "reattach",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetOperationalStatus(metal3v1alpha1.OperationalStatusDetached).build(),
+			ExpectedState:  metal3v1alpha1.StateRegistering,
+			ExpectedStatus: metal3v1alpha1.OperationalStatusOK,
+		},
+		{
+			Scenario: "deleted while detached",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetDetachedAnnotation().SetDeleted().
+				SetOperationalStatus(metal3v1alpha1.OperationalStatusDetached).build(),
+			ExpectedState:  metal3v1alpha1.StateDeleting,
+			ExpectedStatus: metal3v1alpha1.OperationalStatusDetached,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Scenario, func(t *testing.T) {
+			prov := &mockProvisioner{}
+			prov.setNextResult(tt.Dirty)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov)
+			info := makeDefaultReconcileInfo(tt.Host)
+
+			actResult := hsm.ReconcileState(info)
+
+			assert.Equal(t, tt.ExpectedState, tt.Host.Status.Provisioning.State)
+			assert.Equal(t, tt.ExpectedStatus, tt.Host.Status.OperationalStatus)
+			_, noWrite := actResult.(actionContinueNoWrite)
+			assert.Equal(t, tt.ExpectedNoWrite, noWrite)
+			_, continued := actResult.(actionContinue)
+			assert.Equal(t, tt.ExpectedContinued, continued)
+		})
+	}
+}
+
+func TestEvictedHost(t *testing.T) {
+	tolerating := &metal3v1alpha1.HostClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "tolerating", Namespace: "myns", UID: "1234"},
+		Spec: metal3v1alpha1.HostClaimSpec{
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists},
+			},
+		},
+	}
+	machine := &corev1.ObjectReference{Name: "mymachine", Namespace: "myns"}
+
+	tests := []struct {
+		Scenario      string
+		Host          *metal3v1alpha1.BareMetalHost
+		ExpectedState metal3v1alpha1.ProvisioningState
+		ExpectedEvent string
+	}{
+		{
+			Scenario: "provisioned NoExecute",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetConsumerRef(machine).SetTaint(corev1.TaintEffectNoExecute).build(),
+			ExpectedState: metal3v1alpha1.StateDeprovisioning,
+			ExpectedEvent: "Evicted",
+		},
+		{
+			Scenario: "provisioning NoExecute",
+			Host: host(metal3v1alpha1.StateProvisioning).SetImageURL("same").
+				SetConsumerRef(machine).SetTaint(corev1.TaintEffectNoExecute).build(),
+			ExpectedState: metal3v1alpha1.StateDeprovisioning,
+			ExpectedEvent: "Evicted",
+		},
+		{
+			Scenario: "provisioned NoSchedule",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetConsumerRef(machine).SetTaint(corev1.TaintEffectNoSchedule).build(),
+			ExpectedState: metal3v1alpha1.StateProvisioned,
+		},
+		{
+			Scenario: "provisioned NoExecute not consumed",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetTaint(corev1.TaintEffectNoExecute).build(),
+			ExpectedState: metal3v1alpha1.StateProvisioned,
+		},
+		{
+			Scenario: "provisioned NoExecute tolerated",
+			Host: host(metal3v1alpha1.StateProvisioned).SetImageURL("same").
+				SetConsumerRef(tolerating.ConsumerRef()).SetTaint(corev1.TaintEffectNoExecute).build(),
+			ExpectedState: metal3v1alpha1.StateProvisioned,
+		},
+		{
+			Scenario: "ready NoExecute",
+			Host: host(metal3v1alpha1.StateReady).SetImageURL("same").
+				SetConsumerRef(machine).SetTaint(corev1.TaintEffectNoExecute).build(),
+			ExpectedState: metal3v1alpha1.StateReady,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Scenario, func(t *testing.T) {
+			tt.Host.Spec.Online = true
+			tt.Host.Status.Provisioning.Image = *tt.Host.Spec.Image
+			if tt.Host.Status.Provisioning.State == metal3v1alpha1.StateReady {
+				tt.Host.Status.Provisioning.Image = metal3v1alpha1.Image{}
+			}
+			prov := &mockProvisioner{}
+			hsm := newHostStateMachine(tt.Host, newTestReconciler(tolerating), prov)
+			info := makeDefaultReconcileInfo(tt.Host)
+
+			hsm.ReconcileState(info)
+
+			assert.Equal(t, tt.ExpectedState, tt.Host.Status.Provisioning.State)
+			if tt.ExpectedEvent != "" && assert.Len(t, info.events, 1) {
+				assert.Equal(t, tt.ExpectedEvent, info.events[0].Reason)
+			}
+		})
+	}
+}
+
 type hostBuilder struct {
 	metal3v1alpha1.BareMetalHost
 }
@@ -313,6 +605,86 @@ func (hb *hostBuilder) SetImageURL(url string) *hostBuilder {
 	return hb
 }
 
+func (hb *hostBuilder) SetHardwareDetails() *hostBuilder {
+	hb.Status.HardwareSummary = &metal3v1alpha1.HardwareSummary{}
+	return hb
+}
+
+func (hb *hostBuilder) SetRAID() *hostBuilder {
+	hb.Spec.RAID = &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+			{Level: "1"},
+		},
+	}
+	return hb
+}
+
+// SetPendingRAID records the RAID configuration of the spec as being
+// applied.
+func (hb *hostBuilder) SetPendingRAID() *hostBuilder {
+	hb.Status.Provisioning.PendingRAID = hb.Spec.RAID.DeepCopy()
+	return hb
+}
+
+func (hb *hostBuilder) SetFirmwareUpdates() *hostBuilder {
+	hb.Spec.FirmwareUpdates = []metal3v1alpha1.FirmwareUpdate{
+		{
+			Component: metal3v1alpha1.BIOSFirmwareComponent,
+			URL:       "http://example.com/bios.bin",
+			Checksum:  "abcd",
+		},
+	}
+	return hb
+}
+
+func (hb *hostBuilder) SetDetachedAnnotation() *hostBuilder {
+	hb.Annotations = map[string]string{
+		metal3v1alpha1.DetachedAnnotation: "",
+	}
+	return hb
+}
+
+func (hb *hostBuilder) SetInspectionDisabled() *hostBuilder {
+	hb.Annotations = map[string]string{
+		metal3v1alpha1.InspectAnnotation: metal3v1alpha1.InspectAnnotationDisabled,
+	}
+	return hb
+}
+
+func (hb *hostBuilder) SetTaint(effect corev1.TaintEffect) *hostBuilder {
+	hb.Spec.Taints = append(hb.Spec.Taints, corev1.Taint{
+		Key:    "maintenance",
+		Effect: effect,
+	})
+	return hb
+}
+
+func (hb *hostBuilder) SetConsumerRef(ref *corev1.ObjectReference) *hostBuilder {
+	hb.Spec.ConsumerRef = ref
+	return hb
+}
+
+func (hb *hostBuilder) SetDeleted() *hostBuilder {
+	now := metav1.Now()
+	hb.DeletionTimestamp = &now
+	return hb
+}
+
+func (hb *hostBuilder) SetOperationalStatus(status metal3v1alpha1.OperationalStatus) *hostBuilder {
+	hb.Status.OperationalStatus = status
+	return hb
+}
+
+func (hb *hostBuilder) SetPoweredOn() *hostBuilder {
+	hb.Status.PoweredOn = true
+	return hb
+}
+
+func (hb *hostBuilder) SetError(errType metal3v1alpha1.ErrorType) *hostBuilder {
+	hb.SetErrorMessage(errType, "some error")
+	return hb
+}
+
 func makeDefaultReconcileInfo(host *metal3v1alpha1.BareMetalHost) *reconcileInfo {
 	return &reconcileInfo{
 		log:     logf.Log.WithName("test"),
@@ -349,14 +721,22 @@ func (m *mockProvisioner) ValidateManagementAccess(credentialsChanged bool) (res
 	return m.nextResult, err
 }
 
-func (m *mockProvisioner) InspectHardware() (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
-	return m.nextResult, details, err
+func (m *mockProvisioner) InspectHardware(refresh bool) (result provisioner.Result, started bool, hardwareData *metal3v1alpha1.HardwareDataSpec, err error) {
+	return m.nextResult, started, hardwareData, err
 }
 
 func (m *mockProvisioner) UpdateHardwareState() (result provisioner.Result, err error) {
 	return m.nextResult, err
 }
 
+func (m *mockProvisioner) UpdateFirmware(outdated bool) (result provisioner.Result, started bool, err error) {
+	return m.nextResult, started, err
+}
+
+func (m *mockProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
+	return m.nextResult, started, err
+}
+
 func (m *mockProvisioner) Adopt() (result provisioner.Result, err error) {
 	return m.nextResult, err
 }
@@ -373,6 +753,10 @@ func (m *mockProvisioner) Delete() (result provisioner.Result, err error) {
 	return m.nextResult, err
 }
 
+func (m *mockProvisioner) Detach() (result provisioner.Result, err error) {
+	return m.nextResult, err
+}
+
 func (m *mockProvisioner) PowerOn() (result provisioner.Result, err error) {
 	return m.nextResult, err
 }
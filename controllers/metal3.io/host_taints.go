@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// updateHostTaints copies the taints of the spec that are in effect
+// into the status of the host. It returns true when the status
+// changed.
+func updateHostTaints(host *metal3v1alpha1.BareMetalHost) bool {
+	taints := taintsInEffect(host)
+	if equality.Semantic.DeepEqual(taints, host.Status.Taints) {
+		return false
+	}
+	host.Status.Taints = taints
+	return true
+}
+
+// taintsInEffect returns the taints of the spec that are in effect,
+// those with the NoSchedule and NoExecute effects. NoExecute taints
+// have the time they were first seen.
+func taintsInEffect(host *metal3v1alpha1.BareMetalHost) []corev1.Taint {
+	var taints []corev1.Taint
+	for _, taint := range host.Spec.Taints {
+		switch taint.Effect {
+		case corev1.TaintEffectNoSchedule:
+			taint.TimeAdded = nil
+		case corev1.TaintEffectNoExecute:
+			taint.TimeAdded = taintTimeAdded(host.Status.Taints, &taint)
+		default:
+			continue
+		}
+		taints = append(taints, taint)
+	}
+	return taints
+}
+
+// taintTimeAdded returns the time a taint was added according to the
+// current status, or now if it is new.
+func taintTimeAdded(current []corev1.Taint, taint *corev1.Taint) *metav1.Time {
+	for i := range current {
+		if current[i].MatchTaint(taint) && current[i].Value == taint.Value &&
+			current[i].TimeAdded != nil {
+			return current[i].TimeAdded
+		}
+	}
+	if taint.TimeAdded != nil {
+		return taint.TimeAdded
+	}
+	now := metav1.Now()
+	return &now
+}
+
+// consumerTolerations returns the tolerations of the consumer of the
+// host. Only HostClaim consumers can have tolerations.
+func (r *BareMetalHostReconciler) consumerTolerations(host *metal3v1alpha1.BareMetalHost) ([]corev1.Toleration, error) {
+	ref := host.Spec.ConsumerRef
+	if ref == nil || ref.Kind != "HostClaim" ||
+		ref.APIVersion != metal3v1alpha1.GroupVersion.String() {
+		return nil, nil
+	}
+
+	claim := &metal3v1alpha1.HostClaim{}
+	key := client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}
+	if err := r.Get(context.TODO(), key, claim); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to load host claim")
+	}
+	if !claim.Consumes(host) {
+		return nil, nil
+	}
+	return claim.Spec.Tolerations, nil
+}
+
+// hostEvicted returns true when the host is consumed and has a
+// NoExecute taint that its consumer does not tolerate, or no longer
+// tolerates, so that it must be deprovisioned and not provisioned
+// again. When the consumer only tolerates the taints for a while, the
+// time left is recorded in info so that the host is checked again once
+// it has passed.
+func (r *BareMetalHostReconciler) hostEvicted(info *reconcileInfo) (bool, error) {
+	host := info.host
+	if host.Spec.ConsumerRef == nil ||
+		host.ToleratesTaints(nil, corev1.TaintEffectNoExecute) {
+		return false, nil
+	}
+	tolerations, err := r.consumerTolerations(host)
+	if err != nil {
+		return false, err
+	}
+	evicted, expiresIn := taintsEvict(taintsInEffect(host), tolerations, time.Now())
+	info.evictionRecheckAfter = expiresIn
+	return evicted, nil
+}
+
+// taintsEvict returns true when one of the NoExecute taints in effect
+// on a host is not tolerated, or has been in place for longer
+// than it is tolerated. As for pods, the shortest tolerationSeconds of
+// the tolerations matching a taint applies, and a matching toleration
+// without tolerationSeconds tolerates it forever. Otherwise it returns
+// the time left until the first toleration expires, or 0 when they
+// never do.
+func taintsEvict(taints []corev1.Taint, tolerations []corev1.Toleration, now time.Time) (evict bool, expiresIn time.Duration) {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+
+		tolerated, forever := false, false
+		var seconds int64
+		for j := range tolerations {
+			toleration := &tolerations[j]
+			if !toleration.ToleratesTaint(taint) {
+				continue
+			}
+			if toleration.TolerationSeconds == nil {
+				forever = true
+				break
+			}
+			if !tolerated || *toleration.TolerationSeconds < seconds {
+				seconds = *toleration.TolerationSeconds
+			}
+			tolerated = true
+		}
+		if forever {
+			continue
+		}
+		if !tolerated {
+			return true, 0
+		}
+
+		added := now
+		if taint.TimeAdded != nil {
+			added = taint.TimeAdded.Time
+		}
+		left := added.Add(time.Duration(seconds) * time.Second).Sub(now)
+		if left <= 0 {
+			return true, 0
+		}
+		if expiresIn == 0 || left < expiresIn {
+			expiresIn = left
+		}
+	}
+	return false, expiresIn
+}
+
+// requeueForEviction returns the result of a reconcile changed so that
+// the host is reconciled again when the toleration of one of its
+// NoExecute taints expires, unless it already is by then.
+func requeueForEviction(result ctrl.Result, after time.Duration) ctrl.Result {
+	if after <= 0 || (result.Requeue && result.RequeueAfter == 0) {
+		return result
+	}
+	if result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
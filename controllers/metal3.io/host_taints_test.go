@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestUpdateHostTaints(t *testing.T) {
+	added := metav1.NewTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	tests := []struct {
+		Scenario        string
+		Taints          []corev1.Taint
+		StatusTaints    []corev1.Taint
+		ExpectedTaints  []corev1.Taint
+		ExpectedChanged bool
+	}{
+		{
+			Scenario: "none",
+		},
+		{
+			Scenario: "NoSchedule",
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			},
+			ExpectedTaints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			},
+			ExpectedChanged: true,
+		},
+		{
+			Scenario: "PreferNoSchedule is not enforced",
+			Taints: []corev1.Taint{
+				{Key: "slow", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		},
+		{
+			Scenario: "NoExecute keeps its time",
+			Taints: []corev1.Taint{
+				{Key: "maintenance", Effect: corev1.TaintEffectNoExecute},
+			},
+			StatusTaints: []corev1.Taint{
+				{Key: "maintenance", Effect: corev1.TaintEffectNoExecute, TimeAdded: &added},
+			},
+			ExpectedTaints: []corev1.Taint{
+				{Key: "maintenance", Effect: corev1.TaintEffectNoExecute, TimeAdded: &added},
+			},
+		},
+		{
+			Scenario: "removed",
+			StatusTaints: []corev1.Taint{
+				{Key: "maintenance", Effect: corev1.TaintEffectNoExecute, TimeAdded: &added},
+			},
+			ExpectedChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateReady).build()
+			host.Spec.Taints = tt.Taints
+			host.Status.Taints = tt.StatusTaints
+
+			changed := updateHostTaints(host)
+
+			assert.Equal(t, tt.ExpectedChanged, changed)
+			assert.Equal(t, len(tt.ExpectedTaints), len(host.Status.Taints))
+			if len(tt.ExpectedTaints) != 0 {
+				assert.Equal(t, tt.ExpectedTaints, host.Status.Taints)
+			}
+		})
+	}
+}
+
+func TestUpdateHostTaintsNoExecuteTime(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).SetTaint(corev1.TaintEffectNoExecute).build()
+
+	assert.True(t, updateHostTaints(host))
+	if assert.Len(t, host.Status.Taints, 1) {
+		assert.NotNil(t, host.Status.Taints[0].TimeAdded)
+	}
+	assert.False(t, updateHostTaints(host))
+}
+
+func TestTaintsEvict(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	added := metav1.NewTime(now.Add(-time.Minute))
+	taints := []corev1.Taint{
+		{Key: "maintenance", Effect: corev1.TaintEffectNoExecute, TimeAdded: &added},
+	}
+	seconds := func(s int64) *int64 { return &s }
+
+	testCases := []struct {
+		Scenario    string
+		Taints      []corev1.Taint
+		Tolerations []corev1.Toleration
+		Expected    bool
+		ExpiresIn   time.Duration
+	}{
+		{
+			Scenario: "not tolerated",
+			Taints:   taints,
+			Expected: true,
+		},
+		{
+			Scenario: "tolerated forever",
+			Taints:   taints,
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists},
+			},
+		},
+		{
+			Scenario: "tolerated for longer",
+			Taints:   taints,
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(120)},
+			},
+			ExpiresIn: time.Minute,
+		},
+		{
+			Scenario: "first toleration to expire",
+			Taints: append([]corev1.Taint{
+				{Key: "upgrade", Effect: corev1.TaintEffectNoExecute, TimeAdded: &added},
+			}, taints...),
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(120)},
+				{Key: "upgrade", Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(90)},
+			},
+			ExpiresIn: 30 * time.Second,
+		},
+		{
+			Scenario: "toleration expired",
+			Taints:   taints,
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(60)},
+			},
+			Expected: true,
+		},
+		{
+			Scenario: "shortest toleration applies",
+			Taints:   taints,
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(120)},
+				{Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(30)},
+			},
+			Expected: true,
+		},
+		{
+			Scenario: "toleration without seconds wins",
+			Taints:   taints,
+			Tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists, TolerationSeconds: seconds(30)},
+				{Operator: corev1.TolerationOpExists},
+			},
+		},
+		{
+			Scenario: "NoSchedule",
+			Taints: []corev1.Taint{
+				{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			evict, expiresIn := taintsEvict(tc.Taints, tc.Tolerations, now)
+			assert.Equal(t, tc.Expected, evict)
+			assert.Equal(t, tc.ExpiresIn, expiresIn)
+		})
+	}
+}
+
+func TestRequeueForEviction(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Result   ctrl.Result
+		After    time.Duration
+		Expected ctrl.Result
+	}{
+		{
+			Scenario: "no toleration expiring",
+			Result:   ctrl.Result{},
+			Expected: ctrl.Result{},
+		},
+		{
+			Scenario: "not requeued",
+			Result:   ctrl.Result{},
+			After:    time.Minute,
+			Expected: ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			Scenario: "requeued later",
+			Result:   ctrl.Result{Requeue: true, RequeueAfter: time.Hour},
+			After:    time.Minute,
+			Expected: ctrl.Result{Requeue: true, RequeueAfter: time.Minute},
+		},
+		{
+			Scenario: "requeued earlier",
+			Result:   ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second},
+			After:    time.Minute,
+			Expected: ctrl.Result{Requeue: true, RequeueAfter: 10 * time.Second},
+		},
+		{
+			Scenario: "requeued immediately",
+			Result:   ctrl.Result{Requeue: true},
+			After:    time.Minute,
+			Expected: ctrl.Result{Requeue: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, requeueForEviction(tc.Result, tc.After))
+		})
+	}
+}
@@ -0,0 +1,233 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/utils"
+)
+
+// HostClaimReconciler reconciles a HostClaim object
+type HostClaimReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=metal3.io,resources=hostclaims,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=metal3.io,resources=hostclaims/status,verbs=get;update;patch
+
+// Reconcile binds a claim to an available host that matches it, and
+// releases the host when the claim is deleted.
+func (r *HostClaimReconciler) Reconcile(request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("hostclaim", request.NamespacedName)
+
+	claim := &metal3v1alpha1.HostClaim{}
+	err := r.Get(context.TODO(), request.NamespacedName, claim)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load host claim")
+	}
+
+	if !claim.DeletionTimestamp.IsZero() {
+		return r.releaseHost(reqLogger, claim)
+	}
+
+	if !utils.StringInList(claim.Finalizers, metal3v1alpha1.HostClaimFinalizer) {
+		reqLogger.Info("adding finalizer")
+		claim.Finalizers = append(claim.Finalizers, metal3v1alpha1.HostClaimFinalizer)
+		if err := r.Update(context.TODO(), claim); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to add finalizer")
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(context.TODO(), hosts, client.InNamespace(claim.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "could not list hosts")
+	}
+
+	// A host may already have been bound by a previous pass that
+	// failed to record it in the status of the claim.
+	host := findConsumedHost(claim, hosts.Items)
+	if host == nil {
+		host, err = r.bindHost(reqLogger, claim, hosts.Items)
+		if err != nil {
+			if k8serrors.IsConflict(err) {
+				reqLogger.Info("host changed while binding, retrying")
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+	}
+
+	var bound *corev1.LocalObjectReference
+	if host != nil {
+		bound = &corev1.LocalObjectReference{Name: host.Name}
+	} else {
+		reqLogger.Info("no available host matches the claim")
+	}
+	if (bound == nil) != (claim.Status.Host == nil) ||
+		(bound != nil && *bound != *claim.Status.Host) {
+		claim.Status.Host = bound
+		if err := r.Status().Update(context.TODO(), claim); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to update host claim status")
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// findConsumedHost returns the host bound to the claim, if there is
+// one.
+func findConsumedHost(claim *metal3v1alpha1.HostClaim, hosts []metal3v1alpha1.BareMetalHost) *metal3v1alpha1.BareMetalHost {
+	for i := range hosts {
+		if claim.Consumes(&hosts[i]) {
+			return &hosts[i]
+		}
+	}
+	return nil
+}
+
+// bindHost sets the ConsumerRef of the first available host, by name,
+// that matches the claim. The update fails with a conflict if the host
+// was changed since it was listed, so that two claims can never bind
+// the same host. It returns nil when no host matches.
+func (r *HostClaimReconciler) bindHost(log logr.Logger, claim *metal3v1alpha1.HostClaim, hosts []metal3v1alpha1.BareMetalHost) (*metal3v1alpha1.BareMetalHost, error) {
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Name < hosts[j].Name
+	})
+
+	for i := range hosts {
+		host := &hosts[i]
+		if !host.AvailableFor(claim.Spec.Tolerations) {
+			continue
+		}
+		details, err := getHardwareDetails(r.Client, host)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := claim.Matches(host, details)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid host selector")
+		}
+		if !matches {
+			continue
+		}
+
+		log.Info("binding host", "host", host.Name)
+		host.Spec.ConsumerRef = claim.ConsumerRef()
+		if err := r.Update(context.TODO(), host); err != nil {
+			return nil, errors.Wrap(err, "failed to bind host")
+		}
+		return host, nil
+	}
+	return nil, nil
+}
+
+// releaseHost clears the ConsumerRef of the host bound to a deleted
+// claim, along with the image and configuration data the consumer
+// provisioned it with, so that the host is deprovisioned before it
+// is bound to another claim. It then removes the finalizer of the
+// claim.
+func (r *HostClaimReconciler) releaseHost(log logr.Logger, claim *metal3v1alpha1.HostClaim) (ctrl.Result, error) {
+	if !utils.StringInList(claim.Finalizers, metal3v1alpha1.HostClaimFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(context.TODO(), hosts, client.InNamespace(claim.Namespace)); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "could not list hosts")
+	}
+
+	if host := findConsumedHost(claim, hosts.Items); host != nil {
+		log.Info("releasing host", "host", host.Name)
+		host.Spec.ConsumerRef = nil
+		host.Spec.Image = nil
+		host.Spec.UserData = nil
+		host.Spec.MetaData = nil
+		host.Spec.NetworkData = nil
+		if err := r.Update(context.TODO(), host); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to release host")
+		}
+	}
+
+	claim.Finalizers = utils.FilterStringFromList(
+		claim.Finalizers, metal3v1alpha1.HostClaimFinalizer)
+	if err := r.Update(context.TODO(), claim); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to remove finalizer")
+	}
+	return ctrl.Result{}, nil
+}
+
+// claimsForHost returns a request for each claim in the namespace of
+// a host that is not bound yet, or that the host is bound to, so that
+// they are reconciled when the host changes.
+func (r *HostClaimReconciler) claimsForHost(obj handler.MapObject) []reconcile.Request {
+	host, ok := obj.Object.(*metal3v1alpha1.BareMetalHost)
+	if !ok {
+		return nil
+	}
+
+	claims := &metal3v1alpha1.HostClaimList{}
+	if err := r.List(context.TODO(), claims, client.InNamespace(host.Namespace)); err != nil {
+		r.Log.Error(err, "could not list host claims", "namespace", host.Namespace)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if claim.Status.Host != nil && !claim.Consumes(host) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: claim.Namespace,
+				Name:      claim.Name,
+			},
+		})
+	}
+	return requests
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *HostClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metal3v1alpha1.HostClaim{}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.BareMetalHost{}},
+			&handler.EnqueueRequestsFromMapFunc{
+				ToRequests: handler.ToRequestsFunc(r.claimsForHost),
+			}).
+		Complete(r)
+}
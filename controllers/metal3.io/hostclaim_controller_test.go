@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newClaimTestReconciler(initObjs ...runtime.Object) *HostClaimReconciler {
+	return &HostClaimReconciler{
+		Client: fakeclient.NewFakeClient(initObjs...),
+		Scheme: scheme.Scheme,
+		Log:    ctrl.Log.WithName("controller").WithName("hostclaim").WithName("test"),
+	}
+}
+
+func newClaim(name string, spec metal3v1alpha1.HostClaimSpec) *metal3v1alpha1.HostClaim {
+	return &metal3v1alpha1.HostClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			UID:        types.UID(name + "-uid"),
+			Finalizers: []string{metal3v1alpha1.HostClaimFinalizer},
+		},
+		Spec: spec,
+	}
+}
+
+func newClaimHost(name string, labels map[string]string) *metal3v1alpha1.BareMetalHost {
+	return &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			Provisioning: metal3v1alpha1.ProvisionStatus{State: metal3v1alpha1.StateReady},
+		},
+	}
+}
+
+// newClaimHostDetails returns the HardwareData holding the inspected
+// hardware details of the host, and references it from the host.
+func newClaimHostDetails(host *metal3v1alpha1.BareMetalHost, details *metal3v1alpha1.HardwareDetails) *metal3v1alpha1.HardwareData {
+	host.Status.HardwareData = &corev1.LocalObjectReference{Name: host.Name}
+	return &metal3v1alpha1.HardwareData{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      host.Name,
+			Namespace: host.Namespace,
+		},
+		Spec: metal3v1alpha1.HardwareDataSpec{HardwareDetails: details},
+	}
+}
+
+func reconcileClaim(t *testing.T, r *HostClaimReconciler, claim *metal3v1alpha1.HostClaim) {
+	_, err := r.Reconcile(ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+	})
+	assert.NoError(t, err)
+}
+
+func getClaimHost(t *testing.T, r *HostClaimReconciler, name string) *metal3v1alpha1.BareMetalHost {
+	host := &metal3v1alpha1.BareMetalHost{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, host)
+	assert.NoError(t, err)
+	return host
+}
+
+func getClaim(t *testing.T, r *HostClaimReconciler, name string) *metal3v1alpha1.HostClaim {
+	claim := &metal3v1alpha1.HostClaim{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, claim)
+	assert.NoError(t, err)
+	return claim
+}
+
+// TestHostClaimBind ensures that a claim is bound to the first
+// available host that matches it.
+func TestHostClaimBind(t *testing.T) {
+	consumed := newClaimHost("host-0", map[string]string{"rack": "a"})
+	consumed.Spec.ConsumerRef = &corev1.ObjectReference{Name: "machine-0", Namespace: namespace}
+	otherRack := newClaimHost("host-1", map[string]string{"rack": "b"})
+	inspecting := newClaimHost("host-1a", map[string]string{"rack": "a"})
+	inspecting.Status.Provisioning.State = metal3v1alpha1.StateInspecting
+	available := newClaimHost("host-2", map[string]string{"rack": "a"})
+	claim := newClaim("claim", metal3v1alpha1.HostClaimSpec{
+		HostSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"rack": "a"},
+		},
+	})
+	r := newClaimTestReconciler(claim, consumed, otherRack, inspecting, available)
+
+	reconcileClaim(t, r, claim)
+
+	claim = getClaim(t, r, "claim")
+	if assert.NotNil(t, claim.Status.Host) {
+		assert.Equal(t, "host-2", claim.Status.Host.Name)
+	}
+	assert.True(t, claim.Consumes(getClaimHost(t, r, "host-2")))
+	assert.Nil(t, getClaimHost(t, r, "host-1").Spec.ConsumerRef)
+	assert.Nil(t, getClaimHost(t, r, "host-1a").Spec.ConsumerRef)
+	assert.Equal(t, "machine-0", getClaimHost(t, r, "host-0").Spec.ConsumerRef.Name)
+}
+
+// TestHostClaimAddFinalizer ensures that a finalizer is added to new
+// claims before a host is bound to them.
+func TestHostClaimAddFinalizer(t *testing.T) {
+	claim := newClaim("claim", metal3v1alpha1.HostClaimSpec{})
+	claim.Finalizers = nil
+	r := newClaimTestReconciler(claim, newClaimHost("host-0", nil))
+
+	reconcileClaim(t, r, claim)
+
+	claim = getClaim(t, r, "claim")
+	assert.Contains(t, claim.Finalizers, metal3v1alpha1.HostClaimFinalizer)
+	assert.Nil(t, getClaimHost(t, r, "host-0").Spec.ConsumerRef)
+}
+
+// TestHostClaimOneHostPerClaim ensures that two claims are never bound
+// to the same host.
+func TestHostClaimOneHostPerClaim(t *testing.T) {
+	first := newClaim("first", metal3v1alpha1.HostClaimSpec{})
+	second := newClaim("second", metal3v1alpha1.HostClaimSpec{})
+	r := newClaimTestReconciler(first, second, newClaimHost("host-0", nil))
+
+	reconcileClaim(t, r, first)
+	reconcileClaim(t, r, second)
+	reconcileClaim(t, r, first)
+
+	assert.NotNil(t, getClaim(t, r, "first").Status.Host)
+	assert.Nil(t, getClaim(t, r, "second").Status.Host)
+	assert.True(t, first.Consumes(getClaimHost(t, r, "host-0")))
+}
+
+// TestHostClaimBindConflict ensures that a host changed since it was
+// listed is not bound.
+func TestHostClaimBindConflict(t *testing.T) {
+	claim := newClaim("claim", metal3v1alpha1.HostClaimSpec{})
+	r := newClaimTestReconciler(claim, newClaimHost("host-0", nil))
+
+	stale := getClaimHost(t, r, "host-0")
+	current := stale.DeepCopy()
+	current.Spec.ConsumerRef = &corev1.ObjectReference{Name: "machine-0", Namespace: namespace}
+	assert.NoError(t, r.Update(goctx.TODO(), current))
+
+	host, err := r.bindHost(r.Log, claim, []metal3v1alpha1.BareMetalHost{*stale})
+	assert.Nil(t, host)
+	assert.True(t, k8serrors.IsConflict(errors.Cause(err)))
+	assert.Equal(t, "machine-0", getClaimHost(t, r, "host-0").Spec.ConsumerRef.Name)
+}
+
+// TestHostClaimNoMatch ensures that a claim stays unbound while no
+// available host meets its requirements.
+func TestHostClaimNoMatch(t *testing.T) {
+	host := newClaimHost("host-0", nil)
+	hardwareData := newClaimHostDetails(host, &metal3v1alpha1.HardwareDetails{
+		RAMMebibytes: 16384,
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SizeBytes: 100 * metal3v1alpha1.GigaByte},
+			{Name: "/dev/sdb", SizeBytes: 100 * metal3v1alpha1.GigaByte},
+			{Name: "/dev/sdc", SizeBytes: 100 * metal3v1alpha1.GigaByte},
+			{Name: "/dev/sdd", SizeBytes: 100 * metal3v1alpha1.GigaByte},
+			{Name: "/dev/sde", SizeBytes: 100 * metal3v1alpha1.GigaByte},
+		},
+	})
+	claim := newClaim("claim", metal3v1alpha1.HostClaimSpec{
+		HardwareRequirements: metal3v1alpha1.HardwareRequirements{
+			MinRAMMebibytes:     8192,
+			MinStorageSizeBytes: 500 * metal3v1alpha1.GigaByte,
+		},
+	})
+	r := newClaimTestReconciler(claim, host, hardwareData)
+
+	reconcileClaim(t, r, claim)
+
+	assert.Nil(t, getClaim(t, r, "claim").Status.Host)
+	assert.Nil(t, getClaimHost(t, r, "host-0").Spec.ConsumerRef)
+}
+
+// TestHostClaimRelease ensures that the host bound to a deleted claim
+// is released, without the image and configuration data of the
+// consumer so that it is deprovisioned, and the finalizer removed.
+func TestHostClaimRelease(t *testing.T) {
+	claim := newClaim("claim", metal3v1alpha1.HostClaimSpec{})
+	claim.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	claim.Status.Host = &corev1.LocalObjectReference{Name: "host-0"}
+	host := newClaimHost("host-0", nil)
+	host.Spec.ConsumerRef = claim.ConsumerRef()
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "http://example.com/image.qcow2"}
+	host.Spec.UserData = &corev1.SecretReference{Name: "user-data"}
+	host.Spec.MetaData = &corev1.SecretReference{Name: "meta-data"}
+	host.Spec.NetworkData = &corev1.SecretReference{Name: "network-data"}
+	r := newClaimTestReconciler(claim, host)
+
+	reconcileClaim(t, r, claim)
+
+	released := getClaimHost(t, r, "host-0")
+	assert.Nil(t, released.Spec.ConsumerRef)
+	assert.Nil(t, released.Spec.Image)
+	assert.Nil(t, released.Spec.UserData)
+	assert.Nil(t, released.Spec.MetaData)
+	assert.Nil(t, released.Spec.NetworkData)
+	assert.NotContains(t, getClaim(t, r, "claim").Finalizers, metal3v1alpha1.HostClaimFinalizer)
+}
+
+// TestClaimsForHost ensures that a host change only triggers the
+// claims that may bind it or are bound to it.
+func TestClaimsForHost(t *testing.T) {
+	unbound := newClaim("unbound", metal3v1alpha1.HostClaimSpec{})
+	bound := newClaim("bound", metal3v1alpha1.HostClaimSpec{})
+	bound.Status.Host = &corev1.LocalObjectReference{Name: "host-0"}
+	other := newClaim("other", metal3v1alpha1.HostClaimSpec{})
+	other.Status.Host = &corev1.LocalObjectReference{Name: "host-1"}
+	host := newClaimHost("host-0", nil)
+	host.Spec.ConsumerRef = bound.ConsumerRef()
+	r := newClaimTestReconciler(unbound, bound, other)
+
+	requests := r.claimsForHost(handler.MapObject{Meta: host, Object: host})
+
+	var names []string
+	for _, request := range requests {
+		names = append(names, request.Name)
+	}
+	assert.ElementsMatch(t, []string{"unbound", "bound"}, names)
+}
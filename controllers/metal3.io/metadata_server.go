@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// metadataServiceIDIndex is the field index of the hosts using the
+// metadata service by the IDs they can be looked up with.
+const metadataServiceIDIndex = "metadataServiceID"
+
+// instanceIDHeader identifies the host in requests using the standard
+// OpenStack paths, as in the OpenStack metadata proxy.
+const instanceIDHeader = "X-Instance-ID"
+
+// MetadataServer serves the configuration data of the hosts using the
+// metadata service instead of a config drive, in the layout of the
+// OpenStack metadata service, at /<host>/openstack/<version>/<file>.
+// Hosts are identified by their boot MAC address or their UID, which is
+// the instance UUID of their metadata. The host can also be given in
+// the X-Instance-ID header of requests for /openstack/<version>/<file>.
+// All versions are served the same data.
+type MetadataServer struct {
+	Client client.Client
+	Log    logr.Logger
+	Addr   string
+}
+
+// SetupWithManager indexes the hosts using the metadata service and
+// adds the server to the manager.
+func (s *MetadataServer) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(),
+		&metal3v1alpha1.BareMetalHost{}, metadataServiceIDIndex, metadataServiceIDs); err != nil {
+		return errors.Wrap(err, "failed to index hosts using the metadata service")
+	}
+	return mgr.Add(s)
+}
+
+// NeedLeaderElection lets every replica of the operator serve
+// metadata, since the server does not change anything.
+func (s *MetadataServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves metadata until stop is closed.
+func (s *MetadataServer) Start(stop <-chan struct{}) error {
+	server := &http.Server{Addr: s.Addr, Handler: s}
+	errs := make(chan error, 1)
+	go func() {
+		s.Log.Info("serving metadata", "address", s.Addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return errors.Wrap(err, "failed to serve metadata")
+	case <-stop:
+		return server.Shutdown(context.Background())
+	}
+}
+
+func (s *MetadataServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var id string
+	switch {
+	case len(parts) == 4 && parts[1] == "openstack":
+		id, parts = parts[0], parts[1:]
+	case len(parts) == 3 && parts[0] == "openstack":
+		id = r.Header.Get(instanceIDHeader)
+	}
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	host, err := s.findHost(id)
+	if err != nil {
+		s.Log.Error(err, "failed to find host", "id", id)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if host == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	log := s.Log.WithValues("baremetalhost", host.Namespace+"/"+host.Name)
+	hcd := &hostConfigData{host: host, log: log, client: s.Client}
+	var data string
+	switch parts[2] {
+	case "meta_data.json":
+		data, err = metaDataJSON(hcd)
+	case "user_data":
+		data, err = hcd.UserData()
+	case "network_data.json":
+		data, err = networkDataJSON(hcd)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		// The errors may show the contents of the secrets, they are
+		// only logged.
+		log.Error(err, "failed to get configuration data", "file", parts[2])
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if data == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if strings.HasSuffix(parts[2], ".json") {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	_, _ = w.Write([]byte(data))
+}
+
+// findHost returns the host using the metadata service with the given
+// boot MAC address or UID, or nil if there is none or the ID is
+// ambiguous.
+func (s *MetadataServer) findHost(id string) (*metal3v1alpha1.BareMetalHost, error) {
+	id = normalizeMetadataServiceID(id)
+
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := s.Client.List(context.TODO(), hosts,
+		client.MatchingFields{metadataServiceIDIndex: id}); err != nil {
+		return nil, errors.Wrap(err, "failed to list hosts")
+	}
+
+	var found []*metal3v1alpha1.BareMetalHost
+	for i := range hosts.Items {
+		host := &hosts.Items[i]
+		for _, hostID := range metadataServiceIDs(host) {
+			if hostID == id {
+				found = append(found, host)
+				break
+			}
+		}
+	}
+	if len(found) > 1 {
+		// Serving either host could leak the data of the other one.
+		s.Log.Info("more than one host matches, not serving any",
+			"id", id,
+			"hosts", []string{found[0].Namespace + "/" + found[0].Name, found[1].Namespace + "/" + found[1].Name})
+		return nil, nil
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return found[0], nil
+}
+
+// metadataServiceIDs returns the IDs a host using the metadata service
+// can be looked up with, for the metadataServiceIDIndex field index.
+func metadataServiceIDs(obj runtime.Object) []string {
+	host, ok := obj.(*metal3v1alpha1.BareMetalHost)
+	if !ok || !host.UsesMetadataService() {
+		return nil
+	}
+	var ids []string
+	if host.UID != "" {
+		ids = append(ids, string(host.UID))
+	}
+	if host.Spec.BootMACAddress != "" {
+		ids = append(ids, normalizeMetadataServiceID(host.Spec.BootMACAddress))
+	}
+	return ids
+}
+
+// normalizeMetadataServiceID returns MAC addresses, which may be
+// written with dashes as iPXE and some kernel command lines do, in
+// lower case with colons, and other IDs in lower case.
+func normalizeMetadataServiceID(id string) string {
+	if mac, err := net.ParseMAC(id); err == nil {
+		return mac.String()
+	}
+	return strings.ToLower(id)
+}
+
+// MetadataBindAddress returns the address the metadata service binds
+// to. When addr has no host, the service binds to the first address of
+// the provisioning interface, if one is given, so that it is only
+// reachable from the provisioning network.
+func MetadataBindAddress(addr, provisioningInterface string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid metadata address %q", addr)
+	}
+	if host != "" || provisioningInterface == "" {
+		return addr, nil
+	}
+
+	iface, err := net.InterfaceByName(provisioningInterface)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find provisioning interface %s", provisioningInterface)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read addresses of provisioning interface %s", provisioningInterface)
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+			return net.JoinHostPort(ipNet.IP.String(), port), nil
+		}
+	}
+	return "", errors.Errorf("provisioning interface %s has no address", provisioningInterface)
+}
+
+// metaDataJSON returns the meta_data.json of a host, with the same
+// default keys as in config drives.
+func metaDataJSON(hcd *hostConfigData) (string, error) {
+	metaData := map[string]interface{}{
+		"uuid":             string(hcd.host.UID),
+		"metal3-namespace": hcd.host.Namespace,
+		"metal3-name":      hcd.host.Name,
+		"local-hostname":   hcd.host.Name,
+		"local_hostname":   hcd.host.Name,
+	}
+	metaDataRaw, err := hcd.MetaData()
+	if err != nil {
+		return "", err
+	}
+	if err := yaml.Unmarshal([]byte(metaDataRaw), &metaData); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal metadata from secret")
+	}
+	data, err := json.Marshal(metaData)
+	return string(data), err
+}
+
+// networkDataJSON returns the network_data.json of a host, which the
+// network data secret may hold as YAML.
+func networkDataJSON(hcd *hostConfigData) (string, error) {
+	networkDataRaw, err := hcd.NetworkData()
+	if err != nil || networkDataRaw == "" {
+		return "", err
+	}
+	data, err := yaml.YAMLToJSON([]byte(networkDataRaw))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal network_data.json from secret")
+	}
+	return string(data), nil
+}
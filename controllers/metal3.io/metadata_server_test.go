@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestMetadataServer(t *testing.T) {
+	annotations := map[string]string{
+		metal3v1alpha1.ConfigDataAnnotation: metal3v1alpha1.ConfigDataMetadataService,
+	}
+	metadataHost := newHost("metadata-host", &metal3v1alpha1.BareMetalHostSpec{
+		BootMACAddress: "00:11:22:33:44:AA",
+		UserData:       &corev1.SecretReference{Name: "user-data", Namespace: namespace},
+		NetworkData:    &corev1.SecretReference{Name: "network-data", Namespace: namespace},
+	})
+	metadataHost.UID = "27720611-e5d1-45d3-ba3a-222dcfaa4ca2"
+	metadataHost.Annotations = annotations
+	configDriveHost := newHost("config-drive-host", &metal3v1alpha1.BareMetalHostSpec{
+		BootMACAddress: "00:11:22:33:44:bb",
+		UserData:       &corev1.SecretReference{Name: "user-data", Namespace: namespace},
+	})
+	// Two hosts claiming the same boot MAC address.
+	duplicateHost1 := newHost("duplicate-host-1", &metal3v1alpha1.BareMetalHostSpec{
+		BootMACAddress: "00:11:22:33:44:dd",
+		UserData:       &corev1.SecretReference{Name: "user-data", Namespace: namespace},
+	})
+	duplicateHost1.UID = "5dc6ed8c-48fa-4bd6-b5b7-4dcf19b6b2a0"
+	duplicateHost1.Annotations = annotations
+	duplicateHost2 := newHost("duplicate-host-2", &metal3v1alpha1.BareMetalHostSpec{
+		BootMACAddress: "00:11:22:33:44:DD",
+		UserData:       &corev1.SecretReference{Name: "user-data", Namespace: namespace},
+	})
+	duplicateHost2.UID = "a3b8e1a4-5e0b-4c4f-9a54-5f3a1b9a5e6b"
+	duplicateHost2.Annotations = annotations
+	secret := func(name string, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       data,
+		}
+	}
+
+	server := &MetadataServer{
+		Client: fakeclient.NewFakeClient(
+			metadataHost,
+			configDriveHost,
+			duplicateHost1,
+			duplicateHost2,
+			secret("user-data", map[string][]byte{"userData": []byte("#cloud-config\n")}),
+			secret("network-data", map[string][]byte{"networkData": []byte("links: []\nnetworks: []\n")}),
+		),
+		Log: ctrl.Log.WithName("Test"),
+	}
+
+	testCases := []struct {
+		Scenario     string
+		Path         string
+		InstanceID   string
+		ExpectedCode int
+		ExpectedBody string
+	}{
+		{
+			Scenario:     "metadata by MAC",
+			Path:         "/00-11-22-33-44-aa/openstack/latest/meta_data.json",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: `{"uuid": "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",` +
+				` "metal3-namespace": "test-namespace", "metal3-name": "metadata-host",` +
+				` "local-hostname": "metadata-host", "local_hostname": "metadata-host"}`,
+		},
+		{
+			Scenario:     "user data by UUID",
+			Path:         "/27720611-e5d1-45d3-ba3a-222dcfaa4ca2/openstack/2012-08-10/user_data",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: "#cloud-config\n",
+		},
+		{
+			Scenario:     "network data by instance ID header",
+			Path:         "/openstack/latest/network_data.json",
+			InstanceID:   "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: `{"links": [], "networks": []}`,
+		},
+		{
+			Scenario:     "no host",
+			Path:         "/openstack/latest/user_data",
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Scenario:     "host using a config drive",
+			Path:         "/00:11:22:33:44:bb/openstack/latest/user_data",
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Scenario:     "unknown host",
+			Path:         "/00:11:22:33:44:cc/openstack/latest/user_data",
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Scenario:     "ambiguous MAC",
+			Path:         "/00:11:22:33:44:dd/openstack/latest/user_data",
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Scenario:     "UUID of a host with an ambiguous MAC",
+			Path:         "/5dc6ed8c-48fa-4bd6-b5b7-4dcf19b6b2a0/openstack/latest/user_data",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: "#cloud-config\n",
+		},
+		{
+			Scenario:     "unknown file",
+			Path:         "/00:11:22:33:44:aa/openstack/latest/vendor_data.json",
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Scenario:     "not OpenStack",
+			Path:         "/00:11:22:33:44:aa/latest/meta-data",
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.Path, nil)
+			if tc.InstanceID != "" {
+				req.Header.Set(instanceIDHeader, tc.InstanceID)
+			}
+
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.ExpectedCode, recorder.Code)
+			if tc.ExpectedCode != http.StatusOK {
+				return
+			}
+			if recorder.Header().Get("Content-Type") == "application/json" {
+				assert.JSONEq(t, tc.ExpectedBody, recorder.Body.String())
+			} else {
+				assert.Equal(t, tc.ExpectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestMetadataBindAddress(t *testing.T) {
+	testCases := []struct {
+		Scenario              string
+		Addr                  string
+		ProvisioningInterface string
+		Expected              string
+		ExpectError           bool
+	}{
+		{
+			Scenario:              "host given",
+			Addr:                  "192.168.111.1:8089",
+			ProvisioningInterface: "lo",
+			Expected:              "192.168.111.1:8089",
+		},
+		{
+			Scenario: "no provisioning interface",
+			Addr:     ":8089",
+			Expected: ":8089",
+		},
+		{
+			Scenario:              "provisioning interface",
+			Addr:                  ":8089",
+			ProvisioningInterface: "lo",
+			Expected:              "127.0.0.1:8089",
+		},
+		{
+			Scenario:              "unknown provisioning interface",
+			Addr:                  ":8089",
+			ProvisioningInterface: "does-not-exist",
+			ExpectError:           true,
+		},
+		{
+			Scenario:    "no port",
+			Addr:        "192.168.111.1",
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			addr, err := MetadataBindAddress(tc.Addr, tc.ProvisioningInterface)
+			if tc.ExpectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, addr)
+		})
+	}
+}
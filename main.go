@@ -17,24 +17,30 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
 
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	metal3iov1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	metal3iov1alpha2 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha2"
 	metal3iocontroller "github.com/metal3-io/baremetal-operator/controllers/metal3.io"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/demo"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic"
 	"github.com/metal3-io/baremetal-operator/pkg/version"
+	metal3iowebhooks "github.com/metal3-io/baremetal-operator/webhooks/metal3.io"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,6 +53,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = metal3iov1alpha1.AddToScheme(scheme)
+	_ = metal3iov1alpha2.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -56,6 +63,16 @@ func printVersion() {
 	setupLog.Info(fmt.Sprintf("baremetal-operator version: %s", version.String))
 }
 
+// defaultWebhookPort returns the webhook port from the environment,
+// or 0 to leave the webhooks disabled.
+func defaultWebhookPort() int {
+	port, err := strconv.Atoi(os.Getenv("WEBHOOK_PORT"))
+	if err != nil {
+		return 0
+	}
+	return port
+}
+
 func main() {
 	var watchNamespace string
 	var metricsAddr string
@@ -63,6 +80,9 @@ func main() {
 	var devLogging bool
 	var runInTestMode bool
 	var runInDemoMode bool
+	var webhookPort int
+	var metadataAddr string
+	var metadataURL string
 
 	// From CAPI point of view, BMO should be able to watch all namespaces
 	// in case of a deployment that is not multi-tenant. If the deployment
@@ -79,6 +99,13 @@ func main() {
 	flag.BoolVar(&runInTestMode, "test-mode", false, "disable ironic communication")
 	flag.BoolVar(&runInDemoMode, "demo-mode", false,
 		"use the demo provisioner to set host states")
+	flag.IntVar(&webhookPort, "webhook-port", defaultWebhookPort(),
+		"Webhook Server port (set to 0 to disable webhooks).")
+	flag.StringVar(&metadataAddr, "metadata-addr", os.Getenv("METADATA_ADDR"),
+		"The address the metadata service binds to (empty to disable it). "+
+			"Without a host, it binds to the address of the provisioning interface.")
+	flag.StringVar(&metadataURL, "metadata-url", os.Getenv("METADATA_URL"),
+		"The URL hosts reach the metadata service at (defaults to the address it binds to).")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(devLogging)))
@@ -88,7 +115,7 @@ func main() {
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                  scheme,
 		MetricsBindAddress:      metricsAddr,
-		Port:                    0, // Add flag with default of 9443 when adding webhooks
+		Port:                    webhookPort,
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionID:        "baremetal-operator",
 		LeaderElectionNamespace: watchNamespace,
@@ -99,6 +126,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Hardware profiles are looked up through the cache, and the
+	// default profiles are created once the manager is running.
+	hardware.SetProfileReader(mgr.GetCache())
+	if err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		return hardware.EnsureDefaultProfiles(context.Background(), mgr.GetClient())
+	})); err != nil {
+		setupLog.Error(err, "unable to set up default hardware profiles")
+		os.Exit(1)
+	}
+
+	if metadataAddr != "" {
+		metadataAddr, err = metal3iocontroller.MetadataBindAddress(
+			metadataAddr, os.Getenv("PROVISIONING_INTERFACE"))
+		if err != nil {
+			setupLog.Error(err, "unable to set up metadata service")
+			os.Exit(1)
+		}
+		if metadataURL == "" {
+			metadataURL = "http://" + metadataAddr
+		}
+		ironic.SetMetadataURL(metadataURL)
+	}
+
 	var provisionerFactory provisioner.Factory
 	if runInTestMode {
 		provisionerFactory = fixture.New
@@ -120,6 +170,47 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalHost")
 		os.Exit(1)
 	}
+
+	if err = (&metal3iocontroller.HostClaimReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("HostClaim"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HostClaim")
+		os.Exit(1)
+	}
+
+	if metadataAddr != "" {
+		if err = (&metal3iocontroller.MetadataServer{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("metadata"),
+			Addr:   metadataAddr,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to set up metadata service")
+			os.Exit(1)
+		}
+	}
+
+	if webhookPort != 0 {
+		if err = ctrl.NewWebhookManagedBy(mgr).
+			For(&metal3iov1alpha1.BareMetalHost{}).
+			Complete(); err != nil {
+			setupLog.Error(err, "unable to create conversion webhook", "webhook", "BareMetalHost")
+			os.Exit(1)
+		}
+		if err = (&metal3iowebhooks.BareMetalHostDefaulter{
+			Log: ctrl.Log.WithName("webhooks").WithName("BareMetalHost"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BareMetalHost")
+			os.Exit(1)
+		}
+		if err = (&metal3iowebhooks.BareMetalHostValidator{
+			Log: ctrl.Log.WithName("webhooks").WithName("BareMetalHost"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BareMetalHost")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
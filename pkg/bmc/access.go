@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 )
 
 // AccessDetailsFactory describes a callable that returns a new
@@ -56,6 +58,10 @@ type AccessDetails interface {
 	PowerInterface() string
 	RAIDInterface() string
 	VendorInterface() string
+
+	// BuildBIOSSettings converts the firmware settings of a host into
+	// the BIOS settings understood by the driver.
+	BuildBIOSSettings(firmwareConfig *metal3v1alpha1.FirmwareConfig) (settings []map[string]string, err error)
 }
 
 func getParsedURL(address string) (parsedURL *url.URL, err error) {
@@ -102,6 +108,20 @@ func getParsedURL(address string) (parsedURL *url.URL, err error) {
 	return parsedURL, nil
 }
 
+// DefaultAddress returns the BMC address with the scheme filled in
+// when the address is a bare host or host:port, for which the ipmi
+// scheme is assumed. Other addresses are returned unchanged.
+func DefaultAddress(address string) (string, error) {
+	parsedURL, err := getParsedURL(address)
+	if err != nil {
+		return address, err
+	}
+	if parsedURL.Scheme == "ipmi" && !strings.Contains(address, "://") {
+		return parsedURL.String(), nil
+	}
+	return address, nil
+}
+
 // NewAccessDetails creates an AccessDetails structure from the URL
 // for a BMC.
 func NewAccessDetails(address string, disableCertificateVerification bool) (AccessDetails, error) {
@@ -1084,3 +1084,47 @@ func TestUnknownType(t *testing.T) {
 		t.Fatalf("unexpected parse success")
 	}
 }
+
+func TestDefaultAddress(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Address  string
+		Expected string
+	}{
+		{
+			Scenario: "host only",
+			Address:  "192.168.122.1",
+			Expected: "ipmi://192.168.122.1",
+		},
+		{
+			Scenario: "host and port",
+			Address:  "192.168.122.1:6233",
+			Expected: "ipmi://192.168.122.1:6233",
+		},
+		{
+			Scenario: "ipv6 host and port",
+			Address:  "[fe80::fc33:62ff:fe83:8a76]:6233",
+			Expected: "ipmi://[fe80::fc33:62ff:fe83:8a76]:6233",
+		},
+		{
+			Scenario: "ipmi url",
+			Address:  "ipmi://192.168.122.1",
+			Expected: "ipmi://192.168.122.1",
+		},
+		{
+			Scenario: "redfish url",
+			Address:  "redfish://192.168.122.1/redfish/v1/Systems/1",
+			Expected: "redfish://192.168.122.1/redfish/v1/Systems/1",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := DefaultAddress(tc.Address)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.Expected {
+				t.Fatalf("unexpected address %q, expected %q", actual, tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package bmc
+
+import (
+	"fmt"
+	"sort"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// biosSettingNames holds the names a driver uses for the well-known
+// BIOS settings, and the values it uses to turn them on and off.
+type biosSettingNames struct {
+	virtualization string
+	multithreading string
+	sriov          string
+	enabled        string
+	disabled       string
+}
+
+var (
+	// The BIOS attribute names used by Dell and HPE servers.
+	dellBIOSSettingNames = &biosSettingNames{
+		virtualization: "ProcVirtualization",
+		multithreading: "LogicalProc",
+		sriov:          "SriovGlobalEnable",
+		enabled:        "Enabled",
+		disabled:       "Disabled",
+	}
+	hpeBIOSSettingNames = &biosSettingNames{
+		virtualization: "ProcVirtualization",
+		multithreading: "ProcHyperthreading",
+		sriov:          "Sriov",
+		enabled:        "Enabled",
+		disabled:       "Disabled",
+	}
+	fujitsuBIOSSettingNames = &biosSettingNames{
+		virtualization: "cpu_vt_enabled",
+		multithreading: "hyper_threading_enabled",
+		sriov:          "single_root_io_virtualization_support_enabled",
+		enabled:        "True",
+		disabled:       "False",
+	}
+)
+
+// buildBIOSSettings converts the firmware settings of a host into the
+// list of BIOS settings passed to the apply_configuration clean step
+// of Ironic. Drivers without names for the well-known settings pass
+// nil names, and only support the vendor specific settings. A vendor
+// specific setting with the name of a well-known setting that is set
+// is an error, since the order Ironic applies them in would decide the
+// value.
+func buildBIOSSettings(firmwareConfig *metal3v1alpha1.FirmwareConfig, names *biosSettingNames, driver string) (settings []map[string]string, err error) {
+	if firmwareConfig == nil {
+		return nil, nil
+	}
+
+	// The fields of the well-known settings that are set, by name.
+	wellKnown := map[string]string{}
+	add := func(name string, field string, value *bool) error {
+		if value == nil {
+			return nil
+		}
+		if names == nil {
+			return fmt.Errorf("the %s driver does not support the well-known firmware settings, use vendor specific settings instead",
+				driver)
+		}
+		v := names.disabled
+		if *value {
+			v = names.enabled
+		}
+		settings = append(settings, map[string]string{"name": name, "value": v})
+		wellKnown[name] = field
+		return nil
+	}
+
+	var virtualization, multithreading, sriov string
+	if names != nil {
+		virtualization, multithreading, sriov = names.virtualization, names.multithreading, names.sriov
+	}
+	if err := add(virtualization, "virtualizationEnabled", firmwareConfig.VirtualizationEnabled); err != nil {
+		return nil, err
+	}
+	if err := add(multithreading, "simultaneousMultithreadingEnabled", firmwareConfig.SimultaneousMultithreadingEnabled); err != nil {
+		return nil, err
+	}
+	if err := add(sriov, "sriovEnabled", firmwareConfig.SriovEnabled); err != nil {
+		return nil, err
+	}
+
+	// Sort the vendor specific settings so the clean step does not
+	// change between calls.
+	keys := make([]string, 0, len(firmwareConfig.Settings))
+	for name := range firmwareConfig.Settings {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		if field, ok := wellKnown[name]; ok {
+			return nil, fmt.Errorf("the vendor specific firmware setting %s conflicts with %s, set only one of them",
+				name, field)
+		}
+		settings = append(settings, map[string]string{
+			"name":  name,
+			"value": firmwareConfig.Settings[name],
+		})
+	}
+
+	return settings, nil
+}
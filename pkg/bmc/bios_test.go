@@ -0,0 +1,123 @@
+package bmc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestBuildBIOSSettings(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	testCases := []struct {
+		Scenario      string
+		Address       string
+		Firmware      *metal3v1alpha1.FirmwareConfig
+		Expected      []map[string]string
+		ExpectedError string
+	}{
+		{
+			Scenario: "no settings",
+			Address:  "ipmi://192.168.122.1",
+		},
+		{
+			Scenario: "idrac",
+			Address:  "idrac://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				VirtualizationEnabled:             &enabled,
+				SimultaneousMultithreadingEnabled: &disabled,
+				SriovEnabled:                      &enabled,
+			},
+			Expected: []map[string]string{
+				{"name": "ProcVirtualization", "value": "Enabled"},
+				{"name": "LogicalProc", "value": "Disabled"},
+				{"name": "SriovGlobalEnable", "value": "Enabled"},
+			},
+		},
+		{
+			Scenario: "ilo5",
+			Address:  "ilo5://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				SimultaneousMultithreadingEnabled: &enabled,
+			},
+			Expected: []map[string]string{
+				{"name": "ProcHyperthreading", "value": "Enabled"},
+			},
+		},
+		{
+			Scenario: "irmc",
+			Address:  "irmc://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				SriovEnabled: &disabled,
+			},
+			Expected: []map[string]string{
+				{"name": "single_root_io_virtualization_support_enabled", "value": "False"},
+			},
+		},
+		{
+			Scenario: "vendor specific settings after well-known settings",
+			Address:  "idrac://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				VirtualizationEnabled: &enabled,
+				Settings: map[string]string{
+					"LogicalProc": "Disabled",
+					"BootMode":    "Uefi",
+				},
+			},
+			Expected: []map[string]string{
+				{"name": "ProcVirtualization", "value": "Enabled"},
+				{"name": "BootMode", "value": "Uefi"},
+				{"name": "LogicalProc", "value": "Disabled"},
+			},
+		},
+		{
+			Scenario: "vendor specific setting conflicting with a well-known setting",
+			Address:  "idrac://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				VirtualizationEnabled: &enabled,
+				Settings: map[string]string{
+					"ProcVirtualization": "Disabled",
+					"BootMode":           "Uefi",
+				},
+			},
+			ExpectedError: "the vendor specific firmware setting ProcVirtualization conflicts with virtualizationEnabled, set only one of them",
+		},
+		{
+			Scenario: "vendor specific settings without well-known names",
+			Address:  "redfish://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{"BootMode": "Uefi"},
+			},
+			Expected: []map[string]string{
+				{"name": "BootMode", "value": "Uefi"},
+			},
+		},
+		{
+			Scenario: "well-known settings without well-known names",
+			Address:  "ipmi://192.168.122.1",
+			Firmware: &metal3v1alpha1.FirmwareConfig{
+				VirtualizationEnabled: &enabled,
+			},
+			ExpectedError: "the ipmi driver does not support the well-known firmware settings, use vendor specific settings instead",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			acc, err := NewAccessDetails(tc.Address, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			settings, err := acc.BuildBIOSSettings(tc.Firmware)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, settings)
+		})
+	}
+}
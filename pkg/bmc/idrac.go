@@ -3,6 +3,8 @@ package bmc
 import (
 	"net/url"
 	"strings"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 )
 
 func init() {
@@ -93,3 +95,9 @@ func (a *iDracAccessDetails) RAIDInterface() string {
 func (a *iDracAccessDetails) VendorInterface() string {
 	return ""
 }
+
+// BuildBIOSSettings converts the firmware settings of a host into
+// the BIOS settings understood by the driver.
+func (a *iDracAccessDetails) BuildBIOSSettings(firmwareConfig *metal3v1alpha1.FirmwareConfig) (settings []map[string]string, err error) {
+	return buildBIOSSettings(firmwareConfig, dellBIOSSettingNames, a.Driver())
+}
@@ -4,6 +4,8 @@ package bmc
 
 import (
 	"net/url"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 )
 
 func init() {
@@ -89,3 +91,9 @@ func (a *iLOAccessDetails) RAIDInterface() string {
 func (a *iLOAccessDetails) VendorInterface() string {
 	return ""
 }
+
+// BuildBIOSSettings converts the firmware settings of a host into
+// the BIOS settings understood by the driver.
+func (a *iLOAccessDetails) BuildBIOSSettings(firmwareConfig *metal3v1alpha1.FirmwareConfig) (settings []map[string]string, err error) {
+	return buildBIOSSettings(firmwareConfig, hpeBIOSSettingNames, a.Driver())
+}
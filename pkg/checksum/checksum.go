@@ -0,0 +1,236 @@
+/*
+Package checksum fetches the checksums of images from checksum files
+served over http(s).
+*/
+package checksum
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// maxFileSize is the largest checksum file that is read.
+const maxFileSize = 1024 * 1024
+
+// maxCacheEntries is the number of checksum files a Resolver caches.
+const maxCacheEntries = 256
+
+// digestLengths maps the supported checksum algorithms to the length
+// of their hexadecimal digests.
+var digestLengths = map[metal3v1alpha1.ChecksumType]int{
+	metal3v1alpha1.MD5:    32,
+	metal3v1alpha1.SHA256: 64,
+	metal3v1alpha1.SHA512: 128,
+}
+
+var hexDigest = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// InvalidChecksumError is returned when a checksum file does not hold
+// a valid checksum for an image. Fetching the file again will not fix
+// it.
+type InvalidChecksumError struct {
+	Message string
+}
+
+func (e InvalidChecksumError) Error() string {
+	return e.Message
+}
+
+// IsInvalid returns true when the error, or its cause, is an
+// InvalidChecksumError.
+func IsInvalid(err error) bool {
+	_, ok := errors.Cause(err).(InvalidChecksumError)
+	return ok
+}
+
+func invalidf(format string, args ...interface{}) error {
+	return InvalidChecksumError{Message: fmt.Sprintf(format, args...)}
+}
+
+type cacheEntry struct {
+	content string
+	fetched time.Time
+}
+
+// Resolver fetches checksum files and caches their contents for a
+// while, so that the file is not downloaded on every reconcile. The
+// cache is bounded: expired files are dropped, and the oldest one is
+// evicted when it is full.
+type Resolver struct {
+	client     *http.Client
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewResolver returns a Resolver using the client to fetch checksum
+// files, and caching them for ttl.
+func NewResolver(client *http.Client, ttl time.Duration) *Resolver {
+	return &Resolver{
+		client:     client,
+		ttl:        ttl,
+		maxEntries: maxCacheEntries,
+		now:        time.Now,
+		cache:      map[string]cacheEntry{},
+	}
+}
+
+// Resolve returns the checksum of the image at imageURL read from the
+// checksum file at checksumURL, and its algorithm. The algorithm is
+// guessed from the length of the checksum when checksumType is empty.
+// The file may hold a single checksum without a file name, or one
+// line per file in the format written by md5sum and sha256sum, keyed
+// by the file name of the image.
+func (r *Resolver) Resolve(imageURL, checksumURL string, checksumType metal3v1alpha1.ChecksumType) (string, metal3v1alpha1.ChecksumType, error) {
+	content, err := r.fetch(checksumURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	digest, err := findChecksum(content, imageURL)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid checksum file %s", checksumURL)
+	}
+
+	resolvedType, err := validateChecksum(digest, checksumType)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid checksum file %s", checksumURL)
+	}
+	return strings.ToLower(digest), resolvedType, nil
+}
+
+// fetch returns the content of the checksum file, from the cache when
+// it was fetched recently.
+func (r *Resolver) fetch(checksumURL string) (string, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[checksumURL]
+	r.mu.Unlock()
+	if ok && r.now().Sub(entry.fetched) < r.ttl {
+		return entry.content, nil
+	}
+
+	resp, err := r.client.Get(checksumURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to fetch checksum file %s", checksumURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksum file %s: %s", checksumURL, resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read checksum file %s", checksumURL)
+	}
+
+	content := string(body)
+	r.store(checksumURL, content)
+	return content, nil
+}
+
+// store adds the content of the checksum file to the cache, after
+// dropping the expired files and, when the cache is still full, the
+// oldest one.
+func (r *Resolver) store(checksumURL, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	delete(r.cache, checksumURL)
+	for key, entry := range r.cache {
+		if now.Sub(entry.fetched) >= r.ttl {
+			delete(r.cache, key)
+		}
+	}
+	for len(r.cache) >= r.maxEntries && len(r.cache) > 0 {
+		oldest := ""
+		for key, entry := range r.cache {
+			if oldest == "" || entry.fetched.Before(r.cache[oldest].fetched) {
+				oldest = key
+			}
+		}
+		delete(r.cache, oldest)
+	}
+	r.cache[checksumURL] = cacheEntry{content: content, fetched: now}
+}
+
+// findChecksum returns the checksum of the image in the content of a
+// checksum file.
+func findChecksum(content, imageURL string) (string, error) {
+	imageName := imageURL
+	if parsed, err := url.Parse(imageURL); err == nil {
+		imageName = parsed.Path
+	}
+	imageName = path.Base(imageName)
+
+	var lines [][]string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		lines = append(lines, fields)
+	}
+
+	// A file holding a single checksum may not name the image at all.
+	if len(lines) == 1 && len(lines[0]) == 1 {
+		return lines[0][0], nil
+	}
+	for _, fields := range lines {
+		if len(fields) < 2 {
+			continue
+		}
+		// sha256sum marks files read in binary mode with a '*'.
+		name := strings.TrimPrefix(fields[len(fields)-1], "*")
+		if path.Base(name) == imageName {
+			return fields[0], nil
+		}
+	}
+	if len(lines) == 0 {
+		return "", invalidf("no checksum found")
+	}
+	return "", invalidf("no checksum found for %s", imageName)
+}
+
+// validateChecksum checks that the checksum is a digest of the given
+// algorithm, or of any supported algorithm when it is empty, and
+// returns the algorithm.
+func validateChecksum(digest string, checksumType metal3v1alpha1.ChecksumType) (metal3v1alpha1.ChecksumType, error) {
+	if !hexDigest.MatchString(digest) {
+		return "", invalidf("checksum %q is not hexadecimal", digest)
+	}
+
+	if checksumType != "" {
+		length, ok := digestLengths[checksumType]
+		if !ok {
+			return "", invalidf("unsupported checksum type %s", checksumType)
+		}
+		if len(digest) != length {
+			return "", invalidf("checksum %q is not a %s checksum", digest, checksumType)
+		}
+		return checksumType, nil
+	}
+
+	for algorithm, length := range digestLengths {
+		if len(digest) == length {
+			return algorithm, nil
+		}
+	}
+	return "", invalidf("checksum %q has an unknown length", digest)
+}
@@ -0,0 +1,190 @@
+package checksum
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const (
+	md5Digest    = "d41d8cd98f00b204e9800998ecf8427e"
+	sha256Digest = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestResolve(t *testing.T) {
+	testCases := []struct {
+		Scenario     string
+		Content      string
+		ImageURL     string
+		ChecksumType metal3v1alpha1.ChecksumType
+		Expected     string
+		ExpectedType metal3v1alpha1.ChecksumType
+		Invalid      bool
+	}{
+		{
+			Scenario:     "single checksum",
+			Content:      md5Digest + "\n",
+			ImageURL:     "http://example.com/images/image.qcow2",
+			Expected:     md5Digest,
+			ExpectedType: metal3v1alpha1.MD5,
+		},
+		{
+			Scenario:     "single checksum with the file name",
+			Content:      md5Digest + "  image.qcow2\n",
+			ImageURL:     "http://example.com/images/image.qcow2",
+			Expected:     md5Digest,
+			ExpectedType: metal3v1alpha1.MD5,
+		},
+		{
+			Scenario: "single checksum with another file name",
+			Content:  md5Digest + "  other.qcow2\n",
+			ImageURL: "http://example.com/images/image.qcow2",
+			Invalid:  true,
+		},
+		{
+			Scenario: "sha256sum file",
+			Content: "# checksums\n" +
+				md5Digest + md5Digest + "  other.qcow2\n" +
+				sha256Digest + " *image.qcow2\n",
+			ImageURL:     "http://example.com/images/image.qcow2?version=1",
+			Expected:     sha256Digest,
+			ExpectedType: metal3v1alpha1.SHA256,
+		},
+		{
+			Scenario:     "upper case checksum",
+			Content:      "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855\n",
+			ImageURL:     "http://example.com/image.qcow2",
+			ChecksumType: metal3v1alpha1.SHA256,
+			Expected:     sha256Digest,
+			ExpectedType: metal3v1alpha1.SHA256,
+		},
+		{
+			Scenario: "image missing from file",
+			Content: md5Digest + "  other.qcow2\n" +
+				md5Digest + "  another.qcow2\n",
+			ImageURL: "http://example.com/image.qcow2",
+			Invalid:  true,
+		},
+		{
+			Scenario: "empty file",
+			Content:  "\n",
+			ImageURL: "http://example.com/image.qcow2",
+			Invalid:  true,
+		},
+		{
+			Scenario:     "wrong checksum type",
+			Content:      md5Digest + "\n",
+			ImageURL:     "http://example.com/image.qcow2",
+			ChecksumType: metal3v1alpha1.SHA256,
+			Invalid:      true,
+		},
+		{
+			Scenario: "not a checksum",
+			Content:  "<html>not found</html>\n",
+			ImageURL: "http://example.com/image.qcow2",
+			Invalid:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tc.Content)
+			}))
+			defer server.Close()
+
+			resolver := NewResolver(server.Client(), time.Minute)
+			digest, checksumType, err := resolver.Resolve(tc.ImageURL, server.URL+"/SHA256SUMS", tc.ChecksumType)
+
+			if tc.Invalid {
+				assert.Error(t, err)
+				assert.True(t, IsInvalid(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, digest)
+			assert.Equal(t, tc.ExpectedType, checksumType)
+		})
+	}
+}
+
+func TestResolveFetchFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(server.Client(), time.Minute)
+	_, _, err := resolver.Resolve("http://example.com/image.qcow2", server.URL+"/SHA256SUMS", "")
+
+	assert.Error(t, err)
+	assert.False(t, IsInvalid(err))
+}
+
+func TestResolveCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, md5Digest)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	resolver := NewResolver(server.Client(), time.Minute)
+	resolver.now = func() time.Time { return now }
+	resolve := func() {
+		_, _, err := resolver.Resolve("http://example.com/image.qcow2", server.URL+"/image.qcow2.md5sum", "")
+		assert.NoError(t, err)
+	}
+
+	resolve()
+	resolve()
+	assert.Equal(t, 1, requests, "the file should be fetched once while cached")
+
+	now = now.Add(2 * time.Minute)
+	resolve()
+	assert.Equal(t, 2, requests, "the file should be fetched again once expired")
+}
+
+func TestResolveCacheBounded(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		fmt.Fprint(w, md5Digest)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	resolver := NewResolver(server.Client(), time.Minute)
+	resolver.maxEntries = 2
+	resolver.now = func() time.Time { return now }
+	resolve := func(name string) {
+		_, _, err := resolver.Resolve("http://example.com/image.qcow2", server.URL+"/"+name, "")
+		assert.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+
+	resolve("a.md5sum")
+	resolve("b.md5sum")
+	resolve("c.md5sum")
+	assert.Len(t, resolver.cache, 2)
+
+	resolve("b.md5sum")
+	resolve("c.md5sum")
+	assert.Equal(t, 1, requests["/b.md5sum"], "recent files should stay cached")
+	assert.Equal(t, 1, requests["/c.md5sum"], "recent files should stay cached")
+
+	resolve("a.md5sum")
+	assert.Equal(t, 2, requests["/a.md5sum"], "the oldest file should be evicted")
+	assert.Len(t, resolver.cache, 2)
+
+	now = now.Add(2 * time.Minute)
+	resolve("d.md5sum")
+	assert.Len(t, resolver.cache, 1, "expired files should be dropped")
+}
@@ -0,0 +1,26 @@
+package hardware
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// NewFakeProfileReader returns a reader for tests holding the default
+// HardwareProfile resources and any extra ones given, to be passed to
+// SetProfileReader.
+func NewFakeProfileReader(extra ...*metal3v1alpha1.HardwareProfile) client.Reader {
+	s := runtime.NewScheme()
+	_ = metal3v1alpha1.AddToScheme(s)
+
+	profiles := []runtime.Object{}
+	for _, profile := range extra {
+		profiles = append(profiles, profile.DeepCopy())
+	}
+	for _, profile := range DefaultProfiles() {
+		profiles = append(profiles, profile.DeepCopy())
+	}
+	return fakeclient.NewFakeClientWithScheme(s, profiles...)
+}
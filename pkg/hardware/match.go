@@ -0,0 +1,165 @@
+package hardware
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// Match describes the profile chosen for a host and why.
+type Match struct {
+	// Name is the name of the profile.
+	Name string
+
+	// Score is the number of rules of the profile that matched.
+	Score int
+
+	// Reasons holds a description of each rule that matched.
+	Reasons []string
+}
+
+// Reason returns a human readable explanation of the match.
+func (m Match) Reason() string {
+	return strings.Join(m.Reasons, ", ")
+}
+
+// scoreProfile returns the number of match rules of the profile
+// satisfied by the hardware details, and a description of each of
+// them. It returns ok=false if the profile has no rules, or if any of
+// the rules is not satisfied, and an error if a pattern is not a valid
+// regular expression.
+func scoreProfile(rules *metal3v1alpha1.HardwareMatchRules, details *metal3v1alpha1.HardwareDetails) (match Match, ok bool, err error) {
+	if rules == nil || details == nil {
+		return match, false, nil
+	}
+
+	matched := func(format string, args ...interface{}) {
+		match.Score++
+		match.Reasons = append(match.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	matchPattern := func(field, pattern, value string) (bool, error) {
+		if pattern == "" {
+			return true, nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid %s pattern %q", field, pattern)
+		}
+		if !re.MatchString(value) {
+			return false, nil
+		}
+		matched("%s %q matches %q", field, value, pattern)
+		return true, nil
+	}
+
+	// Both patterns are checked before returning, so that an invalid
+	// one is reported even when the other does not match.
+	manufacturerOK, err := matchPattern("manufacturer", rules.Manufacturer, details.SystemVendor.Manufacturer)
+	if err != nil {
+		return match, false, err
+	}
+	productOK, err := matchPattern("product name", rules.ProductName, details.SystemVendor.ProductName)
+	if err != nil {
+		return match, false, err
+	}
+	if !manufacturerOK || !productOK {
+		return match, false, nil
+	}
+
+	if rules.MinRAMMebibytes != 0 {
+		if details.RAMMebibytes < rules.MinRAMMebibytes {
+			return match, false, nil
+		}
+		matched("%d MiB of RAM", details.RAMMebibytes)
+	}
+
+	if rules.CPUArch != "" {
+		if details.CPU.Arch != rules.CPUArch {
+			return match, false, nil
+		}
+		matched("CPU architecture %s", details.CPU.Arch)
+	}
+
+	if rules.MinCPUCount != 0 {
+		if details.CPU.Count < rules.MinCPUCount {
+			return match, false, nil
+		}
+		matched("%d CPUs", details.CPU.Count)
+	}
+
+	if rules.MinDiskCount != 0 || rules.MinDiskSizeBytes != 0 {
+		minCount := rules.MinDiskCount
+		if minCount == 0 {
+			minCount = 1
+		}
+		count := 0
+		for _, disk := range details.Storage {
+			if disk.SizeBytes >= rules.MinDiskSizeBytes {
+				count++
+			}
+		}
+		if count < minCount {
+			return match, false, nil
+		}
+		// The count and the size are separate rules for scoring, but
+		// are described together.
+		reason := fmt.Sprintf("%d disks", count)
+		if rules.MinDiskCount != 0 {
+			match.Score++
+		}
+		if rules.MinDiskSizeBytes != 0 {
+			match.Score++
+			reason = fmt.Sprintf("%d disks of at least %d bytes", count, rules.MinDiskSizeBytes)
+		}
+		match.Reasons = append(match.Reasons, reason)
+	}
+
+	return match, match.Score > 0, nil
+}
+
+// MatchProfile compares the hardware details of a host against the
+// match rules of all of the HardwareProfile resources and returns the
+// best match. It returns ok=false when no profile matches, and an error
+// when the rules of any profile are invalid.
+func MatchProfile(details *metal3v1alpha1.HardwareDetails) (best Match, ok bool, err error) {
+	if profileReader == nil {
+		return best, false, fmt.Errorf("No hardware profiles available")
+	}
+
+	profiles := &metal3v1alpha1.HardwareProfileList{}
+	if err := profileReader.List(context.TODO(), profiles); err != nil {
+		return best, false, errors.Wrap(err, "failed to list hardware profiles")
+	}
+
+	// Sort by name so the result does not depend on the order of the
+	// list when scores and priorities are equal.
+	sort.Slice(profiles.Items, func(i, j int) bool {
+		return profiles.Items[i].Name < profiles.Items[j].Name
+	})
+
+	bestPriority := 0
+	for _, profile := range profiles.Items {
+		match, matched, err := scoreProfile(profile.Spec.MatchRules, details)
+		if err != nil {
+			return Match{}, false, errors.Wrapf(err, "hardware profile %s", profile.Name)
+		}
+		if !matched {
+			continue
+		}
+		if ok && (match.Score < best.Score ||
+			(match.Score == best.Score && profile.Spec.Priority <= bestPriority)) {
+			continue
+		}
+		match.Name = profile.Name
+		best, bestPriority, ok = match, profile.Spec.Priority, true
+	}
+
+	return best, ok, nil
+}
@@ -0,0 +1,263 @@
+package hardware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func testDetails() *metal3v1alpha1.HardwareDetails {
+	return &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{
+			Manufacturer: "Dell Inc.",
+			ProductName:  "PowerEdge R640",
+		},
+		RAMMebibytes: 196608,
+		CPU: metal3v1alpha1.CPU{
+			Arch:  "x86_64",
+			Count: 40,
+		},
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "sda", SizeBytes: 480 * metal3v1alpha1.GigaByte},
+			{Name: "sdb", SizeBytes: 2 * metal3v1alpha1.TeraByte},
+			{Name: "sdc", SizeBytes: 2 * metal3v1alpha1.TeraByte},
+		},
+	}
+}
+
+func TestScoreProfile(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Rules    *metal3v1alpha1.HardwareMatchRules
+		Matched  bool
+		Score    int
+		Error    string
+	}{
+		{
+			Scenario: "no rules",
+			Rules:    nil,
+			Matched:  false,
+		},
+		{
+			Scenario: "empty rules",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{},
+			Matched:  false,
+		},
+		{
+			Scenario: "manufacturer",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{Manufacturer: "^Dell"},
+			Matched:  true,
+			Score:    1,
+		},
+		{
+			Scenario: "wrong manufacturer",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{Manufacturer: "^HPE"},
+			Matched:  false,
+		},
+		{
+			Scenario: "invalid pattern",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{Manufacturer: "("},
+			Error:    `invalid manufacturer pattern "("`,
+		},
+		{
+			Scenario: "invalid pattern after a mismatch",
+			Rules: &metal3v1alpha1.HardwareMatchRules{
+				Manufacturer: "^HPE",
+				ProductName:  "PowerEdge (R640",
+			},
+			Error: `invalid product name pattern "PowerEdge (R640"`,
+		},
+		{
+			Scenario: "vendor and product",
+			Rules: &metal3v1alpha1.HardwareMatchRules{
+				Manufacturer: "^Dell",
+				ProductName:  "R6[0-9]0$",
+			},
+			Matched: true,
+			Score:   2,
+		},
+		{
+			Scenario: "not enough RAM",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{MinRAMMebibytes: 262144},
+			Matched:  false,
+		},
+		{
+			Scenario: "CPU",
+			Rules: &metal3v1alpha1.HardwareMatchRules{
+				CPUArch:     "x86_64",
+				MinCPUCount: 32,
+			},
+			Matched: true,
+			Score:   2,
+		},
+		{
+			Scenario: "wrong CPU arch",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{CPUArch: "aarch64"},
+			Matched:  false,
+		},
+		{
+			Scenario: "disk count",
+			Rules:    &metal3v1alpha1.HardwareMatchRules{MinDiskCount: 3},
+			Matched:  true,
+			Score:    1,
+		},
+		{
+			Scenario: "large disks",
+			Rules: &metal3v1alpha1.HardwareMatchRules{
+				MinDiskCount:     2,
+				MinDiskSizeBytes: metal3v1alpha1.TeraByte,
+			},
+			Matched: true,
+			Score:   2,
+		},
+		{
+			Scenario: "not enough large disks",
+			Rules: &metal3v1alpha1.HardwareMatchRules{
+				MinDiskCount:     3,
+				MinDiskSizeBytes: metal3v1alpha1.TeraByte,
+			},
+			Matched: false,
+		},
+		{
+			Scenario: "one rule fails",
+			Rules: &metal3v1alpha1.HardwareMatchRules{
+				Manufacturer: "^Dell",
+				MinCPUCount:  64,
+			},
+			Matched: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			match, ok, err := scoreProfile(tc.Rules, testDetails())
+			if tc.Error != "" {
+				assert.False(t, ok)
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Matched, ok)
+			if ok {
+				assert.Equal(t, tc.Score, match.Score)
+				assert.NotEmpty(t, match.Reason())
+			}
+		})
+	}
+}
+
+func TestScoreProfileWithoutDetails(t *testing.T) {
+	_, ok, err := scoreProfile(&metal3v1alpha1.HardwareMatchRules{Manufacturer: ".*"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func newMatchProfile(name string, priority int, rules *metal3v1alpha1.HardwareMatchRules) runtime.Object {
+	return &metal3v1alpha1.HardwareProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: metal3v1alpha1.HardwareProfileSpec{
+			MatchRules: rules,
+			Priority:   priority,
+		},
+	}
+}
+
+func TestMatchProfile(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Profiles []runtime.Object
+		Expected string
+		Error    string
+	}{
+		{
+			Scenario: "no rules",
+			Profiles: []runtime.Object{
+				newMatchProfile("unknown", 0, nil),
+			},
+			Expected: "",
+		},
+		{
+			Scenario: "most specific",
+			Profiles: []runtime.Object{
+				newMatchProfile("dell", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer: "^Dell",
+				}),
+				newMatchProfile("dell-storage", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer:     "^Dell",
+					MinDiskCount:     2,
+					MinDiskSizeBytes: metal3v1alpha1.TeraByte,
+				}),
+				newMatchProfile("hpe", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer:     "^HPE",
+					MinDiskCount:     2,
+					MinDiskSizeBytes: metal3v1alpha1.TeraByte,
+				}),
+			},
+			Expected: "dell-storage",
+		},
+		{
+			Scenario: "priority",
+			Profiles: []runtime.Object{
+				newMatchProfile("a", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer: "^Dell",
+				}),
+				newMatchProfile("b", 10, &metal3v1alpha1.HardwareMatchRules{
+					CPUArch: "x86_64",
+				}),
+			},
+			Expected: "b",
+		},
+		{
+			Scenario: "name breaks ties",
+			Profiles: []runtime.Object{
+				newMatchProfile("b", 0, &metal3v1alpha1.HardwareMatchRules{
+					CPUArch: "x86_64",
+				}),
+				newMatchProfile("a", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer: "^Dell",
+				}),
+			},
+			Expected: "a",
+		},
+		{
+			Scenario: "invalid pattern",
+			Profiles: []runtime.Object{
+				newMatchProfile("a", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer: "^Dell",
+				}),
+				newMatchProfile("b", 0, &metal3v1alpha1.HardwareMatchRules{
+					Manufacturer: "[Dell",
+				}),
+			},
+			Error: "hardware profile b: invalid manufacturer pattern",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			SetProfileReader(fakeclient.NewFakeClientWithScheme(newScheme(), tc.Profiles...))
+			defer SetProfileReader(nil)
+
+			match, ok, err := MatchProfile(testDetails())
+			if tc.Error != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.Expected != "", ok)
+			assert.Equal(t, tc.Expected, match.Name)
+		})
+	}
+}
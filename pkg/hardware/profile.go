@@ -1,8 +1,16 @@
 package hardware
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/pkg/errors"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 )
 
@@ -31,65 +39,86 @@ type Profile struct {
 	CPUArch string
 }
 
-var profiles = make(map[string]Profile)
+// profileReader is used to look up HardwareProfile resources. In the
+// operator it is the informer cache of the manager.
+var profileReader client.Reader
 
-func init() {
-	profiles[DefaultProfileName] = Profile{
-		Name: DefaultProfileName,
-		RootDeviceHints: metal3v1alpha1.RootDeviceHints{
-			DeviceName: "/dev/sda",
-		},
-		RootGB:  10,
-		LocalGB: 50,
-		CPUArch: "x86_64",
-	}
+// SetProfileReader sets the reader used by GetProfile to look up
+// HardwareProfile resources.
+func SetProfileReader(reader client.Reader) {
+	profileReader = reader
+}
 
-	profiles["libvirt"] = Profile{
-		Name: "libvirt",
-		RootDeviceHints: metal3v1alpha1.RootDeviceHints{
-			DeviceName: "/dev/vda",
+func newDefaultProfile(name string, hints metal3v1alpha1.RootDeviceHints) metal3v1alpha1.HardwareProfile {
+	return metal3v1alpha1.HardwareProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
 		},
-		RootGB:  10,
-		LocalGB: 50,
-		CPUArch: "x86_64",
-	}
-
-	profiles["dell"] = Profile{
-		Name: "dell",
-		RootDeviceHints: metal3v1alpha1.RootDeviceHints{
-			HCTL: "0:0:0:0",
+		Spec: metal3v1alpha1.HardwareProfileSpec{
+			RootDeviceHints: hints,
+			RootGB:          10,
+			LocalGB:         50,
+			CPUArch:         "x86_64",
 		},
-		RootGB:  10,
-		LocalGB: 50,
-		CPUArch: "x86_64",
 	}
+}
 
-	profiles["dell-raid"] = Profile{
-		Name: "dell-raid",
-		RootDeviceHints: metal3v1alpha1.RootDeviceHints{
+// DefaultProfiles returns the HardwareProfile resources the operator
+// creates when they do not already exist.
+func DefaultProfiles() []metal3v1alpha1.HardwareProfile {
+	return []metal3v1alpha1.HardwareProfile{
+		newDefaultProfile(DefaultProfileName, metal3v1alpha1.RootDeviceHints{
+			DeviceName: "/dev/sda",
+		}),
+		newDefaultProfile("libvirt", metal3v1alpha1.RootDeviceHints{
+			DeviceName: "/dev/vda",
+		}),
+		newDefaultProfile("dell", metal3v1alpha1.RootDeviceHints{
+			HCTL: "0:0:0:0",
+		}),
+		newDefaultProfile("dell-raid", metal3v1alpha1.RootDeviceHints{
 			HCTL: "0:2:0:0",
-		},
-		RootGB:  10,
-		LocalGB: 50,
-		CPUArch: "x86_64",
+		}),
+		newDefaultProfile("openstack", metal3v1alpha1.RootDeviceHints{
+			DeviceName: "/dev/vdb",
+		}),
 	}
+}
 
-	profiles["openstack"] = Profile{
-		Name: "openstack",
-		RootDeviceHints: metal3v1alpha1.RootDeviceHints{
-			DeviceName: "/dev/vdb",
-		},
-		RootGB:  10,
-		LocalGB: 50,
-		CPUArch: "x86_64",
+// EnsureDefaultProfiles creates the default HardwareProfile
+// resources that are missing. Existing resources are left alone, so
+// the defaults can be edited.
+func EnsureDefaultProfiles(ctx context.Context, c client.Client) error {
+	for _, profile := range DefaultProfiles() {
+		profile := profile
+		err := c.Create(ctx, &profile)
+		if err != nil && !k8serrors.IsAlreadyExists(err) {
+			return errors.Wrap(err, fmt.Sprintf("failed to create hardware profile %q", profile.Name))
+		}
 	}
+	return nil
 }
 
 // GetProfile returns the named profile
 func GetProfile(name string) (Profile, error) {
-	profile, ok := profiles[name]
-	if !ok {
-		return Profile{}, fmt.Errorf("No hardware profile named %q", name)
+	if profileReader == nil {
+		return Profile{}, fmt.Errorf("No hardware profiles available")
 	}
-	return profile, nil
+
+	resource := &metal3v1alpha1.HardwareProfile{}
+	err := profileReader.Get(context.TODO(), types.NamespacedName{Name: name}, resource)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return Profile{}, fmt.Errorf("No hardware profile named %q", name)
+		}
+		return Profile{}, errors.Wrap(err, fmt.Sprintf("failed to look up hardware profile %q", name))
+	}
+
+	return Profile{
+		Name:            resource.Name,
+		RootDeviceHints: resource.Spec.RootDeviceHints,
+		RootGB:          resource.Spec.RootGB,
+		LocalGB:         resource.Spec.LocalGB,
+		CPUArch:         resource.Spec.CPUArch,
+	}, nil
 }
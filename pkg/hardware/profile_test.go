@@ -0,0 +1,80 @@
+package hardware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func newScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	metal3v1alpha1.AddToScheme(s)
+	return s
+}
+
+func TestGetProfile(t *testing.T) {
+	custom := &metal3v1alpha1.HardwareProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom"},
+		Spec: metal3v1alpha1.HardwareProfileSpec{
+			RootDeviceHints: metal3v1alpha1.RootDeviceHints{HCTL: "1:0:0:0"},
+			RootGB:          20,
+			LocalGB:         100,
+			CPUArch:         "aarch64",
+		},
+	}
+	SetProfileReader(fakeclient.NewFakeClientWithScheme(newScheme(), custom))
+	defer SetProfileReader(nil)
+
+	profile, err := GetProfile("custom")
+	if assert.NoError(t, err) {
+		assert.Equal(t, Profile{
+			Name:            "custom",
+			RootDeviceHints: metal3v1alpha1.RootDeviceHints{HCTL: "1:0:0:0"},
+			RootGB:          20,
+			LocalGB:         100,
+			CPUArch:         "aarch64",
+		}, profile)
+	}
+
+	_, err = GetProfile("missing")
+	assert.EqualError(t, err, `No hardware profile named "missing"`)
+}
+
+func TestGetProfileWithoutReader(t *testing.T) {
+	SetProfileReader(nil)
+	_, err := GetProfile(DefaultProfileName)
+	assert.Error(t, err)
+}
+
+func TestEnsureDefaultProfiles(t *testing.T) {
+	edited := DefaultProfiles()[0]
+	edited.Spec.RootGB = 42
+	c := fakeclient.NewFakeClientWithScheme(newScheme(), &edited)
+
+	if err := EnsureDefaultProfiles(context.TODO(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles := &metal3v1alpha1.HardwareProfileList{}
+	if err := c.List(context.TODO(), profiles); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, profiles.Items, len(DefaultProfiles()))
+
+	existing := &metal3v1alpha1.HardwareProfile{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: edited.Name}, existing); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 42, existing.Spec.RootGB)
+
+	// Running again is a no-op.
+	assert.NoError(t, EnsureDefaultProfiles(context.TODO(), c))
+}
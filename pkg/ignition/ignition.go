@@ -0,0 +1,117 @@
+// Package ignition recognises and validates Ignition configs, and adds
+// the files the operator derives from the host to them.
+package ignition
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// supportedVersions are the minor versions of the Ignition spec that
+// configs can be written for. Version 2.2 is the oldest supported by
+// CoreOS images booting from a config drive.
+var supportedVersions = map[string]bool{
+	"2.2": true,
+	"2.3": true,
+	"3.0": true,
+	"3.1": true,
+	"3.2": true,
+	"3.3": true,
+	"3.4": true,
+}
+
+// File is a file to add to the root filesystem.
+type File struct {
+	Path     string
+	Mode     int
+	Contents string
+}
+
+// IsConfig returns whether data looks like an Ignition config, that is
+// a JSON object with an ignition version.
+func IsConfig(data []byte) bool {
+	var config struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	return config.Ignition.Version != ""
+}
+
+// Merge validates an Ignition config and adds files to it, in the
+// format of its spec version. Files the config already has are left
+// untouched.
+func Merge(data []byte, files []File) ([]byte, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, "not a JSON object")
+	}
+
+	ignition, _ := config["ignition"].(map[string]interface{})
+	version, _ := ignition["version"].(string)
+	if version == "" {
+		return nil, errors.New("no ignition version")
+	}
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 || !supportedVersions[parts[0]+"."+parts[1]] {
+		return nil, errors.Errorf("ignition version %q is not supported", version)
+	}
+	spec2 := parts[0] == "2"
+
+	storage, ok := config["storage"].(map[string]interface{})
+	if !ok {
+		if _, present := config["storage"]; present {
+			return nil, errors.New("storage is not an object")
+		}
+		storage = map[string]interface{}{}
+		config["storage"] = storage
+	}
+	existing, ok := storage["files"].([]interface{})
+	if !ok && storage["files"] != nil {
+		return nil, errors.New("storage.files is not a list")
+	}
+
+	paths := map[string]bool{}
+	for _, f := range existing {
+		file, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("storage.files has an entry that is not an object")
+		}
+		path, _ := file["path"].(string)
+		paths[path] = true
+	}
+	for _, file := range files {
+		if paths[file.Path] {
+			continue
+		}
+		entry := map[string]interface{}{
+			"path": file.Path,
+			"mode": file.Mode,
+			"contents": map[string]interface{}{
+				"source": dataURL(file.Contents),
+			},
+		}
+		if spec2 {
+			entry["filesystem"] = "root"
+		} else {
+			entry["overwrite"] = true
+		}
+		existing = append(existing, entry)
+	}
+	if len(existing) != 0 {
+		storage["files"] = existing
+	}
+
+	return json.Marshal(config)
+}
+
+func dataURL(contents string) string {
+	return fmt.Sprintf("data:;base64,%s", base64.StdEncoding.EncodeToString([]byte(contents)))
+}
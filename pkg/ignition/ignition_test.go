@@ -0,0 +1,108 @@
+package ignition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConfig(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Data     string
+		Expected bool
+	}{
+		{Scenario: "ignition", Data: `{"ignition": {"version": "3.2.0"}}`, Expected: true},
+		{Scenario: "cloud-config", Data: "#cloud-config\nhostname: test\n"},
+		{Scenario: "other JSON", Data: `{"ignition": "yes"}`},
+		{Scenario: "no version", Data: `{"ignition": {}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, IsConfig([]byte(tc.Data)))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	files := []File{
+		{Path: "/etc/hostname", Mode: 0644, Contents: "host-0"},
+	}
+
+	testCases := []struct {
+		Scenario string
+		Config   string
+		Expected string
+		Error    string
+	}{
+		{
+			Scenario: "spec 3",
+			Config:   `{"ignition": {"version": "3.2.0"}, "passwd": {"users": [{"name": "core"}]}}`,
+			Expected: `{
+				"ignition": {"version": "3.2.0"},
+				"passwd": {"users": [{"name": "core"}]},
+				"storage": {"files": [{"path": "/etc/hostname", "mode": 420, "overwrite": true,
+					"contents": {"source": "data:;base64,aG9zdC0w"}}]}
+			}`,
+		},
+		{
+			Scenario: "spec 2",
+			Config:   `{"ignition": {"version": "2.2.0"}, "storage": {"files": [{"path": "/etc/motd"}]}}`,
+			Expected: `{
+				"ignition": {"version": "2.2.0"},
+				"storage": {"files": [
+					{"path": "/etc/motd"},
+					{"path": "/etc/hostname", "mode": 420, "filesystem": "root",
+						"contents": {"source": "data:;base64,aG9zdC0w"}}
+				]}
+			}`,
+		},
+		{
+			Scenario: "file already in config",
+			Config:   `{"ignition": {"version": "3.0.0"}, "storage": {"files": [{"path": "/etc/hostname"}]}}`,
+			Expected: `{"ignition": {"version": "3.0.0"}, "storage": {"files": [{"path": "/etc/hostname"}]}}`,
+		},
+		{
+			Scenario: "unsupported version",
+			Config:   `{"ignition": {"version": "2.1.0"}}`,
+			Error:    `ignition version "2.1.0" is not supported`,
+		},
+		{
+			Scenario: "malformed version",
+			Config:   `{"ignition": {"version": "3"}}`,
+			Error:    `ignition version "3" is not supported`,
+		},
+		{
+			Scenario: "no version",
+			Config:   `{"ignition": {}}`,
+			Error:    "no ignition version",
+		},
+		{
+			Scenario: "invalid JSON",
+			Config:   `{"ignition": `,
+			Error:    "not a JSON object",
+		},
+		{
+			Scenario: "invalid files",
+			Config:   `{"ignition": {"version": "3.1.0"}, "storage": {"files": {}}}`,
+			Error:    "storage.files is not a list",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			merged, err := Merge([]byte(tc.Config), files)
+
+			if tc.Error != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, tc.Expected, string(merged))
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+// Package nmstate converts network configuration written for nmstate
+// to the OpenStack network_data.json format written to config drives.
+package nmstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// The subset of the nmstate desired state that can be expressed in
+// network_data.json. Any other field is rejected when decoding.
+
+type state struct {
+	Interfaces  []iface     `json:"interfaces"`
+	Routes      routes      `json:"routes"`
+	DNSResolver dnsResolver `json:"dns-resolver"`
+}
+
+type iface struct {
+	Name            string           `json:"name"`
+	Type            string           `json:"type"`
+	State           string           `json:"state"`
+	MACAddress      string           `json:"mac-address"`
+	MTU             int              `json:"mtu"`
+	IPv4            *ipConfig        `json:"ipv4"`
+	IPv6            *ipConfig        `json:"ipv6"`
+	LinkAggregation *linkAggregation `json:"link-aggregation"`
+	VLAN            *vlan            `json:"vlan"`
+}
+
+type ipConfig struct {
+	Enabled  bool      `json:"enabled"`
+	DHCP     bool      `json:"dhcp"`
+	Autoconf bool      `json:"autoconf"`
+	Address  []address `json:"address"`
+}
+
+type address struct {
+	IP           string `json:"ip"`
+	PrefixLength int    `json:"prefix-length"`
+}
+
+type linkAggregation struct {
+	Mode string   `json:"mode"`
+	Port []string `json:"port"`
+	// Slaves is the name of Port before nmstate 2.0.
+	Slaves  []string               `json:"slaves"`
+	Options map[string]interface{} `json:"options"`
+}
+
+type vlan struct {
+	BaseIface string `json:"base-iface"`
+	ID        int    `json:"id"`
+}
+
+type routes struct {
+	Config []route `json:"config"`
+}
+
+type route struct {
+	Destination      string `json:"destination"`
+	NextHopAddress   string `json:"next-hop-address"`
+	NextHopInterface string `json:"next-hop-interface"`
+}
+
+type dnsResolver struct {
+	Config dnsConfig `json:"config"`
+}
+
+type dnsConfig struct {
+	Server []string `json:"server"`
+	Search []string `json:"search"`
+}
+
+// The network_data.json format, see
+// https://docs.openstack.org/nova/latest/user/metadata.html
+
+type networkData struct {
+	Links    []link    `json:"links"`
+	Networks []network `json:"networks"`
+	Services []service `json:"services,omitempty"`
+}
+
+type link struct {
+	ID                 string      `json:"id"`
+	Type               string      `json:"type"`
+	MACAddress         string      `json:"ethernet_mac_address,omitempty"`
+	MTU                int         `json:"mtu,omitempty"`
+	BondMode           string      `json:"bond_mode,omitempty"`
+	BondLinks          []string    `json:"bond_links,omitempty"`
+	BondMIIMon         interface{} `json:"bond_miimon,omitempty"`
+	BondXmitHashPolicy interface{} `json:"bond_xmit_hash_policy,omitempty"`
+	VLANID             int         `json:"vlan_id,omitempty"`
+	VLANLink           string      `json:"vlan_link,omitempty"`
+	VLANMACAddress     string      `json:"vlan_mac_address,omitempty"`
+}
+
+type network struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Link      string         `json:"link"`
+	IPAddress string         `json:"ip_address,omitempty"`
+	Netmask   string         `json:"netmask,omitempty"`
+	Routes    []networkRoute `json:"routes,omitempty"`
+}
+
+type networkRoute struct {
+	Network string `json:"network"`
+	Netmask string `json:"netmask"`
+	Gateway string `json:"gateway"`
+}
+
+type service struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+// bondOptions maps the bond options of nmstate to the fields of
+// network_data.json links.
+var bondOptions = map[string]func(*link, interface{}){
+	"miimon":           func(l *link, v interface{}) { l.BondMIIMon = v },
+	"xmit_hash_policy": func(l *link, v interface{}) { l.BondXmitHashPolicy = v },
+}
+
+// ToNetworkData converts an nmstate document, in YAML or JSON, to
+// network_data.json. Ethernet interfaces without a mac-address take
+// the MAC address found for their name in macs, since cloud-init
+// matches links to interfaces by MAC address. Constructs that cannot
+// be expressed in network_data.json are reported as errors.
+func ToNetworkData(doc []byte, macs map[string]string) ([]byte, error) {
+	var desired state
+	if err := yaml.UnmarshalStrict(doc, &desired); err != nil {
+		return nil, errors.Wrap(err, "unsupported nmstate document")
+	}
+
+	c := converter{macs: macs, links: map[string]link{}}
+	data, err := c.convert(desired)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+type converter struct {
+	macs  map[string]string
+	links map[string]link
+	data  networkData
+}
+
+func (c *converter) convert(desired state) (networkData, error) {
+	for _, iface := range desired.Interfaces {
+		if iface.Name == "" {
+			return networkData{}, errors.New("interface without a name")
+		}
+		if iface.State != "" && iface.State != "up" {
+			return networkData{}, errors.Errorf("interface %s: state %q is not supported", iface.Name, iface.State)
+		}
+		if _, exists := c.links[iface.Name]; exists {
+			return networkData{}, errors.Errorf("interface %s is listed twice", iface.Name)
+		}
+		// Bonds and VLANs refer to interfaces listed before them.
+		l, err := c.link(iface)
+		if err != nil {
+			return networkData{}, errors.Wrapf(err, "interface %s", iface.Name)
+		}
+		c.data.Links = append(c.data.Links, *l)
+		c.links[iface.Name] = *l
+
+		if err := c.addNetworks(iface.Name, iface.IPv4, false); err != nil {
+			return networkData{}, errors.Wrapf(err, "interface %s", iface.Name)
+		}
+		if err := c.addNetworks(iface.Name, iface.IPv6, true); err != nil {
+			return networkData{}, errors.Wrapf(err, "interface %s", iface.Name)
+		}
+	}
+
+	for _, r := range desired.Routes.Config {
+		if err := c.addRoute(r); err != nil {
+			return networkData{}, errors.Wrapf(err, "route to %s", r.Destination)
+		}
+	}
+
+	if len(desired.DNSResolver.Config.Search) != 0 {
+		return networkData{}, errors.New("DNS search domains are not supported")
+	}
+	for _, server := range desired.DNSResolver.Config.Server {
+		if net.ParseIP(server) == nil {
+			return networkData{}, errors.Errorf("DNS server %q is not an IP address", server)
+		}
+		c.data.Services = append(c.data.Services, service{Type: "dns", Address: server})
+	}
+
+	if c.data.Links == nil {
+		c.data.Links = []link{}
+	}
+	if c.data.Networks == nil {
+		c.data.Networks = []network{}
+	}
+	return c.data, nil
+}
+
+func (c *converter) link(iface iface) (*link, error) {
+	l := &link{
+		ID:         iface.Name,
+		MACAddress: strings.ToLower(iface.MACAddress),
+		MTU:        iface.MTU,
+	}
+
+	switch iface.Type {
+	case "ethernet":
+		if iface.LinkAggregation != nil || iface.VLAN != nil {
+			return nil, errors.New("ethernet interfaces cannot have bond or VLAN settings")
+		}
+		l.Type = "phy"
+		if l.MACAddress == "" {
+			l.MACAddress = strings.ToLower(c.macs[iface.Name])
+		}
+		if l.MACAddress == "" {
+			return nil, errors.New("no mac-address given and none found by inspection")
+		}
+
+	case "bond":
+		bond := iface.LinkAggregation
+		if bond == nil || iface.VLAN != nil {
+			return nil, errors.New("bonds need link-aggregation settings only")
+		}
+		l.Type = "bond"
+		l.BondMode = bond.Mode
+		if l.BondMode == "" {
+			return nil, errors.New("bond without a mode")
+		}
+		ports := bond.Port
+		if len(ports) == 0 {
+			ports = bond.Slaves
+		}
+		if len(ports) == 0 {
+			return nil, errors.New("bond without ports")
+		}
+		for _, port := range ports {
+			portLink, ok := c.links[port]
+			if !ok || portLink.Type != "phy" {
+				return nil, errors.Errorf("bond port %s is not an ethernet interface listed before the bond", port)
+			}
+			if l.MACAddress == "" {
+				l.MACAddress = portLink.MACAddress
+			}
+		}
+		l.BondLinks = ports
+		for name, value := range bond.Options {
+			set, ok := bondOptions[name]
+			if !ok {
+				return nil, errors.Errorf("bond option %s is not supported", name)
+			}
+			set(l, value)
+		}
+
+	case "vlan":
+		if iface.VLAN == nil || iface.LinkAggregation != nil {
+			return nil, errors.New("VLANs need vlan settings only")
+		}
+		base, ok := c.links[iface.VLAN.BaseIface]
+		if !ok {
+			return nil, errors.Errorf("VLAN base interface %s is not listed before the VLAN", iface.VLAN.BaseIface)
+		}
+		l.Type = "vlan"
+		l.VLANID = iface.VLAN.ID
+		l.VLANLink = base.ID
+		l.VLANMACAddress = l.MACAddress
+		if l.VLANMACAddress == "" {
+			l.VLANMACAddress = base.MACAddress
+		}
+		l.MACAddress = ""
+
+	default:
+		return nil, errors.Errorf("interface type %q is not supported", iface.Type)
+	}
+	return l, nil
+}
+
+func (c *converter) addNetworks(linkID string, config *ipConfig, ipv6 bool) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	family := "ipv4"
+	if ipv6 {
+		family = "ipv6"
+	}
+	add := func(n network) {
+		n.ID = fmt.Sprintf("network%d", len(c.data.Networks))
+		n.Link = linkID
+		c.data.Networks = append(c.data.Networks, n)
+	}
+
+	if config.DHCP {
+		add(network{Type: family + "_dhcp"})
+	}
+	if config.Autoconf {
+		if !ipv6 {
+			return errors.New("autoconf is only supported for IPv6")
+		}
+		add(network{Type: "ipv6_slaac"})
+	}
+	for _, addr := range config.Address {
+		ip := net.ParseIP(addr.IP)
+		if ip == nil || (ip.To4() == nil) != ipv6 {
+			return errors.Errorf("%q is not an %s address", addr.IP, family)
+		}
+		if net.CIDRMask(addr.PrefixLength, 8*len(ip.To16())) == nil ||
+			(!ipv6 && addr.PrefixLength > 32) {
+			return errors.Errorf("%d is not a valid prefix-length for %s", addr.PrefixLength, addr.IP)
+		}
+		add(network{
+			Type:      family,
+			IPAddress: addr.IP,
+			Netmask:   netmask(addr.PrefixLength, ipv6),
+		})
+	}
+	return nil
+}
+
+func (c *converter) addRoute(r route) error {
+	_, destination, err := net.ParseCIDR(r.Destination)
+	if err != nil {
+		return errors.Errorf("destination %q is not a CIDR", r.Destination)
+	}
+	ipv6 := destination.IP.To4() == nil
+	gateway := net.ParseIP(r.NextHopAddress)
+	if gateway == nil || (gateway.To4() == nil) != ipv6 {
+		return errors.Errorf("next-hop-address %q is not in the family of the destination", r.NextHopAddress)
+	}
+
+	// network_data.json attaches routes to the static network they go
+	// through.
+	family := "ipv4"
+	if ipv6 {
+		family = "ipv6"
+	}
+	for i := range c.data.Networks {
+		n := &c.data.Networks[i]
+		if n.Link != r.NextHopInterface || n.Type != family {
+			continue
+		}
+		ones, _ := destination.Mask.Size()
+		n.Routes = append(n.Routes, networkRoute{
+			Network: destination.IP.String(),
+			Netmask: netmask(ones, ipv6),
+			Gateway: gateway.String(),
+		})
+		return nil
+	}
+	return errors.Errorf("next-hop-interface %q has no static %s address", r.NextHopInterface, family)
+}
+
+// netmask returns the netmask of a prefix length in the notation of
+// its address family.
+func netmask(prefixLength int, ipv6 bool) string {
+	if ipv6 {
+		return net.IP(net.CIDRMask(prefixLength, 128)).String()
+	}
+	return net.IP(net.CIDRMask(prefixLength, 32)).String()
+}
@@ -0,0 +1,226 @@
+package nmstate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToNetworkData(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		NMState  string
+		MACs     map[string]string
+		Expected string
+		Error    string
+	}{
+		{
+			Scenario: "static address",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+  state: up
+  mac-address: 00:11:22:33:44:AA
+  mtu: 9000
+  ipv4:
+    enabled: true
+    address:
+    - ip: 192.168.1.10
+      prefix-length: 24
+routes:
+  config:
+  - destination: 0.0.0.0/0
+    next-hop-address: 192.168.1.1
+    next-hop-interface: eth0
+dns-resolver:
+  config:
+    server:
+    - 192.168.1.2
+`,
+			Expected: `{
+				"links": [{"id": "eth0", "type": "phy", "ethernet_mac_address": "00:11:22:33:44:aa", "mtu": 9000}],
+				"networks": [{"id": "network0", "type": "ipv4", "link": "eth0",
+					"ip_address": "192.168.1.10", "netmask": "255.255.255.0",
+					"routes": [{"network": "0.0.0.0", "netmask": "0.0.0.0", "gateway": "192.168.1.1"}]}],
+				"services": [{"type": "dns", "address": "192.168.1.2"}]
+			}`,
+		},
+		{
+			Scenario: "dhcp with inspected MAC",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+  ipv4:
+    enabled: true
+    dhcp: true
+  ipv6:
+    enabled: true
+    autoconf: true
+    address:
+    - ip: 2001:db8::10
+      prefix-length: 64
+`,
+			MACs: map[string]string{"eth0": "00:11:22:33:44:aa"},
+			Expected: `{
+				"links": [{"id": "eth0", "type": "phy", "ethernet_mac_address": "00:11:22:33:44:aa"}],
+				"networks": [
+					{"id": "network0", "type": "ipv4_dhcp", "link": "eth0"},
+					{"id": "network1", "type": "ipv6_slaac", "link": "eth0"},
+					{"id": "network2", "type": "ipv6", "link": "eth0",
+						"ip_address": "2001:db8::10", "netmask": "ffff:ffff:ffff:ffff::"}
+				]
+			}`,
+		},
+		{
+			Scenario: "VLAN on a bond",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+  mac-address: 00:11:22:33:44:aa
+- name: eth1
+  type: ethernet
+  mac-address: 00:11:22:33:44:bb
+- name: bond0
+  type: bond
+  link-aggregation:
+    mode: 802.3ad
+    port: [eth0, eth1]
+    options:
+      miimon: 100
+- name: bond0.10
+  type: vlan
+  vlan:
+    base-iface: bond0
+    id: 10
+  ipv4:
+    enabled: true
+    dhcp: true
+`,
+			Expected: `{
+				"links": [
+					{"id": "eth0", "type": "phy", "ethernet_mac_address": "00:11:22:33:44:aa"},
+					{"id": "eth1", "type": "phy", "ethernet_mac_address": "00:11:22:33:44:bb"},
+					{"id": "bond0", "type": "bond", "ethernet_mac_address": "00:11:22:33:44:aa",
+						"bond_mode": "802.3ad", "bond_links": ["eth0", "eth1"], "bond_miimon": 100},
+					{"id": "bond0.10", "type": "vlan", "vlan_id": 10, "vlan_link": "bond0",
+						"vlan_mac_address": "00:11:22:33:44:aa"}
+				],
+				"networks": [{"id": "network0", "type": "ipv4_dhcp", "link": "bond0.10"}]
+			}`,
+		},
+		{
+			Scenario: "unknown field",
+			NMState: `
+interfaces:
+- name: br0
+  type: linux-bridge
+  bridge:
+    port: []
+`,
+			Error: `unknown field "bridge"`,
+		},
+		{
+			Scenario: "unsupported interface type",
+			NMState: `
+interfaces:
+- name: br0
+  type: linux-bridge
+`,
+			Error: `interface br0: interface type "linux-bridge" is not supported`,
+		},
+		{
+			Scenario: "no MAC address",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+`,
+			Error: "interface eth0: no mac-address given and none found by inspection",
+		},
+		{
+			Scenario: "absent interface",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+  state: absent
+`,
+			Error: `interface eth0: state "absent" is not supported`,
+		},
+		{
+			Scenario: "bond of an unknown port",
+			NMState: `
+interfaces:
+- name: bond0
+  type: bond
+  link-aggregation:
+    mode: active-backup
+    port: [eth0]
+`,
+			Error: "interface bond0: bond port eth0 is not an ethernet interface listed before the bond",
+		},
+		{
+			Scenario: "unsupported bond option",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+  mac-address: 00:11:22:33:44:aa
+- name: bond0
+  type: bond
+  link-aggregation:
+    mode: active-backup
+    port: [eth0]
+    options:
+      primary: eth0
+`,
+			Error: "interface bond0: bond option primary is not supported",
+		},
+		{
+			Scenario: "route through DHCP",
+			NMState: `
+interfaces:
+- name: eth0
+  type: ethernet
+  mac-address: 00:11:22:33:44:aa
+  ipv4:
+    enabled: true
+    dhcp: true
+routes:
+  config:
+  - destination: 10.0.0.0/8
+    next-hop-address: 192.168.1.1
+    next-hop-interface: eth0
+`,
+			Error: `route to 10.0.0.0/8: next-hop-interface "eth0" has no static ipv4 address`,
+		},
+		{
+			Scenario: "search domains",
+			NMState: `
+dns-resolver:
+  config:
+    search: [example.com]
+`,
+			Error: "DNS search domains are not supported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := ToNetworkData([]byte(tc.NMState), tc.MACs)
+
+			if tc.Error != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.Error)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, tc.Expected, string(actual))
+			}
+		})
+	}
+}
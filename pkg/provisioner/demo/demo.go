@@ -98,11 +98,10 @@ func (p *demoProvisioner) ValidateManagementAccess(credentialsChanged bool) (res
 	return result, nil
 }
 
-// InspectHardware updates the HardwareDetails field of the host with
-// details of devices discovered on the hardware. It may be called
-// multiple times, and should return true for its dirty flag until the
-// inspection is completed.
-func (p *demoProvisioner) InspectHardware() (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
+// InspectHardware returns the details of devices discovered on the
+// hardware. It may be called multiple times, and should return true
+// for its dirty flag until the inspection is completed.
+func (p *demoProvisioner) InspectHardware(refresh bool) (result provisioner.Result, started bool, hardwareData *metal3v1alpha1.HardwareDataSpec, err error) {
 	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus())
 
 	hostName := p.host.ObjectMeta.Name
@@ -115,13 +114,20 @@ func (p *demoProvisioner) InspectHardware() (result provisioner.Result, details
 		return
 	}
 
+	if refresh {
+		p.publisher("InspectionStarted", "Hardware inspection started")
+		result.Dirty = true
+		result.RequeueAfter = time.Second * 5
+		return result, true, nil, nil
+	}
+
 	// The inspection is ongoing. We'll need to check the demo
 	// status for the server here until it is ready for us to get the
 	// inspection details. Simulate that for now by creating the
 	// hardware details struct as part of a second pass.
-	if p.host.Status.HardwareDetails == nil {
+	if p.host.Status.HardwareSummary == nil {
 		p.log.Info("continuing inspection by setting details")
-		details =
+		details :=
 			&metal3v1alpha1.HardwareDetails{
 				RAMMebibytes: 128 * 1024,
 				NIC: []metal3v1alpha1.NIC{
@@ -164,6 +170,7 @@ func (p *demoProvisioner) InspectHardware() (result provisioner.Result, details
 					Count:          1,
 				},
 			}
+		hardwareData = &metal3v1alpha1.HardwareDataSpec{HardwareDetails: details}
 		p.publisher("InspectionComplete", "Hardware inspection completed")
 		p.host.SetOperationalStatus(metal3v1alpha1.OperationalStatusOK)
 	}
@@ -182,6 +189,20 @@ func (p *demoProvisioner) UpdateHardwareState() (result provisioner.Result, err
 	return result, nil
 }
 
+// UpdateFirmware installs the firmware images listed in the host
+// spec.
+func (p *demoProvisioner) UpdateFirmware(outdated bool) (result provisioner.Result, started bool, err error) {
+	p.log.Info("updating firmware")
+	return
+}
+
+// Prepare applies the settings that must be in place before the
+// host is ready to be provisioned.
+func (p *demoProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
+	p.log.Info("preparing host")
+	return
+}
+
 // Adopt allows an externally-provisioned server to be adopted.
 func (p *demoProvisioner) Adopt() (result provisioner.Result, err error) {
 	p.log.Info("adopting host")
@@ -235,10 +256,10 @@ func (p *demoProvisioner) Deprovision() (result provisioner.Result, err error) {
 	// // necessary once we really have Demo doing the deprovisioning
 	// // and we can monitor it's status.
 
-	// if p.host.Status.HardwareDetails != nil {
+	// if p.host.Status.HardwareSummary != nil {
 	// 	p.publisher("DeprovisionStarted", "Image deprovisioning started")
 	// 	p.log.Info("clearing hardware details")
-	// 	p.host.Status.HardwareDetails = nil
+	// 	p.host.Status.HardwareSummary = nil
 	// 	result.Dirty = true
 	// 	return result, nil
 	// }
@@ -262,6 +283,14 @@ func (p *demoProvisioner) Delete() (result provisioner.Result, err error) {
 	return result, nil
 }
 
+// Detach removes the host from the provisioning system without
+// deprovisioning it. It may be called multiple times, and should
+// return true for its dirty flag until the host is removed.
+func (p *demoProvisioner) Detach() (result provisioner.Result, err error) {
+	p.log.Info("detaching host")
+	return result, nil
+}
+
 // PowerOn ensures the server is powered on independently of any image
 // provisioning operation.
 func (p *demoProvisioner) PowerOn() (result provisioner.Result, err error) {
@@ -68,20 +68,27 @@ func (p *fixtureProvisioner) ValidateManagementAccess(credentialsChanged bool) (
 	return result, nil
 }
 
-// InspectHardware updates the HardwareDetails field of the host with
-// details of devices discovered on the hardware. It may be called
-// multiple times, and should return true for its dirty flag until the
-// inspection is completed.
-func (p *fixtureProvisioner) InspectHardware() (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
+// InspectHardware returns the details of devices discovered on the
+// hardware. It may be called multiple times, and should return true
+// for its dirty flag until the inspection is completed.
+func (p *fixtureProvisioner) InspectHardware(refresh bool) (result provisioner.Result, started bool, hardwareData *metal3v1alpha1.HardwareDataSpec, err error) {
 	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus())
 
+	if refresh {
+		p.log.Info("starting new inspection")
+		p.publisher("InspectionStarted", "Hardware inspection started")
+		result.Dirty = true
+		result.RequeueAfter = time.Second * 5
+		return result, true, nil, nil
+	}
+
 	// The inspection is ongoing. We'll need to check the fixture
 	// status for the server here until it is ready for us to get the
 	// inspection details. Simulate that for now by creating the
 	// hardware details struct as part of a second pass.
-	if p.host.Status.HardwareDetails == nil {
+	if p.host.Status.HardwareSummary == nil {
 		p.log.Info("continuing inspection by setting details")
-		details =
+		details :=
 			&metal3v1alpha1.HardwareDetails{
 				RAMMebibytes: 128 * 1024,
 				NIC: []metal3v1alpha1.NIC{
@@ -124,6 +131,7 @@ func (p *fixtureProvisioner) InspectHardware() (result provisioner.Result, detai
 					Count:          1,
 				},
 			}
+		hardwareData = &metal3v1alpha1.HardwareDataSpec{HardwareDetails: details}
 		p.publisher("InspectionComplete", "Hardware inspection completed")
 	}
 
@@ -143,6 +151,22 @@ func (p *fixtureProvisioner) UpdateHardwareState() (result provisioner.Result, e
 	return result, nil
 }
 
+// UpdateFirmware installs the firmware images listed in the host
+// spec.
+func (p *fixtureProvisioner) UpdateFirmware(outdated bool) (result provisioner.Result, started bool, err error) {
+	p.log.Info("updating firmware")
+	started = outdated
+	return
+}
+
+// Prepare applies the settings that must be in place before the
+// host is ready to be provisioned.
+func (p *fixtureProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
+	p.log.Info("preparing host")
+	started = unprepared
+	return
+}
+
 // Adopt allows an externally-provisioned server to be adopted.
 func (p *fixtureProvisioner) Adopt() (result provisioner.Result, err error) {
 	p.log.Info("adopting host")
@@ -185,10 +209,10 @@ func (p *fixtureProvisioner) Deprovision() (result provisioner.Result, err error
 	// necessary once we really have Fixture doing the deprovisioning
 	// and we can monitor it's status.
 
-	if p.host.Status.HardwareDetails != nil {
+	if p.host.Status.HardwareSummary != nil {
 		p.publisher("DeprovisionStarted", "Image deprovisioning started")
 		p.log.Info("clearing hardware details")
-		p.host.Status.HardwareDetails = nil
+		p.host.Status.HardwareSummary = nil
 		result.Dirty = true
 		return result, nil
 	}
@@ -213,6 +237,14 @@ func (p *fixtureProvisioner) Delete() (result provisioner.Result, err error) {
 	return result, nil
 }
 
+// Detach removes the host from the provisioning system without
+// deprovisioning it. It may be called multiple times, and should
+// return true for its dirty flag until the host is removed.
+func (p *fixtureProvisioner) Detach() (result provisioner.Result, err error) {
+	p.log.Info("detaching host")
+	return p.Delete()
+}
+
 // PowerOn ensures the server is powered on independently of any image
 // provisioning operation.
 func (p *fixtureProvisioner) PowerOn() (result provisioner.Result, err error) {
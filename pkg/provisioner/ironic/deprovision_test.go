@@ -0,0 +1,269 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestDeprovision(t *testing.T) {
+
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	manualCleaning := map[string]interface{}{manualCleaningExtraKey: true}
+
+	cases := []struct {
+		name             string
+		ironic           *testserver.IronicMock
+		cleaningMode     metal3v1alpha1.AutomatedCleaningMode
+		customCleanSteps []metal3v1alpha1.CustomCleanStep
+		cleanSteps       *metal3v1alpha1.StepsStatus
+
+		expectedDirty        bool
+		expectedRequestAfter int
+		expectedPublish      string
+		expectedMethods      []string
+		expectedCleanSteps   *metal3v1alpha1.StepsStatus
+	}{
+		{
+			name: "active-default-mode",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Active),
+			}).WithNodeStatesProvision(nodeUUID),
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "DeprovisioningStarted Image deprovisioning started, automated cleaning mode metadata",
+			expectedMethods:      []string{http.MethodGet, http.MethodPatch, http.MethodPut},
+		},
+		{
+			name: "active-disabled",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Active),
+			}).WithNodeStatesProvision(nodeUUID),
+			cleaningMode: metal3v1alpha1.CleaningModeDisabled,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "DeprovisioningStarted Image deprovisioning started, automated cleaning mode disabled",
+			expectedMethods:      []string{http.MethodGet, http.MethodPatch, http.MethodPut},
+		},
+		{
+			name: "active-full",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Active),
+			}).WithNodeStatesProvision(nodeUUID),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "DeprovisioningStarted Image deprovisioning started, automated cleaning mode full",
+			expectedMethods:      []string{http.MethodGet, http.MethodPatch, http.MethodPut},
+		},
+		{
+			name: "active-live-iso",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:            nodeUUID,
+				ProvisionState:  string(nodes.Active),
+				DeployInterface: ramdiskDeployInterface,
+			}).WithNodeStatesProvision(nodeUUID),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "DeprovisioningStarted Detaching the live ISO",
+			expectedMethods:      []string{http.MethodGet, http.MethodPatch, http.MethodPut},
+		},
+		{
+			name: "manageable-erase-disks",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				Extra:          manualCleaning,
+			}).WithNodeStatesProvision(nodeUUID),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "CleaningStarted Running manual clean steps deploy.erase_devices",
+			expectedMethods:      []string{http.MethodGet, http.MethodPut},
+		},
+		{
+			name: "custom-clean-steps-mark",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Active),
+			}).WithNodeStatesProvision(nodeUUID),
+			customCleanSteps: []metal3v1alpha1.CustomCleanStep{
+				{Interface: "management", Step: "clear_job_queue"},
+			},
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "DeprovisioningStarted Image deprovisioning started, automated cleaning mode metadata",
+			expectedMethods:      []string{http.MethodGet, http.MethodPatch, http.MethodPut},
+			expectedCleanSteps:   &metal3v1alpha1.StepsStatus{Total: 1},
+		},
+		{
+			name: "manageable-custom-clean-steps",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				Extra:          manualCleaning,
+			}).WithNodeStatesProvision(nodeUUID),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+			customCleanSteps: []metal3v1alpha1.CustomCleanStep{
+				{Interface: "management", Step: "clear_job_queue"},
+				{Interface: "bios", Step: "factory_reset"},
+			},
+			cleanSteps: &metal3v1alpha1.StepsStatus{Total: 2},
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "CleaningStarted Running manual clean steps deploy.erase_devices, management.clear_job_queue, bios.factory_reset",
+			expectedMethods:      []string{http.MethodGet, http.MethodPut},
+			expectedCleanSteps:   &metal3v1alpha1.StepsStatus{Total: 2},
+		},
+		{
+			name: "cleaning-custom-step-progress",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:                 nodeUUID,
+				ProvisionState:       string(nodes.CleanWait),
+				TargetProvisionState: string(nodes.Manageable),
+				CleanStep: map[string]interface{}{
+					"interface": "bios",
+					"step":      "factory_reset",
+				},
+			}),
+			customCleanSteps: []metal3v1alpha1.CustomCleanStep{
+				{Interface: "management", Step: "clear_job_queue"},
+				{Interface: "bios", Step: "factory_reset"},
+			},
+			cleanSteps: &metal3v1alpha1.StepsStatus{Total: 2},
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedMethods:      []string{http.MethodGet},
+			expectedCleanSteps:   &metal3v1alpha1.StepsStatus{Current: "bios.factory_reset", Completed: 1, Total: 2},
+		},
+		{
+			name: "manageable-custom-clean-steps-complete",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+			customCleanSteps: []metal3v1alpha1.CustomCleanStep{
+				{Interface: "management", Step: "clear_job_queue"},
+				{Interface: "bios", Step: "factory_reset"},
+			},
+			cleanSteps: &metal3v1alpha1.StepsStatus{Current: "bios.factory_reset", Completed: 1, Total: 2},
+
+			expectedPublish:    "DeprovisioningComplete Image deprovisioning completed",
+			expectedMethods:    []string{http.MethodGet},
+			expectedCleanSteps: &metal3v1alpha1.StepsStatus{Completed: 2, Total: 2},
+		},
+		{
+			name: "cleaning-clear-mark",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:                 nodeUUID,
+				ProvisionState:       string(nodes.CleanWait),
+				TargetProvisionState: string(nodes.Manageable),
+				Extra:                manualCleaning,
+			}),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedMethods:      []string{http.MethodGet, http.MethodPatch},
+		},
+		{
+			name: "automated-cleaning-keep-mark",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:                 nodeUUID,
+				ProvisionState:       string(nodes.CleanWait),
+				TargetProvisionState: string(nodes.Available),
+				Extra:                manualCleaning,
+			}),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedMethods:      []string{http.MethodGet},
+		},
+		{
+			name: "cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanWait),
+			}),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedMethods:      []string{http.MethodGet},
+		},
+		{
+			name: "manageable-complete",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+
+			expectedPublish: "DeprovisioningComplete Image deprovisioning completed",
+			expectedMethods: []string{http.MethodGet},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.ironic != nil {
+				tc.ironic.Start()
+				defer tc.ironic.Stop()
+			}
+
+			host := makeHost()
+			host.Spec.AutomatedCleaningMode = tc.cleaningMode
+			host.Spec.CustomCleanSteps = tc.customCleanSteps
+			host.Status.Provisioning.CleanSteps = tc.cleanSteps
+			publishedMsg := ""
+			publisher := func(reason, message string) {
+				publishedMsg = reason + " " + message
+			}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			result, err := prov.Deprovision()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
+			assert.Equal(t, tc.expectedPublish, publishedMsg)
+			assert.Equal(t, tc.expectedCleanSteps, host.Status.Provisioning.CleanSteps)
+
+			var methods []string
+			for _, r := range tc.ironic.FullRequests {
+				if r.URL.Path != "/v1" && r.URL.Path != "/v1/" {
+					methods = append(methods, r.Method)
+				}
+			}
+			assert.Equal(t, tc.expectedMethods, methods)
+		})
+	}
+}
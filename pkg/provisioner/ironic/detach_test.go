@@ -0,0 +1,112 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestDetach(t *testing.T) {
+
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name   string
+		ironic *testserver.IronicMock
+
+		expectedDirty   bool
+		expectedMethods []string
+	}{
+		{
+			name: "active",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Active),
+			}),
+
+			expectedDirty:   true,
+			expectedMethods: []string{http.MethodGet, http.MethodPatch},
+		},
+		{
+			name: "active-maintenance",
+			ironic: func() *testserver.IronicMock {
+				ironic := testserver.NewIronic(t).Ready()
+				node, _ := json.Marshal(nodes.Node{
+					UUID:           nodeUUID,
+					ProvisionState: string(nodes.Active),
+					Maintenance:    true,
+				})
+				ironic.Handler("/v1/nodes/"+nodeUUID, func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == http.MethodDelete {
+						w.WriteHeader(http.StatusNoContent)
+						return
+					}
+					w.Write(node)
+				})
+				return ironic
+			}(),
+
+			expectedDirty:   true,
+			expectedMethods: []string{http.MethodGet, http.MethodDelete},
+		},
+		{
+			name: "available",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Available),
+			}).WithNodeStatesProvision(nodeUUID),
+
+			expectedDirty:   true,
+			expectedMethods: []string{http.MethodGet, http.MethodPut},
+		},
+		{
+			name: "removed",
+			ironic: func() *testserver.IronicMock {
+				ironic := testserver.NewIronic(t).Ready()
+				ironic.NotFound("/v1/nodes/" + nodeUUID)
+				ironic.NotFound("/v1/nodes/myhost")
+				return ironic
+			}(),
+
+			expectedMethods: []string{http.MethodGet, http.MethodGet},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			host := makeHost()
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			result, err := prov.Detach()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+
+			var methods []string
+			for _, r := range tc.ironic.FullRequests {
+				if r.URL.Path != "/v1" && r.URL.Path != "/v1/" {
+					methods = append(methods, r.Method)
+				}
+			}
+			assert.Equal(t, tc.expectedMethods, methods)
+		})
+	}
+}
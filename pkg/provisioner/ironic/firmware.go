@@ -0,0 +1,65 @@
+package ironic
+
+import (
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// firmwareMicroversion is the first version of the Ironic API that
+// reports the firmware versions of nodes.
+const firmwareMicroversion = "1.86"
+
+// buildFirmwareUpdateCleanSteps returns the manual clean steps that
+// install the firmware images in the list, using the update step of
+// the firmware interface that reports the versions read back through
+// the firmware API. The step takes no checksum.
+func buildFirmwareUpdateCleanSteps(updates []metal3v1alpha1.FirmwareUpdate) []nodes.CleanStep {
+	settings := make([]map[string]string, 0, len(updates))
+	for _, update := range updates {
+		settings = append(settings, map[string]string{
+			"component": string(update.Component),
+			"url":       update.URL,
+		})
+	}
+	return []nodes.CleanStep{
+		{
+			Interface: "firmware",
+			Step:      "update",
+			Args: map[string]interface{}{
+				"settings": settings,
+			},
+		},
+	}
+}
+
+// firmwareComponent is a firmware component of a node as reported by
+// Ironic.
+type firmwareComponent struct {
+	Component          string `json:"component"`
+	InitialVersion     string `json:"initial_version"`
+	CurrentVersion     string `json:"current_version"`
+	LastVersionFlashed string `json:"last_version_flashed"`
+	UpdatedAt          string `json:"updated_at"`
+}
+
+// getFirmwareComponentStatus converts the firmware components reported
+// by Ironic to the form used in the host status.
+func getFirmwareComponentStatus(components []firmwareComponent) (status []metal3v1alpha1.FirmwareComponentStatus) {
+	for _, component := range components {
+		componentStatus := metal3v1alpha1.FirmwareComponentStatus{
+			Component:          component.Component,
+			InitialVersion:     component.InitialVersion,
+			CurrentVersion:     component.CurrentVersion,
+			LastVersionFlashed: component.LastVersionFlashed,
+		}
+		if updatedAt, err := time.Parse(time.RFC3339, component.UpdatedAt); err == nil {
+			componentStatus.UpdatedAt = &metav1.Time{Time: updatedAt}
+		}
+		status = append(status, componentStatus)
+	}
+	return
+}
@@ -0,0 +1,189 @@
+package ironic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestBuildFirmwareUpdateCleanSteps(t *testing.T) {
+	steps := buildFirmwareUpdateCleanSteps([]metal3v1alpha1.FirmwareUpdate{
+		{
+			Component: metal3v1alpha1.BIOSFirmwareComponent,
+			URL:       "http://example.com/bios.bin",
+			Checksum:  "abcd",
+		},
+		{
+			Component: metal3v1alpha1.BMCFirmwareComponent,
+			URL:       "http://example.com/bmc.bin",
+			Checksum:  "ef01",
+		},
+	})
+
+	assert.Equal(t, []nodes.CleanStep{
+		{
+			Interface: "firmware",
+			Step:      "update",
+			Args: map[string]interface{}{
+				"settings": []map[string]string{
+					{"component": "bios", "url": "http://example.com/bios.bin"},
+					{"component": "bmc", "url": "http://example.com/bmc.bin"},
+				},
+			},
+		},
+	}, steps)
+}
+
+func TestUpdateFirmware(t *testing.T) {
+
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	firmwareUpdates := []metal3v1alpha1.FirmwareUpdate{
+		{
+			Component: metal3v1alpha1.BIOSFirmwareComponent,
+			URL:       "http://example.com/bios.bin",
+			Checksum:  "abcd",
+		},
+	}
+	updatedAt := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	noFirmwareAPI := testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+		UUID:           nodeUUID,
+		ProvisionState: string(nodes.Manageable),
+	})
+	noFirmwareAPI.NotFound("/v1/nodes/" + nodeUUID + "/firmware")
+
+	cases := []struct {
+		name     string
+		ironic   *testserver.IronicMock
+		outdated bool
+
+		expectedStarted      bool
+		expectedDirty        bool
+		expectedRequestAfter int
+		expectedResultError  string
+		expectedPublish      string
+		expectedComponents   []metal3v1alpha1.FirmwareComponentStatus
+	}{
+		{
+			name: "manageable-start-cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}).WithNodeStatesProvision(nodeUUID),
+			outdated: true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "FirmwareUpdateStarted Installing 1 firmware images",
+		},
+		{
+			name: "available-manage-first",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Available),
+			}).WithNodeStatesProvision(nodeUUID),
+			outdated: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanWait),
+			}),
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "clean-failed",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanFail),
+				LastError:      "bad checksum",
+			}),
+
+			expectedResultError: "Firmware update failed: bad checksum",
+		},
+		{
+			name: "manageable-updated",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}).WithFirmware(nodeUUID, `{"firmware": [{
+				"component": "bios",
+				"initial_version": "1.0.0",
+				"current_version": "1.1.0",
+				"last_version_flashed": "1.1.0",
+				"updated_at": "2020-10-01T12:00:00+00:00"
+			}]}`),
+
+			expectedComponents: []metal3v1alpha1.FirmwareComponentStatus{
+				{
+					Component:          "bios",
+					InitialVersion:     "1.0.0",
+					CurrentVersion:     "1.1.0",
+					LastVersionFlashed: "1.1.0",
+				},
+			},
+		},
+		{
+			name:   "manageable-updated-without-firmware-api",
+			ironic: noFirmwareAPI,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.ironic != nil {
+				tc.ironic.Start()
+				defer tc.ironic.Stop()
+			}
+
+			host := makeHost()
+			host.Spec.FirmwareUpdates = firmwareUpdates
+			host.Status.HardwareSummary = &metal3v1alpha1.HardwareSummary{}
+			publishedMsg := ""
+			publisher := func(reason, message string) {
+				publishedMsg = reason + " " + message
+			}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			result, started, err := prov.UpdateFirmware(tc.outdated)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStarted, started)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
+			assert.Equal(t, tc.expectedResultError, result.ErrorMessage)
+			assert.Equal(t, tc.expectedPublish, publishedMsg)
+
+			components := host.Status.HardwareSummary.Firmware.Components
+			for i := range components {
+				if assert.NotNil(t, components[i].UpdatedAt) {
+					assert.True(t, updatedAt.Equal(components[i].UpdatedAt.Time))
+				}
+				components[i].UpdatedAt = nil
+			}
+			assert.Equal(t, tc.expectedComponents, components)
+		})
+	}
+}
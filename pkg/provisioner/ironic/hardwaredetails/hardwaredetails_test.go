@@ -270,7 +270,7 @@ func TestGetFirmwareDetails(t *testing.T) {
 	// Finally, ensure we can handle completely empty firmware data
 	firmware = getFirmwareDetails(introspection.ExtraHardwareDataSection{})
 
-	if (firmware != metal3v1alpha1.Firmware{}) {
+	if !reflect.DeepEqual(firmware, metal3v1alpha1.Firmware{}) {
 		t.Errorf("Expected firmware data to be empty but got: %s", firmware)
 	}
 
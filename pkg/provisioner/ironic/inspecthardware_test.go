@@ -22,8 +22,10 @@ func TestInspectHardware(t *testing.T) {
 		name      string
 		ironic    *testserver.IronicMock
 		inspector *testserver.InspectorMock
+		refresh   bool
 
 		expectedDirty        bool
+		expectedStarted      bool
 		expectedRequestAfter int
 		expectedResultError  string
 		expectedDetailsHost  string
@@ -40,6 +42,7 @@ func TestInspectHardware(t *testing.T) {
 			inspector: testserver.NewInspector(t).Ready().WithIntrospectionFailed(nodeUUID, http.StatusNotFound),
 
 			expectedDirty:        true,
+			expectedStarted:      true,
 			expectedRequestAfter: 10,
 			expectedPublish:      "InspectionStarted Hardware inspection started",
 		},
@@ -115,7 +118,7 @@ func TestInspectHardware(t *testing.T) {
 			name: "inspection-completed",
 			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
 				UUID: nodeUUID,
-			}),
+			}).WithPorts(),
 			inspector: testserver.NewInspector(t).Ready().
 				WithIntrospection(nodeUUID, introspection.Introspection{
 					Finished: true,
@@ -130,6 +133,52 @@ func TestInspectHardware(t *testing.T) {
 			expectedDetailsHost: "node-0",
 			expectedPublish:     "InspectionComplete Hardware inspection completed",
 		},
+		{
+			name: "refresh-start-new-hardware-inspection",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: "manageable",
+			}).WithNodeStatesProvision(nodeUUID),
+			inspector: testserver.NewInspector(t).Ready().
+				WithIntrospection(nodeUUID, introspection.Introspection{
+					Finished: true,
+				}),
+			refresh: true,
+
+			expectedDirty:        true,
+			expectedStarted:      true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "InspectionStarted Hardware inspection started",
+		},
+		{
+			name: "refresh-manage-available-host",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: "available",
+			}).WithNodeStatesProvision(nodeUUID),
+			inspector: testserver.NewInspector(t).Ready().
+				WithIntrospection(nodeUUID, introspection.Introspection{
+					Finished: true,
+				}),
+			refresh: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "refresh-inspection-in-progress",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: "inspect wait",
+			}),
+			inspector: testserver.NewInspector(t).Ready().WithIntrospection(nodeUUID, introspection.Introspection{
+				Finished: false,
+			}),
+			refresh: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 15,
+		},
 	}
 
 	for _, tc := range cases {
@@ -158,14 +207,16 @@ func TestInspectHardware(t *testing.T) {
 			}
 
 			prov.status.ID = nodeUUID
-			result, details, err := prov.InspectHardware()
+			result, started, hardwareData, err := prov.InspectHardware(tc.refresh)
 
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, tc.expectedStarted, started)
 			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
 			assert.Equal(t, tc.expectedResultError, result.ErrorMessage)
 
-			if details != nil {
-				assert.Equal(t, tc.expectedDetailsHost, details.Hostname)
+			if hardwareData != nil {
+				assert.Equal(t, tc.expectedDetailsHost, hardwareData.HardwareDetails.Hostname)
+				assert.NotNil(t, hardwareData.Data)
 			}
 			assert.Equal(t, tc.expectedPublish, publishedMsg)
 			if tc.expectedError == "" {
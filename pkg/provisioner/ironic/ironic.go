@@ -1,16 +1,19 @@
 package ironic
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/yaml"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
-	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
 
 	"github.com/pkg/errors"
@@ -20,6 +23,7 @@ import (
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	checksumpkg "github.com/metal3-io/baremetal-operator/pkg/checksum"
 	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
@@ -42,6 +46,23 @@ var ironicInsecure bool
 var ironicAuth clients.AuthConfig
 var inspectorAuth clients.AuthConfig
 
+// metadataURL is the URL of the metadata service given to the hosts
+// using it instead of a config drive, or empty when it is disabled.
+var metadataURL string
+
+// checksumResolver reads the checksums of images from the checksum
+// files given as their checksum URL.
+var checksumResolver = checksumpkg.NewResolver(&http.Client{Timeout: 30 * time.Second}, 10*time.Minute)
+
+// manualCleaningExtraKey marks, in the extra field of a node, that
+// manual clean steps, such as erasing its disks, must run once it is
+// deprovisioned.
+const manualCleaningExtraKey = "metal3_manual_cleaning"
+
+// ramdiskDeployInterface is the deploy interface that boots live ISO
+// images instead of writing them to disk.
+const ramdiskDeployInterface = "ramdisk"
+
 const (
 	// See nodes.Node.PowerState for details
 	powerOn      = "power on"
@@ -127,9 +148,34 @@ func LogStartup() {
 		"inspectorAuthType", inspectorAuth.Type,
 		"deployKernelURL", deployKernelURL,
 		"deployRamdiskURL", deployRamdiskURL,
+		"metadataURL", metadataURL,
 	)
 }
 
+// SetMetadataURL sets the URL of the metadata service passed on the
+// kernel command line of the hosts using it instead of a config drive.
+func SetMetadataURL(url string) {
+	metadataURL = strings.TrimSuffix(url, "/")
+}
+
+// usesMetadataURL returns true when the host reads its configuration
+// data from the metadata service. Its URL is only passed on the kernel
+// command line of images Ironic boots the kernel of, other hosts are
+// given a config drive.
+func (p *ironicProvisioner) usesMetadataURL() bool {
+	return p.host.UsesMetadataService() && metadataURL != "" &&
+		p.host.Spec.Image.BootsWithKernelParams()
+}
+
+// metadataKernelParams returns the kernel parameters pointing ignition
+// and cloud-init at the data of the host in the metadata service.
+func metadataKernelParams(host *metal3v1alpha1.BareMetalHost) string {
+	hostURL := fmt.Sprintf("%s/%s", metadataURL, host.UID)
+	return fmt.Sprintf("ignition.config.url=%s/openstack/latest/user_data"+
+		" ci.ds=OpenStack cc: datasource: {OpenStack: {metadata_urls: ['%s']}} end_cc",
+		hostURL, hostURL)
+}
+
 // A private function to construct an ironicProvisioner (rather than a
 // Provisioner interface) in a consistent way for tests.
 func newProvisionerWithSettings(host *metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials, publisher provisioner.EventPublisher, ironicURL string, ironicAuthSettings clients.AuthConfig, inspectorURL string, inspectorAuthSettings clients.AuthConfig) (*ironicProvisioner, error) {
@@ -303,27 +349,36 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged bool) (r
 		result.Dirty = true
 		p.log.Info("setting provisioning id", "ID", p.status.ID)
 
-		// If we know the MAC, create a port. Otherwise we will have
-		// to do this after we run the introspection step.
-		if p.host.Spec.BootMACAddress != "" {
-			enable := true
-			p.log.Info("creating port for node in ironic", "MAC",
-				p.host.Spec.BootMACAddress)
-			_, err := ports.Create(
-				p.client,
-				ports.CreateOpts{
-					NodeUUID:   ironicNode.UUID,
-					Address:    p.host.Spec.BootMACAddress,
-					PXEEnabled: &enable,
-				}).Extract()
-			if err != nil {
-				return result, errors.Wrap(err, "failed to create port in ironic")
-			}
+		// Create the ports of the NICs we know about. The port of
+		// the NIC the host boots from is created by the introspection
+		// step when the BootMACAddress is not set.
+		if err := p.reconcilePorts(ironicNode.UUID, nil); err != nil {
+			return result, err
 		}
 
-		checksum, checksumType, ok := p.host.GetImageChecksum()
-
-		if ok {
+		if p.host.Spec.Image.IsLiveISO() {
+			updates := append(
+				p.getLiveISOUpdateOptsForNode(ironicNode),
+				nodes.UpdateOperation{
+					Op:    nodes.ReplaceOp,
+					Path:  "/instance_uuid",
+					Value: string(p.host.ObjectMeta.UID),
+				},
+			)
+			_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+			switch err.(type) {
+			case nil:
+			case gophercloud.ErrDefault409:
+				p.log.Info("could not update host settings in ironic, busy")
+				result.Dirty = true
+				result.RequeueAfter = provisionRequeueDelay
+				return result, nil
+			default:
+				return result, errors.Wrap(err, "failed to update host settings in ironic")
+			}
+		} else if checksum, checksumType, ok, err := p.imageChecksum(); err != nil {
+			return result, errors.Wrap(err, "failed to read image checksum")
+		} else if ok {
 			p.log.Info("setting instance info",
 				"image_source", p.host.Spec.Image.URL,
 				"image_os_hash_value", checksum,
@@ -463,6 +518,17 @@ func (p *ironicProvisioner) ValidateManagementAccess(credentialsChanged bool) (r
 			return result, nil
 		}
 
+		// Ironic does not accept the cleaning setting when the node
+		// is created, so set it before managing the node.
+		mode := p.host.AutomatedCleaningMode()
+		result, err = p.updateCleaningSettings(ironicNode,
+			mode != metal3v1alpha1.CleaningModeDisabled, false)
+		if err != nil || result.Dirty {
+			return result, err
+		}
+		p.publisher("CleaningModeSet",
+			fmt.Sprintf("Automated cleaning mode %s", mode))
+
 		return p.changeNodeProvisionState(
 			ironicNode,
 			nodes.ProvisionStateOpts{Target: nodes.TargetManage},
@@ -522,11 +588,11 @@ func (p *ironicProvisioner) changeNodeProvisionState(ironicNode *nodes.Node, opt
 	return
 }
 
-// InspectHardware updates the HardwareDetails field of the host with
-// details of devices discovered on the hardware. It may be called
-// multiple times, and should return true for its dirty flag until the
-// inspection is completed.
-func (p *ironicProvisioner) InspectHardware() (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
+// InspectHardware returns the hardware details of the host, along
+// with the raw introspection data. It may be called multiple times,
+// and should return true for its dirty flag until the inspection is
+// completed.
+func (p *ironicProvisioner) InspectHardware(refresh bool) (result provisioner.Result, started bool, hardwareData *metal3v1alpha1.HardwareDataSpec, err error) {
 	p.log.Info("inspecting hardware", "status", p.host.OperationalStatus())
 
 	ironicNode, err := p.findExistingHost()
@@ -535,7 +601,31 @@ func (p *ironicProvisioner) InspectHardware() (result provisioner.Result, detail
 		return
 	}
 	if ironicNode == nil {
-		return result, nil, fmt.Errorf("no ironic node for host")
+		return result, false, nil, fmt.Errorf("no ironic node for host")
+	}
+
+	if refresh {
+		// The results of the previous inspection are stale, so
+		// start a new one unless it is already running.
+		switch nodes.ProvisionState(ironicNode.ProvisionState) {
+		case nodes.Inspecting, nodes.InspectWait:
+		case nodes.Available:
+			// Only manageable nodes can be inspected.
+			result, err = p.changeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+			)
+			return
+		case nodes.Manageable:
+			result, started, err = p.startInspection(ironicNode)
+			return
+		default:
+			p.log.Info("waiting for host to become manageable",
+				"state", ironicNode.ProvisionState)
+			result.Dirty = true
+			result.RequeueAfter = provisionRequeueDelay
+			return
+		}
 	}
 
 	status, err := introspection.GetIntrospectionStatus(p.inspector, ironicNode.UUID).Extract()
@@ -549,36 +639,7 @@ func (p *ironicProvisioner) InspectHardware() (result provisioner.Result, detail
 				err = nil
 				return
 			default:
-				p.log.Info("updating boot mode before hardware inspection")
-				op, value := buildCapabilitiesValue(ironicNode, p.host.Status.Provisioning.BootMode)
-				updates := nodes.UpdateOpts{
-					nodes.UpdateOperation{
-						Op:    op,
-						Path:  "/properties/capabilities",
-						Value: value,
-					},
-				}
-				_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
-				switch err.(type) {
-				case nil:
-				case gophercloud.ErrDefault409:
-					p.log.Info("could not update host settings in ironic, busy")
-					result.Dirty = true
-					return
-				default:
-					err = errors.Wrap(err, "failed to update host boot mode settings in ironic")
-					return
-				}
-
-				p.log.Info("starting new hardware inspection")
-				var success bool
-				success, result, err = p.tryChangeNodeProvisionState(
-					ironicNode,
-					nodes.ProvisionStateOpts{Target: nodes.TargetInspect},
-				)
-				if success {
-					p.publisher("InspectionStarted", "Hardware inspection started")
-				}
+				result, started, err = p.startInspection(ironicNode)
 				return
 			}
 		}
@@ -605,13 +666,62 @@ func (p *ironicProvisioner) InspectHardware() (result provisioner.Result, detail
 		err = errors.Wrap(err, "failed to retrieve hardware introspection data")
 		return
 	}
-	p.log.Info("received introspection data", "data", introData.Body)
+	p.log.Info("received introspection data")
+
+	raw, err := json.Marshal(introData.Body)
+	if err != nil {
+		err = errors.Wrap(err, "failed to encode hardware introspection data")
+		return
+	}
+	hardwareData = &metal3v1alpha1.HardwareDataSpec{
+		HardwareDetails: hardwaredetails.GetHardwareDetails(data),
+		Data:            &runtime.RawExtension{Raw: raw},
+	}
 
-	details = hardwaredetails.GetHardwareDetails(data)
+	// Inspection may have found the NIC the host boots from, which
+	// the port groups of the host can refer to.
+	if err = p.reconcilePorts(ironicNode.UUID, hardwareData.HardwareDetails); err != nil {
+		hardwareData = nil
+		return
+	}
 	p.publisher("InspectionComplete", "Hardware inspection completed")
 	return
 }
 
+// startInspection updates the boot mode of the node, which the
+// inspection ramdisk is booted with, and starts a new inspection.
+func (p *ironicProvisioner) startInspection(ironicNode *nodes.Node) (result provisioner.Result, started bool, err error) {
+	p.log.Info("updating boot mode before hardware inspection")
+	op, value := buildCapabilitiesValue(ironicNode, p.host.Status.Provisioning.BootMode)
+	updates := nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    op,
+			Path:  "/properties/capabilities",
+			Value: value,
+		},
+	}
+	_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not update host settings in ironic, busy")
+		result.Dirty = true
+		return result, false, nil
+	default:
+		return result, false, errors.Wrap(err, "failed to update host boot mode settings in ironic")
+	}
+
+	p.log.Info("starting new hardware inspection")
+	started, result, err = p.tryChangeNodeProvisionState(
+		ironicNode,
+		nodes.ProvisionStateOpts{Target: nodes.TargetInspect},
+	)
+	if started {
+		p.publisher("InspectionStarted", "Hardware inspection started")
+	}
+	return
+}
+
 // UpdateHardwareState fetches the latest hardware state of the server
 // and updates the HardwareDetails field of the host with details. It
 // is expected to do this in the least expensive way possible, such as
@@ -650,14 +760,334 @@ func (p *ironicProvisioner) UpdateHardwareState() (result provisioner.Result, er
 	return result, nil
 }
 
-func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node) (updates nodes.UpdateOpts, err error) {
+// setTargetRAIDConfig pushes the RAID configuration of the host to
+// Ironic as the target_raid_config of the node and returns the clean
+// steps that apply it.
+func (p *ironicProvisioner) setTargetRAIDConfig(ironicNode *nodes.Node) (cleanSteps []nodes.CleanStep, result provisioner.Result, err error) {
+	logicalDisks, err := buildTargetRAIDConfig(p.host.Spec.RAID)
+	if err != nil {
+		p.log.Info("invalid RAID configuration", "error", err.Error())
+		result.ErrorMessage = fmt.Sprintf("Invalid RAID configuration: %s", err.Error())
+		return nil, result, nil
+	}
 
-	hwProf, err := hardware.GetProfile(p.host.HardwareProfile())
+	softwareRAID := p.host.Spec.RAID != nil && len(p.host.Spec.RAID.SoftwareRAIDVolumes) != 0
+	switch {
+	case softwareRAID && ironicNode.RAIDInterface != softwareRAIDInterface:
+		p.log.Info("switching to the software RAID interface",
+			"current", ironicNode.RAIDInterface)
+		updates := nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:    nodes.ReplaceOp,
+				Path:  "/raid_interface",
+				Value: softwareRAIDInterface,
+			},
+		}
+		_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+		switch err.(type) {
+		case nil:
+		case gophercloud.ErrDefault409:
+			p.log.Info("could not update host raid interface, busy")
+		default:
+			return nil, result, errors.Wrap(err, "failed to update host raid interface")
+		}
+		result.Dirty = true
+		result.RequeueAfter = provisionRequeueDelay
+		return nil, result, nil
+
+	case !softwareRAID && ironicNode.RAIDInterface == noRAIDInterface:
+		result.ErrorMessage = fmt.Sprintf("RAID settings are defined, but the driver %s of the host does not support RAID",
+			ironicNode.Driver)
+		return nil, result, nil
+	}
 
+	p.log.Info("setting target RAID configuration", "logical disks", len(logicalDisks))
+	err = nodes.SetRAIDConfig(
+		p.client,
+		ironicNode.UUID,
+		nodes.RAIDConfigOpts{LogicalDisks: logicalDisks},
+	).ExtractErr()
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not set target RAID configuration, busy")
+		result.Dirty = true
+		result.RequeueAfter = provisionRequeueDelay
+		return nil, result, nil
+	default:
+		return nil, result, errors.Wrap(err, "failed to set target RAID configuration")
+	}
+
+	return buildRAIDCleanSteps(logicalDisks), result, nil
+}
+
+// startManualCleaning starts the manual cleaning that applies the
+// RAID configuration or the BIOS settings of the host, whichever
+// changed since they were last applied. When both changed, the RAID
+// configuration is applied first and the BIOS settings by a later
+// cleaning, so that a failure can be attributed to one of them.
+func (p *ironicProvisioner) startManualCleaning(ironicNode *nodes.Node) (result provisioner.Result, started bool, err error) {
+	var cleanSteps []nodes.CleanStep
+	var applying string
+
+	if p.host.RAIDConfigChanged() {
+		cleanSteps, result, err = p.setTargetRAIDConfig(ironicNode)
+		if err != nil || result.ErrorMessage != "" || result.Dirty {
+			return result, false, err
+		}
+		applying = "the RAID configuration"
+	} else if p.host.FirmwareConfigChanged() {
+		settings, err := p.bmcAccess.BuildBIOSSettings(p.host.Spec.Firmware)
+		if err != nil {
+			p.log.Info("invalid firmware settings", "error", err.Error())
+			result.ErrorMessage = fmt.Sprintf("Invalid firmware settings: %s", err.Error())
+			return result, false, nil
+		}
+		if len(settings) != 0 {
+			cleanSteps = append(cleanSteps, nodes.CleanStep{
+				Interface: "bios",
+				Step:      "apply_configuration",
+				Args: map[string]interface{}{
+					"settings": settings,
+				},
+			})
+		}
+		applying = "the BIOS settings"
+	}
+
+	if len(cleanSteps) == 0 {
+		// The settings were emptied, so there is nothing to apply.
+		p.log.Info("no clean steps needed")
+		return result, true, nil
+	}
+
+	started, result, err = p.tryChangeNodeProvisionState(
+		ironicNode,
+		nodes.ProvisionStateOpts{
+			Target:     nodes.TargetClean,
+			CleanSteps: cleanSteps,
+		},
+	)
+	if started {
+		p.publisher("PreparationStarted", fmt.Sprintf("Applying %s", applying))
+	}
+	return
+}
+
+// startFirmwareUpdate starts the manual cleaning that installs the
+// firmware images listed in the host spec.
+func (p *ironicProvisioner) startFirmwareUpdate(ironicNode *nodes.Node) (result provisioner.Result, started bool, err error) {
+	started, result, err = p.tryChangeNodeProvisionState(
+		ironicNode,
+		nodes.ProvisionStateOpts{
+			Target:     nodes.TargetClean,
+			CleanSteps: buildFirmwareUpdateCleanSteps(p.host.Spec.FirmwareUpdates),
+		},
+	)
+	if started {
+		p.publisher("FirmwareUpdateStarted",
+			fmt.Sprintf("Installing %d firmware images", len(p.host.Spec.FirmwareUpdates)))
+	}
+	return
+}
+
+// updateFirmwareStatus records the firmware versions of the node in
+// the hardware summary of the host, from where the controller stores
+// them in the HardwareData resource. Versions of Ironic that predate
+// the firmware API do not report them, in which case the status is
+// left alone.
+func (p *ironicProvisioner) updateFirmwareStatus(ironicNode *nodes.Node) (err error) {
+	details := p.host.Status.HardwareSummary
+	if details == nil {
+		return nil
+	}
+
+	client := *p.client
+	client.Microversion = firmwareMicroversion
+	var current struct {
+		Firmware []firmwareComponent `json:"firmware"`
+	}
+	_, err = client.Get(client.ServiceURL("nodes", ironicNode.UUID, "firmware"), &current, nil)
 	if err != nil {
-		return updates, errors.Wrap(err,
-			fmt.Sprintf("Could not start provisioning with bad hardware profile %s",
-				p.host.HardwareProfile()))
+		p.log.Info("firmware versions are not available", "error", err.Error())
+		return nil
+	}
+
+	components := getFirmwareComponentStatus(current.Firmware)
+	if !reflect.DeepEqual(components, details.Firmware.Components) {
+		p.log.Info("updating firmware versions", "components", components)
+		details.Firmware.Components = components
+	}
+	return nil
+}
+
+// updateBIOSSettingsStatus records the current values of the BIOS
+// settings set through the firmware settings of the host in its
+// hardware summary, from where the controller stores them in the
+// HardwareData resource.
+func (p *ironicProvisioner) updateBIOSSettingsStatus(ironicNode *nodes.Node) (err error) {
+	details := p.host.Status.HardwareSummary
+	if details == nil {
+		return nil
+	}
+
+	settings, err := p.bmcAccess.BuildBIOSSettings(p.host.Spec.Firmware)
+	if err != nil {
+		// Invalid settings are reported when applying them.
+		return nil
+	}
+
+	var current struct {
+		BIOS []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"bios"`
+	}
+	_, err = p.client.Get(p.client.ServiceURL("nodes", ironicNode.UUID, "bios"), &current, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to get BIOS settings")
+	}
+
+	values := map[string]string{}
+	for _, setting := range settings {
+		values[setting["name"]] = ""
+	}
+	for _, setting := range current.BIOS {
+		if _, ok := values[setting.Name]; ok {
+			values[setting.Name] = setting.Value
+		}
+	}
+	if len(values) == 0 {
+		values = nil
+	}
+
+	if !reflect.DeepEqual(values, details.Firmware.BIOSSettings) {
+		p.log.Info("updating BIOS settings", "settings", values)
+		details.Firmware.BIOSSettings = values
+	}
+	return nil
+}
+
+// Prepare applies the RAID configuration and the BIOS settings of the
+// host through manual cleaning, one of them at a time.
+func (p *ironicProvisioner) Prepare(unprepared bool) (result provisioner.Result, started bool, err error) {
+	p.log.Info("preparing host", "unprepared", unprepared)
+
+	return p.runManualCleaning(unprepared, p.startManualCleaning,
+		p.finishPreparation, "Host preparation failed")
+}
+
+// finishPreparation records the outcome of the manual cleaning that
+// applied the settings of the host.
+func (p *ironicProvisioner) finishPreparation(ironicNode *nodes.Node) error {
+	if len(ironicNode.TargetRAIDConfig) != 0 {
+		p.log.Info("RAID configuration applied", "raid config", ironicNode.RAIDConfig)
+	}
+	if p.host.Spec.Firmware != nil {
+		return p.updateBIOSSettingsStatus(ironicNode)
+	}
+	return nil
+}
+
+// UpdateFirmware installs the firmware images listed in the host spec.
+func (p *ironicProvisioner) UpdateFirmware(outdated bool) (result provisioner.Result, started bool, err error) {
+	p.log.Info("updating firmware", "outdated", outdated)
+
+	return p.runManualCleaning(outdated, p.startFirmwareUpdate,
+		p.updateFirmwareStatus, "Firmware update failed")
+}
+
+// runManualCleaning drives the node through a round of manual
+// cleaning. When pending is true, start is called to begin cleaning
+// once the node is manageable. Otherwise the node is waited on until
+// cleaning is over, when done is called to record the outcome. Ironic
+// only allows manual cleaning of manageable nodes, so a node that was
+// made available earlier is moved back to manageable first.
+func (p *ironicProvisioner) runManualCleaning(pending bool,
+	start func(*nodes.Node) (provisioner.Result, bool, error),
+	done func(*nodes.Node) error,
+	failure string) (result provisioner.Result, started bool, err error) {
+
+	ironicNode, err := p.findExistingHost()
+	if err != nil {
+		return result, false, errors.Wrap(err, "failed to find existing host")
+	}
+	if ironicNode == nil {
+		return result, false, fmt.Errorf("no ironic node for host")
+	}
+
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+
+	case nodes.Available:
+		if pending {
+			result, err = p.changeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+			)
+		}
+		return
+
+	case nodes.Manageable:
+		if pending {
+			return start(ironicNode)
+		}
+		err = done(ironicNode)
+		return
+
+	case nodes.CleanFail:
+		if !pending {
+			p.log.Info("cleaning failed", "error", ironicNode.LastError)
+			result.ErrorMessage = fmt.Sprintf("%s: %s", failure,
+				ironicNode.LastError)
+			return
+		}
+		// The settings changed since the failure, so recover the
+		// node and try again.
+		if ironicNode.Maintenance {
+			p.log.Info("clearing maintenance flag after failed cleaning")
+			result, err = p.setMaintenanceFlag(ironicNode, false)
+			return
+		}
+		result, err = p.changeNodeProvisionState(
+			ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+		)
+		return
+
+	default:
+		// wait states like cleaning and clean wait
+		p.log.Info("waiting for host to become manageable",
+			"state", ironicNode.ProvisionState,
+			"clean step", ironicNode.CleanStep)
+		result.Dirty = true
+		result.RequeueAfter = provisionRequeueDelay
+		return
+	}
+}
+
+// imageChecksum returns the checksum of the image of the host and its
+// algorithm, reading them from the checksum file when the checksum is
+// a URL. ok is false when the image has no usable checksum.
+func (p *ironicProvisioner) imageChecksum() (checksum, checksumType string, ok bool, err error) {
+	image := p.host.Spec.Image
+	if !image.HasChecksumURL() {
+		checksum, checksumType, ok = p.host.GetImageChecksum()
+		return checksum, checksumType, ok, nil
+	}
+
+	digest, algorithm, err := checksumResolver.Resolve(image.URL, image.Checksum, image.ChecksumType)
+	if err != nil {
+		return "", "", false, err
+	}
+	return digest, string(algorithm), true, nil
+}
+
+// getImageUpdateOptsForNode returns the updates describing an image
+// written to the disk of the host.
+func (p *ironicProvisioner) getImageUpdateOptsForNode(ironicNode *nodes.Node) (updates nodes.UpdateOpts, err error) {
+	checksum, checksumType, _, err := p.imageChecksum()
+	if err != nil {
+		return updates, errors.Wrap(err, "failed to read image checksum")
 	}
 
 	// image_source
@@ -678,8 +1108,6 @@ func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node) (update
 		},
 	)
 
-	checksum, checksumType, _ := p.host.GetImageChecksum()
-
 	// image_os_hash_algo
 	if _, ok := ironicNode.InstanceInfo["image_os_hash_algo"]; !ok {
 		op = nodes.AddOp
@@ -748,6 +1176,152 @@ func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node) (update
 		})
 	}
 
+	// kernel and ramdisk
+	for _, setting := range []struct {
+		field string
+		value string
+	}{
+		{"kernel", p.host.Spec.Image.Kernel},
+		{"ramdisk", p.host.Spec.Image.Ramdisk},
+	} {
+		_, exists := ironicNode.InstanceInfo[setting.field]
+		if setting.value == "" {
+			// A partition image may have been written before.
+			if exists {
+				p.log.Info("removing " + setting.field)
+				updates = append(updates, nodes.UpdateOperation{
+					Op:   nodes.RemoveOp,
+					Path: "/instance_info/" + setting.field,
+				})
+			}
+			continue
+		}
+		if !exists {
+			op = nodes.AddOp
+			p.log.Info("adding " + setting.field)
+		} else {
+			op = nodes.ReplaceOp
+			p.log.Info("updating " + setting.field)
+		}
+		updates = append(
+			updates,
+			nodes.UpdateOperation{
+				Op:    op,
+				Path:  "/instance_info/" + setting.field,
+				Value: setting.value,
+			},
+		)
+	}
+
+	// A live ISO may have been booted before.
+	if ironicNode.DeployInterface == ramdiskDeployInterface {
+		p.log.Info("resetting deploy_interface")
+		updates = append(updates, nodes.UpdateOperation{
+			Op:   nodes.RemoveOp,
+			Path: "/deploy_interface",
+		})
+	}
+	if _, ok := ironicNode.InstanceInfo["boot_iso"]; ok {
+		p.log.Info("removing boot_iso")
+		updates = append(updates, nodes.UpdateOperation{
+			Op:   nodes.RemoveOp,
+			Path: "/instance_info/boot_iso",
+		})
+	}
+
+	return updates, nil
+}
+
+// getLiveISOUpdateOptsForNode returns the updates describing a live
+// ISO booted through the ramdisk deploy interface.
+func (p *ironicProvisioner) getLiveISOUpdateOptsForNode(ironicNode *nodes.Node) (updates nodes.UpdateOpts) {
+	p.log.Info("setting deploy_interface", "deploy_interface", ramdiskDeployInterface)
+	updates = append(
+		updates,
+		nodes.UpdateOperation{
+			Op:    nodes.ReplaceOp,
+			Path:  "/deploy_interface",
+			Value: ramdiskDeployInterface,
+		},
+	)
+
+	// boot_iso
+	var op nodes.UpdateOp
+	if _, ok := ironicNode.InstanceInfo["boot_iso"]; !ok {
+		op = nodes.AddOp
+		p.log.Info("adding boot_iso")
+	} else {
+		op = nodes.ReplaceOp
+		p.log.Info("updating boot_iso")
+	}
+	updates = append(
+		updates,
+		nodes.UpdateOperation{
+			Op:    op,
+			Path:  "/instance_info/boot_iso",
+			Value: p.host.Spec.Image.URL,
+		},
+	)
+
+	// A disk image may have been written before.
+	for _, field := range []string{"image_source", "image_os_hash_algo", "image_os_hash_value", "image_checksum", "image_disk_format", "kernel", "ramdisk"} {
+		if _, ok := ironicNode.InstanceInfo[field]; ok {
+			p.log.Info("removing " + field)
+			updates = append(updates, nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/" + field,
+			})
+		}
+	}
+
+	return updates
+}
+
+func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node) (updates nodes.UpdateOpts, err error) {
+
+	hwProf, err := hardware.GetProfile(p.host.HardwareProfile())
+
+	if err != nil {
+		return updates, errors.Wrap(err,
+			fmt.Sprintf("Could not start provisioning with bad hardware profile %s",
+				p.host.HardwareProfile()))
+	}
+
+	// image settings
+	if p.host.Spec.Image.IsLiveISO() {
+		updates = append(updates, p.getLiveISOUpdateOptsForNode(ironicNode)...)
+	} else {
+		imageUpdates, err := p.getImageUpdateOptsForNode(ironicNode)
+		if err != nil {
+			return updates, err
+		}
+		updates = append(updates, imageUpdates...)
+	}
+
+	var op nodes.UpdateOp
+
+	// kernel_append_params
+	//
+	// Hosts using the metadata service have no config drive, so they
+	// are told where the service is on the kernel command line.
+	if p.usesMetadataURL() {
+		if _, ok := ironicNode.InstanceInfo["kernel_append_params"]; !ok {
+			op = nodes.AddOp
+			p.log.Info("adding kernel_append_params")
+		} else {
+			op = nodes.ReplaceOp
+			p.log.Info("updating kernel_append_params")
+		}
+		updates = append(
+			updates,
+			nodes.UpdateOperation{
+				Op:    op,
+				Path:  "/instance_info/kernel_append_params",
+				Value: metadataKernelParams(p.host),
+			},
+		)
+	}
+
 	// instance_uuid
 	p.log.Info("setting instance_uuid")
 	updates = append(
@@ -763,7 +1337,12 @@ func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node) (update
 	//
 	// FIXME(dhellmann): We have to provide something for the disk
 	// size until https://storyboard.openstack.org/#!/story/2005165 is
-	// fixed in ironic.
+	// fixed in ironic, so the size from the hardware profile is used
+	// unless the image sets one.
+	rootGB := hwProf.RootGB
+	if p.host.Spec.Image.RootGB != 0 {
+		rootGB = p.host.Spec.Image.RootGB
+	}
 	if _, ok := ironicNode.InstanceInfo["root_gb"]; !ok {
 		op = nodes.AddOp
 		p.log.Info("adding root_gb")
@@ -776,7 +1355,7 @@ func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node) (update
 		nodes.UpdateOperation{
 			Op:    op,
 			Path:  "/instance_info/root_gb",
-			Value: hwProf.RootGB,
+			Value: rootGB,
 		},
 	)
 
@@ -913,8 +1492,16 @@ func (p *ironicProvisioner) startProvisioning(ironicNode *nodes.Node, hostConf p
 
 	p.log.Info("starting provisioning", "node properties", ironicNode.Properties)
 
+	if err := p.reconcilePorts(ironicNode.UUID, nil); err != nil {
+		return result, err
+	}
+
 	updates, err := p.getUpdateOptsForNode(ironicNode)
 	if err != nil {
+		if checksumpkg.IsInvalid(err) {
+			result.ErrorMessage = err.Error()
+			return result, nil
+		}
 		return result, errors.Wrap(err, "failed to update opts for node")
 	}
 	_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
@@ -1008,6 +1595,32 @@ func (p *ironicProvisioner) Adopt() (result provisioner.Result, err error) {
 	return
 }
 
+// ironicHasSameImage returns true when the instance info of the node
+// describes the image of the host. The checksum read from a checksum
+// file is not compared, so that the file is only fetched when the
+// instance info is written.
+func (p *ironicProvisioner) ironicHasSameImage(ironicNode *nodes.Node) bool {
+	image := p.host.Spec.Image
+	if image.IsLiveISO() {
+		return ironicNode.DeployInterface == ramdiskDeployInterface &&
+			ironicNode.InstanceInfo["boot_iso"] == image.URL
+	}
+
+	same := ironicNode.InstanceInfo["image_source"] == image.URL
+	if !image.HasChecksumURL() {
+		checksum, checksumType, _ := p.host.GetImageChecksum()
+		same = same &&
+			ironicNode.InstanceInfo["image_os_hash_algo"] == checksumType &&
+			ironicNode.InstanceInfo["image_os_hash_value"] == checksum
+	}
+	if image.IsPartitionImage() {
+		same = same &&
+			ironicNode.InstanceInfo["kernel"] == image.Kernel &&
+			ironicNode.InstanceInfo["ramdisk"] == image.Ramdisk
+	}
+	return same
+}
+
 // Provision writes the image from the host spec to the host. It may
 // be called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
@@ -1023,18 +1636,14 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 
 	p.log.Info("provisioning image to host", "state", ironicNode.ProvisionState)
 
-	checksum, checksumType, _ := p.host.GetImageChecksum()
-
 	// Local variable to make it easier to test if ironic is
 	// configured with the same image we are trying to provision to
 	// the host.
-	ironicHasSameImage := (ironicNode.InstanceInfo["image_source"] == p.host.Spec.Image.URL &&
-		ironicNode.InstanceInfo["image_os_hash_algo"] == checksumType &&
-		ironicNode.InstanceInfo["image_os_hash_value"] == checksum)
+	ironicHasSameImage := p.ironicHasSameImage(ironicNode)
 	p.log.Info("checking image settings",
 		"source", ironicNode.InstanceInfo["image_source"],
-		"image_os_hash_algo", checksumType,
-		"image_os_has_value", checksum,
+		"image_os_hash_algo", ironicNode.InstanceInfo["image_os_hash_algo"],
+		"image_os_has_value", ironicNode.InstanceInfo["image_os_hash_value"],
 		"same", ironicHasSameImage,
 		"provisionState", ironicNode.ProvisionState)
 
@@ -1107,31 +1716,58 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 			}
 		}
 
-		var configDrive nodes.ConfigDrive
-		if userData != "" {
-			configDrive = nodes.ConfigDrive{
-				UserData:    userData,
-				MetaData:    metaData,
-				NetworkData: networkData,
+		opts := nodes.ProvisionStateOpts{
+			Target: nodes.TargetActive,
+		}
+		if p.usesMetadataURL() {
+			// The configuration data was read above only to report
+			// errors in it before the host is deployed, the image
+			// reads it from the metadata service instead of a config
+			// drive.
+			p.log.Info("triggering provisioning without config drive, configuration data is served by the metadata service")
+		} else {
+			if p.host.UsesMetadataService() {
+				p.log.Info("the metadata service URL cannot be passed to the image, falling back to a config drive")
 			}
-			if err != nil {
-				return result, errors.Wrap(err, "failed to build config drive")
+			var configDrive nodes.ConfigDrive
+			if p.host.Spec.Image.IsLiveISO() {
+				// The ramdisk deploy interface boots the ISO as it is,
+				// there is no disk to write a config drive to.
+				p.log.Info("triggering provisioning of live ISO without config drive")
+			} else if userData != "" {
+				configDrive = nodes.ConfigDrive{
+					UserData:    userData,
+					MetaData:    metaData,
+					NetworkData: networkData,
+				}
+				p.log.Info("triggering provisioning with config drive")
+			} else {
+				p.log.Info("triggering provisioning without config drive")
 			}
-			p.log.Info("triggering provisioning with config drive")
-		} else {
-			p.log.Info("triggering provisioning without config drive")
+			opts.ConfigDrive = configDrive
 		}
-
-		return p.changeNodeProvisionState(
-			ironicNode,
-			nodes.ProvisionStateOpts{
-				Target:      nodes.TargetActive,
-				ConfigDrive: configDrive,
-			},
-		)
+		if len(p.host.Spec.CustomDeploySteps) != 0 {
+			return p.deployWithCustomSteps(ironicNode, opts)
+		}
+		p.status.DeploySteps = nil
+		return p.changeNodeProvisionState(ironicNode, opts)
 
 	case nodes.Active:
 		// provisioning is done
+		if steps := p.status.DeploySteps; steps != nil {
+			steps.Current = ""
+			steps.Completed = steps.Total
+		}
+		if p.host.Spec.Image.HasChecksumURL() {
+			// Record the checksum the image was written with, read
+			// from the checksum file, which may change later,
+			// rather than its URL.
+			image := *p.host.Spec.Image
+			image.Checksum, _ = ironicNode.InstanceInfo["image_os_hash_value"].(string)
+			checksumType, _ := ironicNode.InstanceInfo["image_os_hash_algo"].(string)
+			image.ChecksumType = metal3v1alpha1.ChecksumType(checksumType)
+			p.status.Image = image
+		}
 		p.publisher("ProvisioningComplete",
 			fmt.Sprintf("Image provisioning completed for %s", p.host.Spec.Image.URL))
 		p.log.Info("finished provisioning")
@@ -1142,11 +1778,112 @@ func (p *ironicProvisioner) Provision(hostConf provisioner.HostConfigData) (resu
 		p.log.Info("waiting for host to become available",
 			"state", ironicNode.ProvisionState,
 			"deploy step", ironicNode.DeployStep)
+		if p.status.DeploySteps != nil {
+			updateStepsStatus(p.status.DeploySteps,
+				deployStepNames(p.host.Spec.CustomDeploySteps), ironicNode.DeployStep)
+		}
 		result.Dirty = true
 		return result, nil
 	}
 }
 
+// deployWithCustomSteps starts deploying the node with the custom
+// deploy steps of the host, which need a newer version of the Ironic
+// API than the other calls.
+func (p *ironicProvisioner) deployWithCustomSteps(ironicNode *nodes.Node, opts nodes.ProvisionStateOpts) (result provisioner.Result, err error) {
+	steps, err := buildDeploySteps(p.host.Spec.CustomDeploySteps)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, nil
+	}
+
+	p.log.Info("changing provisioning state",
+		"current", ironicNode.ProvisionState,
+		"existing target", ironicNode.TargetProvisionState,
+		"new target", opts.Target,
+		"deploy steps", len(steps),
+	)
+
+	client := *p.client
+	client.Microversion = deployStepsMicroversion
+	changeResult := nodes.ChangeProvisionState(&client, ironicNode.UUID,
+		deployStateOpts{ProvisionStateOpts: opts, DeploySteps: steps})
+	switch changeResult.Err.(type) {
+	case nil:
+		p.status.DeploySteps = &metal3v1alpha1.StepsStatus{Total: len(steps)}
+		p.publisher("CustomDeployStepsStarted",
+			fmt.Sprintf("Running %d custom deploy steps", len(steps)))
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not change state of host, busy")
+	default:
+		return result, errors.Wrap(changeResult.Err,
+			fmt.Sprintf("failed to change provisioning state to %q", opts.Target))
+	}
+
+	result.Dirty = true
+	result.RequeueAfter = provisionRequeueDelay
+	return result, nil
+}
+
+// updateCleaningSettings turns the automated cleaning of the node on or
+// off, and records whether manual clean steps must run once it is
+// deprovisioned.
+func (p *ironicProvisioner) updateCleaningSettings(ironicNode *nodes.Node, automatedClean bool, manualCleaning bool) (result provisioner.Result, err error) {
+	p.log.Info("updating cleaning settings",
+		"automatedClean", automatedClean, "manualCleaning", manualCleaning)
+
+	updates := nodes.UpdateOpts{
+		nodes.UpdateOperation{
+			Op:    nodes.AddOp,
+			Path:  "/automated_clean",
+			Value: automatedClean,
+		},
+	}
+	if manualCleaning {
+		updates = append(updates, nodes.UpdateOperation{
+			Op:    nodes.AddOp,
+			Path:  "/extra/" + manualCleaningExtraKey,
+			Value: true,
+		})
+	}
+	_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not update host cleaning settings, busy")
+		result.Dirty = true
+		result.RequeueAfter = provisionRequeueDelay
+	default:
+		return result, errors.Wrap(err, "failed to update host cleaning settings")
+	}
+	return result, nil
+}
+
+// clearManualCleaning removes the mark recording that manual clean
+// steps must run on the node, once they have started.
+func (p *ironicProvisioner) clearManualCleaning(ironicNode *nodes.Node) (result provisioner.Result, err error) {
+	_, err = nodes.Update(
+		p.client,
+		ironicNode.UUID,
+		nodes.UpdateOpts{
+			nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/extra/" + manualCleaningExtraKey,
+			},
+		},
+	).Extract()
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not clear manual cleaning mark, busy")
+	default:
+		return result, errors.Wrap(err, "failed to clear manual cleaning mark")
+	}
+	result.Dirty = true
+	result.RequeueAfter = deprovisionRequeueDelay
+	return result, nil
+}
+
 func (p *ironicProvisioner) setMaintenanceFlag(ironicNode *nodes.Node, value bool) (result provisioner.Result, err error) {
 	_, err = nodes.Update(
 		p.client,
@@ -1239,25 +1976,100 @@ func (p *ironicProvisioner) Deprovision() (result provisioner.Result, err error)
 		result.RequeueAfter = deprovisionRequeueDelay
 		return result, nil
 
-	case nodes.Cleaning:
-		p.log.Info("cleaning")
+	case nodes.Cleaning, nodes.CleanWait:
+		p.log.Info("cleaning", "clean step", ironicNode.CleanStep)
+		// The automated cleaning that follows tearing down the image
+		// targets available, only the manual cleaning started here
+		// targets manageable.
+		_, pending := ironicNode.Extra[manualCleaningExtraKey]
+		if pending && ironicNode.TargetProvisionState == string(nodes.Manageable) {
+			return p.clearManualCleaning(ironicNode)
+		}
+		if p.status.CleanSteps != nil {
+			updateStepsStatus(p.status.CleanSteps,
+				cleanStepNames(p.host.Spec.CustomCleanSteps), ironicNode.CleanStep)
+		}
 		result.Dirty = true
 		result.RequeueAfter = deprovisionRequeueDelay
 		return result, nil
 
-	case nodes.CleanWait:
-		p.log.Info("cleaning")
-		result.Dirty = true
-		result.RequeueAfter = deprovisionRequeueDelay
+	case nodes.Manageable:
+		if _, pending := ironicNode.Extra[manualCleaningExtraKey]; pending {
+			cleanSteps, err := buildManualCleanSteps(p.host)
+			if err != nil {
+				result.ErrorMessage = err.Error()
+				return result, nil
+			}
+			if len(cleanSteps) == 0 {
+				return p.clearManualCleaning(ironicNode)
+			}
+			p.log.Info("starting manual cleaning", "steps", len(cleanSteps))
+			started, result, err := p.tryChangeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{
+					Target:     nodes.TargetClean,
+					CleanSteps: cleanSteps,
+				},
+			)
+			if started {
+				var names []string
+				for _, step := range cleanSteps {
+					names = append(names, step.Interface+"."+step.Step)
+				}
+				p.publisher("CleaningStarted",
+					fmt.Sprintf("Running manual clean steps %s", strings.Join(names, ", ")))
+			}
+			return result, err
+		}
+		if steps := p.status.CleanSteps; steps != nil {
+			steps.Current = ""
+			steps.Completed = steps.Total
+		}
+		p.publisher("DeprovisioningComplete", "Image deprovisioning completed")
 		return result, nil
 
-	case nodes.Manageable, nodes.Enroll, nodes.Verifying:
+	case nodes.Enroll, nodes.Verifying:
 		p.publisher("DeprovisioningComplete", "Image deprovisioning completed")
 		return result, nil
 
 	default:
-		p.log.Info("starting deprovisioning")
-		p.publisher("DeprovisioningStarted", "Image deprovisioning started")
+		if ironicNode.DeployInterface == ramdiskDeployInterface {
+			// Nothing was written to the disks of a host booted from
+			// a live ISO, so tearing it down only detaches the ISO.
+			result, err = p.updateCleaningSettings(ironicNode, false, false)
+			if err != nil || result.Dirty {
+				return result, err
+			}
+			p.log.Info("starting deprovisioning of live ISO")
+			p.publisher("DeprovisioningStarted", "Detaching the live ISO")
+			return p.changeNodeProvisionState(
+				ironicNode,
+				nodes.ProvisionStateOpts{Target: nodes.TargetDeleted},
+			)
+		}
+		// Ironic runs the automated cleaning it is configured with
+		// when the node is deleted. The disks are erased separately
+		// in full mode, and the custom clean steps run, once the node
+		// is manageable again.
+		mode := p.host.AutomatedCleaningMode()
+		cleanSteps, err := buildManualCleanSteps(p.host)
+		if err != nil {
+			result.ErrorMessage = err.Error()
+			return result, nil
+		}
+		result, err = p.updateCleaningSettings(ironicNode,
+			mode == metal3v1alpha1.CleaningModeMetadata,
+			len(cleanSteps) != 0)
+		if err != nil || result.Dirty {
+			return result, err
+		}
+		p.status.CleanSteps = nil
+		if custom := len(p.host.Spec.CustomCleanSteps); custom != 0 {
+			p.status.CleanSteps = &metal3v1alpha1.StepsStatus{Total: custom}
+		}
+		p.log.Info("starting deprovisioning", "automatedCleaningMode", mode)
+		p.publisher("DeprovisioningStarted",
+			fmt.Sprintf("Image deprovisioning started, automated cleaning mode %s", mode))
 		return p.changeNodeProvisionState(
 			ironicNode,
 			nodes.ProvisionStateOpts{Target: nodes.TargetDeleted},
@@ -1328,6 +2140,16 @@ func (p *ironicProvisioner) Delete() (result provisioner.Result, err error) {
 	return result, nil
 }
 
+// Detach removes the host from the provisioning system without
+// deprovisioning it. The node is deleted through the maintenance mode,
+// like in Delete(), so Ironic leaves the image on the host alone. It
+// may be called multiple times, and should return true for its dirty
+// flag until the host is removed.
+func (p *ironicProvisioner) Detach() (result provisioner.Result, err error) {
+	p.log.Info("detaching host")
+	return p.Delete()
+}
+
 func (p *ironicProvisioner) changePower(ironicNode *nodes.Node, target nodes.TargetPowerState) (result provisioner.Result, err error) {
 	p.log.Info("changing power state")
 
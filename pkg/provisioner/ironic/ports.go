@@ -0,0 +1,281 @@
+package ironic
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// portGroupExtraKey marks, in the extra field of a port group, the
+// name of the port group of the host it was created for.
+const portGroupExtraKey = "metal3_port_group"
+
+// portExtraKey marks, in the extra field of a port, that it was created
+// for a NIC or port group member of the host spec, so that it is
+// deleted once the host no longer lists it. Other ports, such as those
+// created by or for inspection, are left alone.
+const portExtraKey = "metal3_managed"
+
+// portGroup is a port group of a node as reported by Ironic, which
+// gophercloud has no support for.
+type portGroup struct {
+	UUID                     string                 `json:"uuid,omitempty"`
+	NodeUUID                 string                 `json:"node_uuid,omitempty"`
+	Address                  string                 `json:"address,omitempty"`
+	Mode                     string                 `json:"mode,omitempty"`
+	StandalonePortsSupported bool                   `json:"standalone_ports_supported"`
+	Properties               map[string]interface{} `json:"properties,omitempty"`
+	Extra                    map[string]interface{} `json:"extra,omitempty"`
+}
+
+// portSettings are the settings of the port of a network interface.
+type portSettings struct {
+	pxeEnabled bool
+	portGroup  string
+	// managed is true for the ports the host spec asks for, as
+	// opposed to those only found by inspection.
+	managed bool
+}
+
+// buildPortSettings returns the settings of the ports the node of the
+// host needs, by lower case MAC address. The NICs found to be PXE
+// bootable by inspection, when details are given, need a PXE enabled
+// port as well, which is not managed unless the spec lists it too.
+func buildPortSettings(host *metal3v1alpha1.BareMetalHost, details *metal3v1alpha1.HardwareDetails) map[string]portSettings {
+	settings := map[string]portSettings{}
+	enablePXE := func(mac string, managed bool) {
+		mac = strings.ToLower(mac)
+		port := settings[mac]
+		port.pxeEnabled = true
+		port.managed = port.managed || managed
+		settings[mac] = port
+	}
+
+	if host.Spec.BootMACAddress != "" {
+		enablePXE(host.Spec.BootMACAddress, true)
+	}
+	if details != nil {
+		for _, nic := range details.NIC {
+			if nic.PXE && nic.MAC != "" {
+				enablePXE(nic.MAC, false)
+			}
+		}
+	}
+	for _, nic := range host.Spec.NICs {
+		mac := strings.ToLower(nic.MACAddress)
+		port := settings[mac]
+		port.pxeEnabled = port.pxeEnabled || nic.PXEEnabled
+		port.managed = true
+		settings[mac] = port
+	}
+	for _, group := range host.Spec.PortGroups {
+		for _, mac := range group.MACAddresses {
+			mac = strings.ToLower(mac)
+			port := settings[mac]
+			port.portGroup = group.Name
+			port.managed = true
+			settings[mac] = port
+		}
+	}
+	return settings
+}
+
+// buildPortGroup returns the port group of the node for a port group
+// of the host.
+func buildPortGroup(nodeUUID string, group metal3v1alpha1.PortGroup) portGroup {
+	properties := map[string]interface{}{}
+	for name, value := range group.Properties {
+		properties[name] = value
+	}
+	return portGroup{
+		NodeUUID: nodeUUID,
+		Address:  strings.ToLower(group.MACAddresses[0]),
+		Mode:     string(group.Mode),
+		// The members of an LACP bond cannot be used on their own.
+		StandalonePortsSupported: group.Mode != metal3v1alpha1.BondModeLACP,
+		Properties:               properties,
+		Extra:                    map[string]interface{}{portGroupExtraKey: group.Name},
+	}
+}
+
+// listPortGroups returns the port groups of the node created for the
+// port groups of the host, by name.
+func (p *ironicProvisioner) listPortGroups(nodeUUID string) (map[string]portGroup, error) {
+	var list struct {
+		PortGroups []portGroup `json:"portgroups"`
+	}
+	url := p.client.ServiceURL("portgroups", "detail") + "?node=" + nodeUUID
+	if _, err := p.client.Get(url, &list, nil); err != nil {
+		return nil, errors.Wrap(err, "failed to list port groups")
+	}
+
+	groups := map[string]portGroup{}
+	for _, group := range list.PortGroups {
+		if name, ok := group.Extra[portGroupExtraKey].(string); ok {
+			groups[name] = group
+		}
+	}
+	return groups, nil
+}
+
+// reconcilePortGroups creates and updates the port groups of the node
+// to match those of the host. It returns the UUIDs of the port groups
+// by name, and the set of UUIDs of the port groups no longer listed by
+// the host, which can only be deleted once they have no ports.
+func (p *ironicProvisioner) reconcilePortGroups(nodeUUID string) (uuids map[string]string, stale map[string]bool, err error) {
+	current, err := p.listPortGroups(nodeUUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uuids = map[string]string{}
+	for _, group := range p.host.Spec.PortGroups {
+		desired := buildPortGroup(nodeUUID, group)
+		existing, ok := current[group.Name]
+		delete(current, group.Name)
+
+		if !ok {
+			p.log.Info("creating port group", "name", group.Name)
+			var created portGroup
+			_, err = p.client.Post(p.client.ServiceURL("portgroups"), desired, &created, nil)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to create port group %s", group.Name)
+			}
+			uuids[group.Name] = created.UUID
+			continue
+		}
+
+		uuids[group.Name] = existing.UUID
+		desired.UUID = existing.UUID
+		if desired.Mode == "" {
+			desired.Mode = existing.Mode
+		}
+		if reflect.DeepEqual(desired, existing) {
+			continue
+		}
+		p.log.Info("updating port group", "name", group.Name)
+		// Port groups are patched in the same way as ports.
+		updates := []ports.UpdateOperation{
+			{Op: ports.ReplaceOp, Path: "/address", Value: desired.Address},
+			{Op: ports.ReplaceOp, Path: "/mode", Value: desired.Mode},
+			{Op: ports.ReplaceOp, Path: "/standalone_ports_supported", Value: desired.StandalonePortsSupported},
+			{Op: ports.ReplaceOp, Path: "/properties", Value: desired.Properties},
+		}
+		_, err = p.client.Patch(p.client.ServiceURL("portgroups", existing.UUID), updates, nil,
+			&gophercloud.RequestOpts{OkCodes: []int{200}})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to update port group %s", group.Name)
+		}
+	}
+
+	stale = map[string]bool{}
+	for _, group := range current {
+		stale[group.UUID] = true
+	}
+	return uuids, stale, nil
+}
+
+// reconcilePorts creates and updates the ports and port groups of the
+// node to match the NICs and port groups of the host. Ports that the
+// host does not list are only removed when they were created for its
+// spec, so ports created for the NICs found by inspection are kept
+// when no details are given.
+func (p *ironicProvisioner) reconcilePorts(nodeUUID string, details *metal3v1alpha1.HardwareDetails) error {
+	groupUUIDs, staleGroups, err := p.reconcilePortGroups(nodeUUID)
+	if err != nil {
+		return err
+	}
+
+	pages, err := ports.ListDetail(p.client, ports.ListOpts{Node: nodeUUID}).AllPages()
+	if err != nil {
+		return errors.Wrap(err, "failed to list ports")
+	}
+	current, err := ports.ExtractPorts(pages)
+	if err != nil {
+		return errors.Wrap(err, "failed to list ports")
+	}
+
+	settings := buildPortSettings(p.host, details)
+	for _, port := range current {
+		mac := strings.ToLower(port.Address)
+		desired, ok := settings[mac]
+		delete(settings, mac)
+
+		if !ok {
+			if managed, _ := port.Extra[portExtraKey].(bool); managed {
+				p.log.Info("deleting port", "MAC", port.Address)
+				if err := ports.Delete(p.client, port.UUID).ExtractErr(); err != nil {
+					return errors.Wrapf(err, "failed to delete port %s", port.Address)
+				}
+			} else if staleGroups[port.PortGroupUUID] {
+				p.log.Info("removing port from port group", "MAC", port.Address)
+				updates := ports.UpdateOpts{
+					ports.UpdateOperation{Op: ports.RemoveOp, Path: "/portgroup_uuid"},
+				}
+				if _, err := ports.Update(p.client, port.UUID, updates).Extract(); err != nil {
+					return errors.Wrapf(err, "failed to update port %s", port.Address)
+				}
+			}
+			continue
+		}
+
+		var updates ports.UpdateOpts
+		// Ports found by inspection keep their PXE setting unless the
+		// host asks for it.
+		if desired.pxeEnabled && !port.PXEEnabled {
+			updates = append(updates, ports.UpdateOperation{
+				Op: ports.ReplaceOp, Path: "/pxe_enabled", Value: true,
+			})
+		}
+		if groupUUID := groupUUIDs[desired.portGroup]; groupUUID != port.PortGroupUUID {
+			if groupUUID == "" {
+				updates = append(updates, ports.UpdateOperation{
+					Op: ports.RemoveOp, Path: "/portgroup_uuid",
+				})
+			} else {
+				updates = append(updates, ports.UpdateOperation{
+					Op: ports.AddOp, Path: "/portgroup_uuid", Value: groupUUID,
+				})
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		p.log.Info("updating port", "MAC", port.Address)
+		if _, err := ports.Update(p.client, port.UUID, updates).Extract(); err != nil {
+			return errors.Wrapf(err, "failed to update port %s", port.Address)
+		}
+	}
+
+	for mac, desired := range settings {
+		p.log.Info("creating port for node in ironic", "MAC", mac)
+		pxeEnabled := desired.pxeEnabled
+		opts := ports.CreateOpts{
+			NodeUUID:      nodeUUID,
+			Address:       mac,
+			PortGroupUUID: groupUUIDs[desired.portGroup],
+			PXEEnabled:    &pxeEnabled,
+		}
+		if desired.managed {
+			opts.Extra = map[string]interface{}{portExtraKey: true}
+		}
+		_, err := ports.Create(p.client, opts).Extract()
+		if err != nil {
+			return errors.Wrapf(err, "failed to create port %s in ironic", mac)
+		}
+	}
+
+	for uuid := range staleGroups {
+		p.log.Info("deleting port group", "uuid", uuid)
+		_, err := p.client.Delete(p.client.ServiceURL("portgroups", uuid), nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete port group %s", uuid)
+		}
+	}
+	return nil
+}
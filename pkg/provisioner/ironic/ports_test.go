@@ -0,0 +1,236 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestBuildPortSettings(t *testing.T) {
+	cases := []struct {
+		name     string
+		spec     metal3v1alpha1.BareMetalHostSpec
+		details  *metal3v1alpha1.HardwareDetails
+		expected map[string]portSettings
+	}{
+		{
+			name:     "none",
+			expected: map[string]portSettings{},
+		},
+		{
+			name: "boot MAC",
+			spec: metal3v1alpha1.BareMetalHostSpec{
+				BootMACAddress: "00:11:22:33:44:AA",
+			},
+			expected: map[string]portSettings{
+				"00:11:22:33:44:aa": {pxeEnabled: true, managed: true},
+			},
+		},
+		{
+			name: "NICs",
+			spec: metal3v1alpha1.BareMetalHostSpec{
+				BootMACAddress: "00:11:22:33:44:aa",
+				NICs: []metal3v1alpha1.NICConfig{
+					{MACAddress: "00:11:22:33:44:bb"},
+					{MACAddress: "00:11:22:33:44:cc", PXEEnabled: true},
+				},
+			},
+			expected: map[string]portSettings{
+				"00:11:22:33:44:aa": {pxeEnabled: true, managed: true},
+				"00:11:22:33:44:bb": {managed: true},
+				"00:11:22:33:44:cc": {pxeEnabled: true, managed: true},
+			},
+		},
+		{
+			name: "bond of the boot NIC",
+			spec: metal3v1alpha1.BareMetalHostSpec{
+				BootMACAddress: "00:11:22:33:44:aa",
+				PortGroups: []metal3v1alpha1.PortGroup{
+					{
+						Name:         "bond0",
+						Mode:         metal3v1alpha1.BondModeLACP,
+						MACAddresses: []string{"00:11:22:33:44:aa", "00:11:22:33:44:bb"},
+					},
+				},
+			},
+			expected: map[string]portSettings{
+				"00:11:22:33:44:aa": {pxeEnabled: true, portGroup: "bond0", managed: true},
+				"00:11:22:33:44:bb": {portGroup: "bond0", managed: true},
+			},
+		},
+		{
+			name: "inspected",
+			spec: metal3v1alpha1.BareMetalHostSpec{
+				PortGroups: []metal3v1alpha1.PortGroup{
+					{
+						Name:         "bond0",
+						MACAddresses: []string{"00:11:22:33:44:aa", "00:11:22:33:44:bb"},
+					},
+				},
+			},
+			details: &metal3v1alpha1.HardwareDetails{
+				NIC: []metal3v1alpha1.NIC{
+					{MAC: "00:11:22:33:44:aa", PXE: true},
+					{MAC: "00:11:22:33:44:bb"},
+					{MAC: "00:11:22:33:44:cc"},
+				},
+			},
+			expected: map[string]portSettings{
+				"00:11:22:33:44:aa": {pxeEnabled: true, portGroup: "bond0", managed: true},
+				"00:11:22:33:44:bb": {portGroup: "bond0", managed: true},
+			},
+		},
+		{
+			name: "inspected PXE NIC",
+			spec: metal3v1alpha1.BareMetalHostSpec{
+				BootMACAddress: "00:11:22:33:44:aa",
+			},
+			details: &metal3v1alpha1.HardwareDetails{
+				NIC: []metal3v1alpha1.NIC{
+					{MAC: "00:11:22:33:44:aa", PXE: true},
+					{MAC: "00:11:22:33:44:bb", PXE: true},
+				},
+			},
+			expected: map[string]portSettings{
+				"00:11:22:33:44:aa": {pxeEnabled: true, managed: true},
+				"00:11:22:33:44:bb": {pxeEnabled: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec = tc.spec
+			assert.Equal(t, tc.expected, buildPortSettings(host, tc.details))
+		})
+	}
+}
+
+func TestReconcilePorts(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	var calls []string
+	bodies := map[string]interface{}{}
+	record := func(r *http.Request) {
+		call := r.Method + " " + r.URL.Path
+		calls = append(calls, call)
+		var body interface{}
+		if json.NewDecoder(r.Body).Decode(&body) == nil {
+			bodies[call] = body
+		}
+	}
+
+	ironic := testserver.NewIronic(t).Ready()
+	ironic.Handler("/v1/portgroups/detail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"portgroups": []portGroup{
+				{
+					UUID:     "old-group-uuid",
+					NodeUUID: nodeUUID,
+					Extra:    map[string]interface{}{portGroupExtraKey: "old"},
+				},
+			},
+		}))
+	})
+	ironic.Handler("/v1/portgroups", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, json.NewEncoder(w).Encode(portGroup{UUID: "bond0-uuid"}))
+	})
+	ironic.Handler("/v1/portgroups/", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ironic.Handler("/v1/ports/detail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"ports": []ports.Port{
+				// The port of the boot NIC, created before it was
+				// bonded.
+				{UUID: "boot-uuid", Address: "00:11:22:33:44:aa", PXEEnabled: true},
+				// A port created for a NIC no longer listed.
+				{UUID: "removed-uuid", Address: "00:11:22:33:44:dd",
+					Extra: map[string]interface{}{portExtraKey: true}},
+				// A port found by inspection, in a port group no
+				// longer listed.
+				{UUID: "inspected-uuid", Address: "00:11:22:33:44:ee",
+					PortGroupUUID: "old-group-uuid"},
+			},
+		}))
+	})
+	ironic.Handler("/v1/ports", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, json.NewEncoder(w).Encode(ports.Port{UUID: "new-uuid"}))
+	})
+	ironic.Handler("/v1/ports/", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(ports.Port{UUID: "updated-uuid"}))
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.BootMACAddress = "00:11:22:33:44:AA"
+	host.Spec.PortGroups = []metal3v1alpha1.PortGroup{
+		{
+			Name:         "bond0",
+			Mode:         metal3v1alpha1.BondModeLACP,
+			MACAddresses: []string{"00:11:22:33:44:aa", "00:11:22:33:44:bb"},
+		},
+	}
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	err = prov.reconcilePorts(nodeUUID, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"POST /v1/portgroups",
+		"PATCH /v1/ports/boot-uuid",
+		"DELETE /v1/ports/removed-uuid",
+		"PATCH /v1/ports/inspected-uuid",
+		"POST /v1/ports",
+		"DELETE /v1/portgroups/old-group-uuid",
+	}, calls)
+	assert.Equal(t, map[string]interface{}{
+		"node_uuid":                  nodeUUID,
+		"address":                    "00:11:22:33:44:aa",
+		"mode":                       "802.3ad",
+		"standalone_ports_supported": false,
+		"extra":                      map[string]interface{}{portGroupExtraKey: "bond0"},
+	}, bodies["POST /v1/portgroups"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"op": "add", "path": "/portgroup_uuid", "value": "bond0-uuid"},
+	}, bodies["PATCH /v1/ports/boot-uuid"])
+	assert.Equal(t, map[string]interface{}{
+		"node_uuid":      nodeUUID,
+		"address":        "00:11:22:33:44:bb",
+		"portgroup_uuid": "bond0-uuid",
+		"pxe_enabled":    false,
+		"extra":          map[string]interface{}{portExtraKey: true},
+	}, bodies["POST /v1/ports"])
+}
@@ -0,0 +1,299 @@
+package ironic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestPrepare(t *testing.T) {
+
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	hardwareRAID := &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+			{Name: "root", Level: "1"},
+		},
+	}
+	softwareRAID := &metal3v1alpha1.RAIDConfig{
+		SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+			{Level: "1"},
+		},
+	}
+
+	enabled := true
+	firmware := &metal3v1alpha1.FirmwareConfig{
+		VirtualizationEnabled: &enabled,
+		Settings:              map[string]string{"BootMode": "Uefi"},
+	}
+
+	cases := []struct {
+		name        string
+		ironic      *testserver.IronicMock
+		raid        *metal3v1alpha1.RAIDConfig
+		appliedRAID *metal3v1alpha1.RAIDConfig
+		firmware    *metal3v1alpha1.FirmwareConfig
+		bmcAddress  string
+		unprepared  bool
+
+		expectedStarted      bool
+		expectedDirty        bool
+		expectedRequestAfter int
+		expectedResultError  string
+		expectedPublish      string
+		expectedRequests     string
+		expectedBIOSSettings map[string]string
+	}{
+		{
+			name: "manageable-start-cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				RAIDInterface:  "idrac",
+			}).WithNodeStatesRAID(nodeUUID).WithNodeStatesProvision(nodeUUID),
+			raid:       hardwareRAID,
+			unprepared: true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "PreparationStarted Applying the RAID configuration",
+			expectedRequests: "/v1/nodes/" + nodeUUID + ";" +
+				"/v1/nodes/" + nodeUUID + "/states/raid;" +
+				"/v1/nodes/" + nodeUUID + "/states/provision;",
+		},
+		{
+			name: "manageable-prepared",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+			raid: hardwareRAID,
+		},
+		{
+			name: "manageable-start-bios-cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}).WithNodeStatesProvision(nodeUUID),
+			firmware:   firmware,
+			bmcAddress: "idrac://192.168.122.1",
+			unprepared: true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "PreparationStarted Applying the BIOS settings",
+			expectedRequests: "/v1/nodes/" + nodeUUID + ";" +
+				"/v1/nodes/" + nodeUUID + "/states/provision;",
+		},
+		{
+			name: "manageable-start-raid-and-bios-cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				RAIDInterface:  "idrac",
+			}).WithNodeStatesRAID(nodeUUID).WithNodeStatesProvision(nodeUUID),
+			raid:       hardwareRAID,
+			firmware:   firmware,
+			bmcAddress: "idrac://192.168.122.1",
+			unprepared: true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "PreparationStarted Applying the RAID configuration",
+			expectedRequests: "/v1/nodes/" + nodeUUID + ";" +
+				"/v1/nodes/" + nodeUUID + "/states/raid;" +
+				"/v1/nodes/" + nodeUUID + "/states/provision;",
+		},
+		{
+			name: "manageable-start-bios-cleaning-after-raid",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				RAIDInterface:  "idrac",
+			}).WithNodeStatesProvision(nodeUUID),
+			raid:        hardwareRAID,
+			appliedRAID: hardwareRAID,
+			firmware:    firmware,
+			bmcAddress:  "idrac://192.168.122.1",
+			unprepared:  true,
+
+			expectedStarted:      true,
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+			expectedPublish:      "PreparationStarted Applying the BIOS settings",
+			expectedRequests: "/v1/nodes/" + nodeUUID + ";" +
+				"/v1/nodes/" + nodeUUID + "/states/provision;",
+		},
+		{
+			name: "unsupported-firmware-settings",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+			firmware:   firmware,
+			bmcAddress: "ipmi://192.168.122.1",
+			unprepared: true,
+
+			expectedResultError: "Invalid firmware settings: the ipmi driver does not support the well-known firmware settings, use vendor specific settings instead",
+		},
+		{
+			name: "manageable-prepared-bios-settings",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}).WithBIOSSettings(nodeUUID, map[string]string{
+				"ProcVirtualization": "Enabled",
+				"BootMode":           "Uefi",
+				"LogicalProc":        "Disabled",
+			}),
+			firmware:   firmware,
+			bmcAddress: "idrac://192.168.122.1",
+
+			expectedBIOSSettings: map[string]string{
+				"ProcVirtualization": "Enabled",
+				"BootMode":           "Uefi",
+			},
+		},
+		{
+			name: "available-manage-first",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Available),
+			}).WithNodeStatesProvision(nodeUUID),
+			raid:       hardwareRAID,
+			unprepared: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "no-raid-interface",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				Driver:         "ipmi",
+				RAIDInterface:  "no-raid",
+			}),
+			raid:       hardwareRAID,
+			unprepared: true,
+
+			expectedResultError: "RAID settings are defined, but the driver ipmi of the host does not support RAID",
+		},
+		{
+			name: "software-raid-interface",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				RAIDInterface:  "no-raid",
+			}),
+			raid:       softwareRAID,
+			unprepared: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "invalid-raid",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+			raid: &metal3v1alpha1.RAIDConfig{
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{Level: "0"},
+				},
+			},
+			unprepared: true,
+
+			expectedResultError: "Invalid RAID configuration: the first software RAID volume must be RAID-1, got \"0\"",
+		},
+		{
+			name: "cleaning",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanWait),
+			}),
+			raid: hardwareRAID,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+		{
+			name: "clean-failed",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanFail),
+				LastError:      "no disks",
+			}),
+			raid: hardwareRAID,
+
+			expectedResultError: "Host preparation failed: no disks",
+		},
+		{
+			name: "clean-failed-retry",
+			ironic: testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanFail),
+				LastError:      "no disks",
+			}).WithNodeStatesProvision(nodeUUID),
+			raid:       hardwareRAID,
+			unprepared: true,
+
+			expectedDirty:        true,
+			expectedRequestAfter: 10,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.ironic != nil {
+				tc.ironic.Start()
+				defer tc.ironic.Stop()
+			}
+
+			host := makeHost()
+			host.Spec.RAID = tc.raid
+			host.Status.Provisioning.RAID = tc.appliedRAID
+			host.Spec.Firmware = tc.firmware
+			if tc.bmcAddress != "" {
+				host.Spec.BMC.Address = tc.bmcAddress
+			}
+			host.Status.HardwareSummary = &metal3v1alpha1.HardwareSummary{}
+			publishedMsg := ""
+			publisher := func(reason, message string) {
+				publishedMsg = reason + " " + message
+			}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			result, started, err := prov.Prepare(tc.unprepared)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStarted, started)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
+			assert.Equal(t, tc.expectedResultError, result.ErrorMessage)
+			assert.Equal(t, tc.expectedPublish, publishedMsg)
+			if tc.expectedRequests != "" {
+				assert.Equal(t, tc.expectedRequests, tc.ironic.Requests)
+			}
+			assert.Equal(t, tc.expectedBIOSSettings, host.Status.HardwareSummary.Firmware.BIOSSettings)
+		})
+	}
+}
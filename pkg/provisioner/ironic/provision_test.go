@@ -0,0 +1,164 @@
+package ironic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+type staticHostConfigData struct {
+	userData    string
+	networkData string
+	metaData    string
+}
+
+func (d staticHostConfigData) UserData() (string, error) {
+	return d.userData, nil
+}
+
+func (d staticHostConfigData) NetworkData() (string, error) {
+	return d.networkData, nil
+}
+
+func (d staticHostConfigData) MetaData() (string, error) {
+	return d.metaData, nil
+}
+
+func TestProvisionConfigDrive(t *testing.T) {
+	configDrive := map[string]interface{}{
+		"user_data": "#cloud-config\n",
+		"meta_data": map[string]interface{}{
+			"uuid":             "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+			"metal3-namespace": "myns",
+			"metal3-name":      "myhost",
+			"local-hostname":   "myhost",
+			"local_hostname":   "myhost",
+		},
+	}
+	metadataService := map[string]string{
+		metal3v1alpha1.ConfigDataAnnotation: metal3v1alpha1.ConfigDataMetadataService,
+	}
+	partitionImage := func(image *metal3v1alpha1.Image) {
+		image.Kernel = "http://example.test/images/vmlinuz"
+		image.Ramdisk = "http://example.test/images/initrd"
+	}
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		image       func(*metal3v1alpha1.Image)
+		metadataURL string
+		expected    interface{}
+	}{
+		{
+			name:     "config drive",
+			expected: configDrive,
+		},
+		{
+			name:        "metadata service",
+			annotations: metadataService,
+			image:       partitionImage,
+			metadataURL: "http://192.168.111.1:8089",
+		},
+		{
+			name:        "metadata service with a whole-disk image",
+			annotations: metadataService,
+			metadataURL: "http://192.168.111.1:8089",
+			expected:    configDrive,
+		},
+		{
+			name:        "metadata service disabled",
+			annotations: metadataService,
+			image:       partitionImage,
+			expected:    configDrive,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body map[string]interface{}
+			ironic := testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+				UUID:           "provisioning-id",
+				ProvisionState: string(nodes.Available),
+			})
+			ironic.Handler("/v1/nodes/provisioning-id/states/provision", func(w http.ResponseWriter, r *http.Request) {
+				content, _ := ioutil.ReadAll(r.Body)
+				json.Unmarshal(content, &body)
+				w.WriteHeader(http.StatusAccepted)
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Annotations = tc.annotations
+			if tc.image != nil {
+				tc.image(host.Spec.Image)
+			}
+			SetMetadataURL(tc.metadataURL)
+			defer SetMetadataURL("")
+			eventPublisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(staticHostConfigData{userData: "#cloud-config\n"})
+
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+			assert.Equal(t, "active", body["target"])
+			assert.Equal(t, tc.expected, body["configdrive"])
+		})
+	}
+}
+
+func TestProvisionActiveRecordsChecksum(t *testing.T) {
+	ironic := testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+		UUID:           "provisioning-id",
+		ProvisionState: string(nodes.Active),
+		InstanceInfo: map[string]interface{}{
+			"image_source":        "http://example.test/images/image.qcow2",
+			"image_os_hash_algo":  "sha256",
+			"image_os_hash_value": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	// The checksum file cannot be fetched, the checksum must come
+	// from the node.
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:      "http://example.test/images/image.qcow2",
+		Checksum: "http://127.0.0.1:0/SHA256SUMS",
+	}
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, err := prov.Provision(staticHostConfigData{})
+
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+	assert.Equal(t, "http://example.test/images/image.qcow2", host.Status.Provisioning.Image.URL)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		host.Status.Provisioning.Image.Checksum)
+	assert.Equal(t, metal3v1alpha1.SHA256, host.Status.Provisioning.Image.ChecksumType)
+}
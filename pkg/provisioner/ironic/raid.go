@@ -0,0 +1,100 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/devicehints"
+)
+
+const (
+	// softwareRAIDInterface is the raid interface Ironic uses to
+	// build software RAID devices through the agent.
+	softwareRAIDInterface = "agent"
+
+	// noRAIDInterface is the raid interface of drivers without RAID
+	// support.
+	noRAIDInterface = "no-raid"
+)
+
+// buildTargetRAIDConfig converts the RAID settings of a host into the
+// logical disks of the Ironic target_raid_config. An empty list of
+// logical disks removes the existing configuration.
+func buildTargetRAIDConfig(raid *metal3v1alpha1.RAIDConfig) (logicalDisks []nodes.LogicalDisk, err error) {
+	logicalDisks = []nodes.LogicalDisk{}
+	if raid == nil {
+		return logicalDisks, nil
+	}
+
+	if len(raid.HardwareRAIDVolumes) != 0 && len(raid.SoftwareRAIDVolumes) != 0 {
+		return nil, fmt.Errorf("hardware and software RAID volumes cannot be combined")
+	}
+
+	for _, volume := range raid.HardwareRAIDVolumes {
+		disk := nodes.LogicalDisk{
+			SizeGB:     volume.SizeGibibytes,
+			RAIDLevel:  nodes.RAIDLevel(volume.Level),
+			VolumeName: volume.Name,
+			Controller: volume.Controller,
+		}
+		if volume.Rotational != nil {
+			if *volume.Rotational {
+				disk.DiskType = nodes.HDD
+			} else {
+				disk.DiskType = nodes.SSD
+			}
+		}
+		if volume.NumberOfPhysicalDisks != nil {
+			disk.NumberOfPhysicalDisks = *volume.NumberOfPhysicalDisks
+		}
+		for _, physicalDisk := range volume.PhysicalDisks {
+			disk.PhysicalDisks = append(disk.PhysicalDisks, physicalDisk)
+		}
+		logicalDisks = append(logicalDisks, disk)
+	}
+
+	if len(raid.SoftwareRAIDVolumes) > 2 {
+		return nil, fmt.Errorf("at most 2 software RAID volumes can be created, got %d",
+			len(raid.SoftwareRAIDVolumes))
+	}
+	for i, volume := range raid.SoftwareRAIDVolumes {
+		// The image is written to the first device, so insist on a
+		// level that survives the loss of a disk.
+		if i == 0 && volume.Level != string(nodes.RAID1) {
+			return nil, fmt.Errorf("the first software RAID volume must be RAID-1, got %q",
+				volume.Level)
+		}
+		disk := nodes.LogicalDisk{
+			SizeGB:     volume.SizeGibibytes,
+			RAIDLevel:  nodes.RAIDLevel(volume.Level),
+			Controller: "software",
+		}
+		for j := range volume.PhysicalDisks {
+			disk.PhysicalDisks = append(disk.PhysicalDisks,
+				devicehints.MakeHintMap(&volume.PhysicalDisks[j]))
+		}
+		logicalDisks = append(logicalDisks, disk)
+	}
+
+	return logicalDisks, nil
+}
+
+// buildRAIDCleanSteps returns the manual clean steps that replace the
+// existing RAID configuration of a node with its target_raid_config.
+func buildRAIDCleanSteps(logicalDisks []nodes.LogicalDisk) []nodes.CleanStep {
+	steps := []nodes.CleanStep{
+		{
+			Interface: "raid",
+			Step:      "delete_configuration",
+		},
+	}
+	if len(logicalDisks) != 0 {
+		steps = append(steps, nodes.CleanStep{
+			Interface: "raid",
+			Step:      "create_configuration",
+		})
+	}
+	return steps
+}
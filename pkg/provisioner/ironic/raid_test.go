@@ -0,0 +1,154 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestBuildTargetRAIDConfig(t *testing.T) {
+	size := 100
+	count := 2
+	rotational := false
+
+	testCases := []struct {
+		Scenario      string
+		RAID          *metal3v1alpha1.RAIDConfig
+		Expected      []nodes.LogicalDisk
+		ExpectedError string
+	}{
+		{
+			Scenario: "no settings",
+			Expected: []nodes.LogicalDisk{},
+		},
+		{
+			Scenario: "no volumes",
+			RAID:     &metal3v1alpha1.RAIDConfig{},
+			Expected: []nodes.LogicalDisk{},
+		},
+		{
+			Scenario: "hardware",
+			RAID: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{
+						Name:                  "root",
+						Level:                 "1+0",
+						SizeGibibytes:         &size,
+						Rotational:            &rotational,
+						Controller:            "RAID.Integrated.1-1",
+						PhysicalDisks:         []string{"disk-1", "disk-2"},
+						NumberOfPhysicalDisks: &count,
+					},
+					{
+						Level: "5",
+					},
+				},
+			},
+			Expected: []nodes.LogicalDisk{
+				{
+					SizeGB:                &size,
+					RAIDLevel:             nodes.RAID10,
+					VolumeName:            "root",
+					DiskType:              nodes.SSD,
+					NumberOfPhysicalDisks: 2,
+					Controller:            "RAID.Integrated.1-1",
+					PhysicalDisks:         []interface{}{"disk-1", "disk-2"},
+				},
+				{
+					RAIDLevel: nodes.RAID5,
+				},
+			},
+		},
+		{
+			Scenario: "software",
+			RAID: &metal3v1alpha1.RAIDConfig{
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{
+						Level:         "1",
+						SizeGibibytes: &size,
+						PhysicalDisks: []metal3v1alpha1.RootDeviceHints{
+							{DeviceName: "/dev/sda"},
+							{DeviceName: "/dev/sdb"},
+						},
+					},
+					{
+						Level: "0",
+					},
+				},
+			},
+			Expected: []nodes.LogicalDisk{
+				{
+					SizeGB:     &size,
+					RAIDLevel:  nodes.RAID1,
+					Controller: "software",
+					PhysicalDisks: []interface{}{
+						map[string]string{"name": "s== /dev/sda"},
+						map[string]string{"name": "s== /dev/sdb"},
+					},
+				},
+				{
+					RAIDLevel:  nodes.RAID0,
+					Controller: "software",
+				},
+			},
+		},
+		{
+			Scenario: "software first volume not mirrored",
+			RAID: &metal3v1alpha1.RAIDConfig{
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{Level: "0"},
+				},
+			},
+			ExpectedError: "the first software RAID volume must be RAID-1, got \"0\"",
+		},
+		{
+			Scenario: "too many software volumes",
+			RAID: &metal3v1alpha1.RAIDConfig{
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{Level: "1"}, {Level: "1"}, {Level: "1"},
+				},
+			},
+			ExpectedError: "at most 2 software RAID volumes can be created, got 3",
+		},
+		{
+			Scenario: "hardware and software",
+			RAID: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{Level: "1"},
+				},
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{Level: "1"},
+				},
+			},
+			ExpectedError: "hardware and software RAID volumes cannot be combined",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := buildTargetRAIDConfig(tc.RAID)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestBuildRAIDCleanSteps(t *testing.T) {
+	steps := buildRAIDCleanSteps([]nodes.LogicalDisk{})
+	assert.Equal(t, []nodes.CleanStep{
+		{Interface: "raid", Step: "delete_configuration"},
+	}, steps)
+
+	steps = buildRAIDCleanSteps([]nodes.LogicalDisk{{RAIDLevel: nodes.RAID1}})
+	assert.Equal(t, []nodes.CleanStep{
+		{Interface: "raid", Step: "delete_configuration"},
+		{Interface: "raid", Step: "create_configuration"},
+	}, steps)
+}
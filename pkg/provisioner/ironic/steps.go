@@ -0,0 +1,153 @@
+package ironic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// deployStepsMicroversion is the first version of the Ironic API that
+// accepts deploy steps when a node is deployed.
+const deployStepsMicroversion = "1.69"
+
+// deployStep is a deploy step passed to Ironic when a node is
+// deployed.
+type deployStep struct {
+	Interface string                 `json:"interface"`
+	Step      string                 `json:"step"`
+	Args      map[string]interface{} `json:"args"`
+	Priority  int                    `json:"priority"`
+}
+
+// deployStateOpts adds the deploy steps, which gophercloud does not
+// know about, to the options of a provision state change.
+type deployStateOpts struct {
+	nodes.ProvisionStateOpts
+	DeploySteps []deployStep
+}
+
+// ToProvisionStateMap implements nodes.ProvisionStateOptsBuilder.
+func (opts deployStateOpts) ToProvisionStateMap() (map[string]interface{}, error) {
+	body, err := opts.ProvisionStateOpts.ToProvisionStateMap()
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.DeploySteps) != 0 {
+		body["deploy_steps"] = opts.DeploySteps
+	}
+	return body, nil
+}
+
+// buildStepArgs converts the arguments of a custom step. Ironic
+// expects an object, even when a step takes no arguments.
+func buildStepArgs(args *runtime.RawExtension) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if args == nil || len(args.Raw) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(args.Raw, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// buildDeploySteps converts the custom deploy steps of a host to the
+// form Ironic expects.
+func buildDeploySteps(steps []metal3v1alpha1.CustomDeployStep) ([]deployStep, error) {
+	result := make([]deployStep, 0, len(steps))
+	for _, step := range steps {
+		args, err := buildStepArgs(step.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for deploy step %s.%s: %s",
+				step.Interface, step.Step, err)
+		}
+		result = append(result, deployStep{
+			Interface: step.Interface,
+			Step:      step.Step,
+			Args:      args,
+			Priority:  step.Priority,
+		})
+	}
+	return result, nil
+}
+
+// buildCustomCleanSteps converts the custom clean steps of a host to
+// manual clean steps.
+func buildCustomCleanSteps(steps []metal3v1alpha1.CustomCleanStep) ([]nodes.CleanStep, error) {
+	result := make([]nodes.CleanStep, 0, len(steps))
+	for _, step := range steps {
+		args, err := buildStepArgs(step.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for clean step %s.%s: %s",
+				step.Interface, step.Step, err)
+		}
+		result = append(result, nodes.CleanStep{
+			Interface: step.Interface,
+			Step:      step.Step,
+			Args:      args,
+		})
+	}
+	return result, nil
+}
+
+// stepName returns the name of a step reported by Ironic, as
+// interface.step.
+func stepName(step map[string]interface{}) string {
+	iface, _ := step["interface"].(string)
+	name, _ := step["step"].(string)
+	if iface == "" || name == "" {
+		return ""
+	}
+	return iface + "." + name
+}
+
+// updateStepsStatus records the progress of custom steps given the
+// step Ironic reports as running. Steps that are not custom ones, such
+// as the default deploy steps, leave the progress alone.
+func updateStepsStatus(status *metal3v1alpha1.StepsStatus, names []string, current map[string]interface{}) {
+	name := stepName(current)
+	for i, custom := range names {
+		if custom == name {
+			status.Current = name
+			status.Completed = i
+			return
+		}
+	}
+}
+
+// deployStepNames returns the names of the custom deploy steps of a
+// host, as interface.step.
+func deployStepNames(steps []metal3v1alpha1.CustomDeployStep) (names []string) {
+	for _, step := range steps {
+		names = append(names, step.Interface+"."+step.Step)
+	}
+	return
+}
+
+// cleanStepNames returns the names of the custom clean steps of a
+// host, as interface.step.
+func cleanStepNames(steps []metal3v1alpha1.CustomCleanStep) (names []string) {
+	for _, step := range steps {
+		names = append(names, step.Interface+"."+step.Step)
+	}
+	return
+}
+
+// buildManualCleanSteps returns the manual clean steps to run once a
+// host is deprovisioned: erasing its disks in full cleaning mode,
+// followed by its custom clean steps.
+func buildManualCleanSteps(host *metal3v1alpha1.BareMetalHost) ([]nodes.CleanStep, error) {
+	var steps []nodes.CleanStep
+	if host.AutomatedCleaningMode() == metal3v1alpha1.CleaningModeFull {
+		steps = append(steps, nodes.CleanStep{Interface: "deploy", Step: "erase_devices"})
+	}
+	custom, err := buildCustomCleanSteps(host.Spec.CustomCleanSteps)
+	if err != nil {
+		return nil, err
+	}
+	return append(steps, custom...), nil
+}
@@ -0,0 +1,250 @@
+package ironic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestBuildDeploySteps(t *testing.T) {
+	cases := []struct {
+		name          string
+		steps         []metal3v1alpha1.CustomDeployStep
+		expected      []deployStep
+		expectedError string
+	}{
+		{
+			name:     "none",
+			expected: []deployStep{},
+		},
+		{
+			name: "with and without args",
+			steps: []metal3v1alpha1.CustomDeployStep{
+				{
+					Interface: "deploy",
+					Step:      "write_image",
+					Priority:  80,
+				},
+				{
+					Interface: "vendor",
+					Step:      "post_install",
+					Args:      &runtime.RawExtension{Raw: []byte(`{"script": "finish.sh", "retries": 2}`)},
+					Priority:  10,
+				},
+			},
+			expected: []deployStep{
+				{
+					Interface: "deploy",
+					Step:      "write_image",
+					Args:      map[string]interface{}{},
+					Priority:  80,
+				},
+				{
+					Interface: "vendor",
+					Step:      "post_install",
+					Args:      map[string]interface{}{"script": "finish.sh", "retries": float64(2)},
+					Priority:  10,
+				},
+			},
+		},
+		{
+			name: "args not an object",
+			steps: []metal3v1alpha1.CustomDeployStep{
+				{
+					Interface: "vendor",
+					Step:      "post_install",
+					Args:      &runtime.RawExtension{Raw: []byte(`["finish.sh"]`)},
+					Priority:  10,
+				},
+			},
+			expectedError: "invalid arguments for deploy step vendor.post_install",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			steps, err := buildDeploySteps(tc.steps)
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, steps)
+		})
+	}
+}
+
+func TestBuildManualCleanSteps(t *testing.T) {
+	cases := []struct {
+		name         string
+		cleaningMode metal3v1alpha1.AutomatedCleaningMode
+		steps        []metal3v1alpha1.CustomCleanStep
+		expected     []nodes.CleanStep
+	}{
+		{
+			name:         "metadata",
+			cleaningMode: metal3v1alpha1.CleaningModeMetadata,
+			expected:     []nodes.CleanStep{},
+		},
+		{
+			name:         "full",
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+			expected: []nodes.CleanStep{
+				{Interface: "deploy", Step: "erase_devices"},
+			},
+		},
+		{
+			name:         "full with custom steps",
+			cleaningMode: metal3v1alpha1.CleaningModeFull,
+			steps: []metal3v1alpha1.CustomCleanStep{
+				{
+					Interface: "deploy",
+					Step:      "burnin_cpu",
+					Args:      &runtime.RawExtension{Raw: []byte(`{"timeout": 600}`)},
+				},
+			},
+			expected: []nodes.CleanStep{
+				{Interface: "deploy", Step: "erase_devices"},
+				{Interface: "deploy", Step: "burnin_cpu", Args: map[string]interface{}{"timeout": float64(600)}},
+			},
+		},
+		{
+			name:         "disabled with custom steps",
+			cleaningMode: metal3v1alpha1.CleaningModeDisabled,
+			steps: []metal3v1alpha1.CustomCleanStep{
+				{Interface: "management", Step: "clear_job_queue"},
+			},
+			expected: []nodes.CleanStep{
+				{Interface: "management", Step: "clear_job_queue", Args: map[string]interface{}{}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.AutomatedCleaningMode = tc.cleaningMode
+			host.Spec.CustomCleanSteps = tc.steps
+
+			steps, err := buildManualCleanSteps(host)
+			assert.NoError(t, err)
+			if len(tc.expected) == 0 {
+				assert.Empty(t, steps)
+				return
+			}
+			assert.Equal(t, tc.expected, steps)
+		})
+	}
+}
+
+func TestUpdateStepsStatus(t *testing.T) {
+	names := []string{"deploy.write_image", "vendor.post_install"}
+
+	cases := []struct {
+		name     string
+		status   metal3v1alpha1.StepsStatus
+		current  map[string]interface{}
+		expected metal3v1alpha1.StepsStatus
+	}{
+		{
+			name:     "first step",
+			status:   metal3v1alpha1.StepsStatus{Total: 2},
+			current:  map[string]interface{}{"interface": "deploy", "step": "write_image"},
+			expected: metal3v1alpha1.StepsStatus{Current: "deploy.write_image", Completed: 0, Total: 2},
+		},
+		{
+			name:     "second step",
+			status:   metal3v1alpha1.StepsStatus{Current: "deploy.write_image", Total: 2},
+			current:  map[string]interface{}{"interface": "vendor", "step": "post_install"},
+			expected: metal3v1alpha1.StepsStatus{Current: "vendor.post_install", Completed: 1, Total: 2},
+		},
+		{
+			name:     "default step",
+			status:   metal3v1alpha1.StepsStatus{Current: "deploy.write_image", Total: 2},
+			current:  map[string]interface{}{"interface": "deploy", "step": "prepare_instance_boot"},
+			expected: metal3v1alpha1.StepsStatus{Current: "deploy.write_image", Total: 2},
+		},
+		{
+			name:     "no step",
+			status:   metal3v1alpha1.StepsStatus{Total: 2},
+			current:  map[string]interface{}{},
+			expected: metal3v1alpha1.StepsStatus{Total: 2},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			updateStepsStatus(&tc.status, names, tc.current)
+			assert.Equal(t, tc.expected, tc.status)
+		})
+	}
+}
+
+func TestDeployWithCustomSteps(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	var microversion string
+	var body map[string]interface{}
+	ironic := testserver.NewIronic(t).Ready().WithNode(nodes.Node{
+		UUID:           nodeUUID,
+		ProvisionState: string(nodes.Available),
+	})
+	ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", func(w http.ResponseWriter, r *http.Request) {
+		microversion = r.Header.Get("X-OpenStack-Ironic-API-Version")
+		content, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(content, &body)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.CustomDeploySteps = []metal3v1alpha1.CustomDeployStep{
+		{
+			Interface: "vendor",
+			Step:      "post_install",
+			Args:      &runtime.RawExtension{Raw: []byte(`{"script": "finish.sh"}`)},
+			Priority:  10,
+		},
+	}
+	publishedMsg := ""
+	publisher := func(reason, message string) {
+		publishedMsg = reason + " " + message
+	}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, err := prov.deployWithCustomSteps(&nodes.Node{UUID: nodeUUID},
+		nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	assert.Equal(t, deployStepsMicroversion, microversion)
+	assert.Equal(t, "active", body["target"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"interface": "vendor",
+			"step":      "post_install",
+			"args":      map[string]interface{}{"script": "finish.sh"},
+			"priority":  float64(10),
+		},
+	}, body["deploy_steps"])
+	assert.Equal(t, &metal3v1alpha1.StepsStatus{Total: 1}, host.Status.Provisioning.DeploySteps)
+	assert.Equal(t, "CustomDeployStepsStarted Running 1 custom deploy steps", publishedMsg)
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 )
 
 // IronicMock is a test server that implements Ironic's semantics
@@ -70,8 +71,48 @@ func (m *IronicMock) WithNode(node nodes.Node) *IronicMock {
 	return m
 }
 
+// WithPorts configures the server with a valid response for
+// /v1/ports/detail listing the ports, and for /v1/portgroups/detail
+// listing no port groups
+func (m *IronicMock) WithPorts(nodePorts ...ports.Port) *IronicMock {
+	if nodePorts == nil {
+		nodePorts = []ports.Port{}
+	}
+	m.ResponseJSON("/v1/ports/detail", map[string]interface{}{"ports": nodePorts})
+	m.Response("/v1/portgroups/detail", `{"portgroups": []}`)
+	return m
+}
+
 // WithNodeStatesProvision configures the server with a valid response for /v1/nodes/<node>/states/provision
 func (m *IronicMock) WithNodeStatesProvision(nodeUUID string) *IronicMock {
 	m.ResponseWithCode("/v1/nodes/"+nodeUUID+"/states/provision", "{}", http.StatusAccepted)
 	return m
 }
+
+// WithNodeStatesRAID configures the server with a valid response for /v1/nodes/<node>/states/raid
+func (m *IronicMock) WithNodeStatesRAID(nodeUUID string) *IronicMock {
+	m.ResponseWithCode("/v1/nodes/"+nodeUUID+"/states/raid", "", http.StatusNoContent)
+	return m
+}
+
+// WithBIOSSettings configures the server with a valid response for /v1/nodes/<node>/bios
+func (m *IronicMock) WithBIOSSettings(nodeUUID string, settings map[string]string) *IronicMock {
+	type setting struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	var body struct {
+		BIOS []setting `json:"bios"`
+	}
+	for name, value := range settings {
+		body.BIOS = append(body.BIOS, setting{Name: name, Value: value})
+	}
+	m.ResponseJSON("/v1/nodes/"+nodeUUID+"/bios", body)
+	return m
+}
+
+// WithFirmware configures the server with a valid response for /v1/nodes/<node>/firmware
+func (m *IronicMock) WithFirmware(nodeUUID string, body string) *IronicMock {
+	m.Response("/v1/nodes/"+nodeUUID+"/firmware", body)
+	return m
+}
@@ -2,6 +2,8 @@ package ironic
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
@@ -278,3 +280,392 @@ func TestGetUpdateOptsForNodeDell(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUpdateOptsForNodeLiveISO(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			UID:       "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			Image: &metal3v1alpha1.Image{
+				URL:        "http://example.test/image.iso",
+				DiskFormat: pointer.StringPtr(metal3v1alpha1.LiveISODiskFormat),
+			},
+			Online: true,
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			HardwareProfile: "libvirt",
+			Provisioning: metal3v1alpha1.ProvisionStatus{
+				ID: "provisioning-id",
+			},
+		},
+	}
+
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		"https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The node still has the settings of a disk image provisioned
+	// before.
+	ironicNode := &nodes.Node{
+		InstanceInfo: map[string]interface{}{
+			"image_source":        "http://example.test/image.qcow2",
+			"image_os_hash_algo":  "md5",
+			"image_os_hash_value": "checksum",
+		},
+	}
+
+	patches, err := prov.getUpdateOptsForNode(ironicNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("patches: %v", patches)
+
+	expected := []struct {
+		Path  string         // the node property path
+		Op    nodes.UpdateOp // the operation on the property
+		Value interface{}    // the value being passed to ironic
+	}{
+		{
+			Path:  "/deploy_interface",
+			Op:    nodes.ReplaceOp,
+			Value: "ramdisk",
+		},
+		{
+			Path:  "/instance_info/boot_iso",
+			Op:    nodes.AddOp,
+			Value: "http://example.test/image.iso",
+		},
+		{
+			Path: "/instance_info/image_source",
+			Op:   nodes.RemoveOp,
+		},
+		{
+			Path: "/instance_info/image_os_hash_algo",
+			Op:   nodes.RemoveOp,
+		},
+		{
+			Path: "/instance_info/image_os_hash_value",
+			Op:   nodes.RemoveOp,
+		},
+		{
+			Path:  "/instance_uuid",
+			Op:    nodes.ReplaceOp,
+			Value: "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		},
+	}
+
+	for _, e := range expected {
+		t.Run(e.Path, func(t *testing.T) {
+			t.Logf("expected: %v", e)
+			var update nodes.UpdateOperation
+			for _, patch := range patches {
+				update = patch.(nodes.UpdateOperation)
+				if update.Path == e.Path {
+					break
+				}
+			}
+			if update.Path != e.Path {
+				t.Errorf("did not find %q in updates", e.Path)
+				return
+			}
+			t.Logf("update: %v", update)
+			assert.Equal(t, e.Op, update.Op, fmt.Sprintf("%s does not match", e.Path))
+			assert.Equal(t, e.Value, update.Value, fmt.Sprintf("%s does not match", e.Path))
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeChecksumURL(t *testing.T) {
+	checksumServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  image.qcow2")
+		fmt.Fprintln(w, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  other.qcow2")
+	}))
+	defer checksumServer.Close()
+
+	host := &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			UID:       "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			Image: &metal3v1alpha1.Image{
+				URL:      "http://example.test/images/image.qcow2",
+				Checksum: checksumServer.URL + "/SHA256SUMS",
+			},
+			Online: true,
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			HardwareProfile: "libvirt",
+			Provisioning: metal3v1alpha1.ProvisionStatus{
+				ID: "provisioning-id",
+			},
+		},
+	}
+
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		"https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ironicNode := &nodes.Node{}
+
+	patches, err := prov.getUpdateOptsForNode(ironicNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("patches: %v", patches)
+
+	expected := []struct {
+		Path  string      // the node property path
+		Value interface{} // the value being passed to ironic
+	}{
+		{
+			Path:  "/instance_info/image_source",
+			Value: "http://example.test/images/image.qcow2",
+		},
+		{
+			Path:  "/instance_info/image_os_hash_algo",
+			Value: "sha256",
+		},
+		{
+			Path:  "/instance_info/image_os_hash_value",
+			Value: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+
+	for _, e := range expected {
+		t.Run(e.Path, func(t *testing.T) {
+			t.Logf("expected: %v", e)
+			var update nodes.UpdateOperation
+			for _, patch := range patches {
+				update = patch.(nodes.UpdateOperation)
+				if update.Path == e.Path {
+					break
+				}
+			}
+			if update.Path != e.Path {
+				t.Errorf("did not find %q in updates", e.Path)
+				return
+			}
+			t.Logf("update: %v", update)
+			assert.Equal(t, e.Value, update.Value, fmt.Sprintf("%s does not match", e.Path))
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodePartitionImage(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			UID:       "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			Image: &metal3v1alpha1.Image{
+				URL:          "http://example.test/image.qcow2",
+				Checksum:     "checksum",
+				ChecksumType: metal3v1alpha1.MD5,
+				Kernel:       "http://example.test/vmlinuz",
+				Ramdisk:      "http://example.test/initrd.img",
+				RootGB:       25,
+			},
+			Online: true,
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			HardwareProfile: "libvirt",
+			Provisioning: metal3v1alpha1.ProvisionStatus{
+				ID: "provisioning-id",
+			},
+		},
+	}
+
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		"https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The node still has the kernel of a partition image provisioned
+	// before.
+	ironicNode := &nodes.Node{
+		InstanceInfo: map[string]interface{}{
+			"kernel": "http://example.test/old-vmlinuz",
+		},
+	}
+
+	patches, err := prov.getUpdateOptsForNode(ironicNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("patches: %v", patches)
+
+	expected := []struct {
+		Path  string         // the node property path
+		Op    nodes.UpdateOp // the operation on the property
+		Value interface{}    // the value being passed to ironic
+	}{
+		{
+			Path:  "/instance_info/image_source",
+			Op:    nodes.AddOp,
+			Value: "http://example.test/image.qcow2",
+		},
+		{
+			Path:  "/instance_info/kernel",
+			Op:    nodes.ReplaceOp,
+			Value: "http://example.test/vmlinuz",
+		},
+		{
+			Path:  "/instance_info/ramdisk",
+			Op:    nodes.AddOp,
+			Value: "http://example.test/initrd.img",
+		},
+		{
+			Path:  "/instance_info/root_gb",
+			Op:    nodes.AddOp,
+			Value: 25,
+		},
+	}
+
+	for _, e := range expected {
+		t.Run(e.Path, func(t *testing.T) {
+			t.Logf("expected: %v", e)
+			var update nodes.UpdateOperation
+			for _, patch := range patches {
+				update = patch.(nodes.UpdateOperation)
+				if update.Path == e.Path {
+					break
+				}
+			}
+			if update.Path != e.Path {
+				t.Errorf("did not find %q in updates", e.Path)
+				return
+			}
+			t.Logf("update: %v", update)
+			assert.Equal(t, e.Op, update.Op, fmt.Sprintf("%s does not match", e.Path))
+			assert.Equal(t, e.Value, update.Value, fmt.Sprintf("%s does not match", e.Path))
+		})
+	}
+
+	// Writing a whole-disk image afterwards removes the kernel and
+	// ramdisk.
+	host.Spec.Image.Kernel = ""
+	host.Spec.Image.Ramdisk = ""
+	ironicNode.InstanceInfo["ramdisk"] = "http://example.test/initrd.img"
+
+	patches, err = prov.getUpdateOptsForNode(ironicNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []string
+	for _, patch := range patches {
+		if update := patch.(nodes.UpdateOperation); update.Op == nodes.RemoveOp {
+			removed = append(removed, update.Path)
+		}
+	}
+	assert.ElementsMatch(t, []string{"/instance_info/kernel", "/instance_info/ramdisk"}, removed)
+}
+
+func TestGetUpdateOptsForNodeMetadataService(t *testing.T) {
+	host := &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			UID:       "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+			Annotations: map[string]string{
+				metal3v1alpha1.ConfigDataAnnotation: metal3v1alpha1.ConfigDataMetadataService,
+			},
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			Image: &metal3v1alpha1.Image{
+				URL:        "http://example.test/image.iso",
+				DiskFormat: pointer.StringPtr(metal3v1alpha1.LiveISODiskFormat),
+			},
+			Online: true,
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			HardwareProfile: "libvirt",
+			Provisioning: metal3v1alpha1.ProvisionStatus{
+				ID: "provisioning-id",
+			},
+		},
+	}
+
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		"https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetMetadataURL("http://192.168.111.1:8089/")
+	defer SetMetadataURL("")
+
+	patches, err := prov.getUpdateOptsForNode(&nodes.Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, patch := range patches {
+		update := patch.(nodes.UpdateOperation)
+		if update.Path != "/instance_info/kernel_append_params" {
+			continue
+		}
+		found = true
+		assert.Equal(t, nodes.AddOp, update.Op)
+		assert.Equal(t, "ignition.config.url=http://192.168.111.1:8089/27720611-e5d1-45d3-ba3a-222dcfaa4ca2/openstack/latest/user_data"+
+			" ci.ds=OpenStack cc: datasource: {OpenStack: {metadata_urls: ['http://192.168.111.1:8089/27720611-e5d1-45d3-ba3a-222dcfaa4ca2']}} end_cc",
+			update.Value)
+	}
+	assert.True(t, found, "kernel_append_params not set")
+
+	// Whole-disk images are not booted with the parameters.
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:          "http://example.test/image.qcow2",
+		Checksum:     "checksum",
+		ChecksumType: metal3v1alpha1.MD5,
+	}
+	patches, err = prov.getUpdateOptsForNode(&nodes.Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, patch := range patches {
+		assert.NotEqual(t, "/instance_info/kernel_append_params", patch.(nodes.UpdateOperation).Path)
+	}
+
+	// Hosts using a config drive are not given the parameters.
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:        "http://example.test/image.iso",
+		DiskFormat: pointer.StringPtr(metal3v1alpha1.LiveISODiskFormat),
+	}
+	host.Annotations = nil
+	patches, err = prov.getUpdateOptsForNode(&nodes.Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, patch := range patches {
+		assert.NotEqual(t, "/instance_info/kernel_append_params", patch.(nodes.UpdateOperation).Path)
+	}
+}
@@ -0,0 +1,89 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestValidateManagementAccessCleaningMode(t *testing.T) {
+
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name         string
+		cleaningMode metal3v1alpha1.AutomatedCleaningMode
+
+		expectedAutomatedClean bool
+		expectedPublish        string
+	}{
+		{
+			name:                   "default",
+			expectedAutomatedClean: true,
+			expectedPublish:        "CleaningModeSet Automated cleaning mode metadata",
+		},
+		{
+			name:                   "full",
+			cleaningMode:           metal3v1alpha1.CleaningModeFull,
+			expectedAutomatedClean: true,
+			expectedPublish:        "CleaningModeSet Automated cleaning mode full",
+		},
+		{
+			name:                   "disabled",
+			cleaningMode:           metal3v1alpha1.CleaningModeDisabled,
+			expectedAutomatedClean: false,
+			expectedPublish:        "CleaningModeSet Automated cleaning mode disabled",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var patch []nodes.UpdateOperation
+			ironic := testserver.NewIronic(t).Ready().WithNodeStatesProvision(nodeUUID)
+			ironic.Handler("/v1/nodes/"+nodeUUID, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPatch {
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				assert.NoError(t, json.NewEncoder(w).Encode(nodes.Node{
+					UUID:           nodeUUID,
+					ProvisionState: string(nodes.Enroll),
+				}))
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.AutomatedCleaningMode = tc.cleaningMode
+			publishedMsg := ""
+			publisher := func(reason, message string) {
+				publishedMsg = reason + " " + message
+			}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			prov.status.ID = nodeUUID
+			result, err := prov.ValidateManagementAccess(false)
+
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+			assert.Equal(t, tc.expectedPublish, publishedMsg)
+			assert.Equal(t, []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/automated_clean", Value: tc.expectedAutomatedClean},
+			}, patch)
+		})
+	}
+}
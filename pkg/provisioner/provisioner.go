@@ -44,11 +44,15 @@ type Provisioner interface {
 	// credentials is correct.
 	ValidateManagementAccess(credentialsChanged bool) (result Result, err error)
 
-	// InspectHardware updates the HardwareDetails field of the host with
-	// details of devices discovered on the hardware. It may be called
-	// multiple times, and should return true for its dirty flag until the
-	// inspection is completed.
-	InspectHardware() (result Result, details *metal3v1alpha1.HardwareDetails, err error)
+	// InspectHardware returns the details of devices discovered on
+	// the hardware, along with the raw inspection data when the
+	// provisioner has it, once the inspection is completed. The
+	// refresh flag tells the provisioner to discard the results of
+	// any previous inspection and start a new one. The started flag
+	// is true when the provisioner began inspecting the host. It may
+	// be called multiple times, and should return true for its dirty
+	// flag until the inspection is completed.
+	InspectHardware(refresh bool) (result Result, started bool, hardwareData *metal3v1alpha1.HardwareDataSpec, err error)
 
 	// UpdateHardwareState fetches the latest hardware state of the
 	// server and updates the HardwareDetails field of the host with
@@ -57,6 +61,26 @@ type Provisioner interface {
 	// if any state information has changed.
 	UpdateHardwareState() (result Result, err error)
 
+	// UpdateFirmware installs the firmware images listed in the host
+	// spec. The outdated flag tells the provisioner that the images
+	// have not been installed yet. The started flag is true when the
+	// provisioner began installing them. It may be called multiple
+	// times, and should return true for its dirty flag until the
+	// update is completed.
+	UpdateFirmware(outdated bool) (result Result, started bool, err error)
+
+	// Prepare applies the settings, such as the RAID configuration,
+	// that must be in place before the host is ready to be
+	// provisioned. The unprepared flag tells the provisioner that the
+	// settings in the host spec have not been applied yet. The
+	// started flag is true when the provisioner began applying
+	// them. When both the RAID configuration and the firmware
+	// settings changed, only the RAID configuration is applied, and
+	// the firmware settings are started by a later call. It may be
+	// called multiple times, and should return true for its dirty
+	// flag until the preparation is completed.
+	Prepare(unprepared bool) (result Result, started bool, err error)
+
 	// Adopt brings an externally-provisioned host under management by
 	// the provisioner.
 	Adopt() (result Result, err error)
@@ -76,6 +100,12 @@ type Provisioner interface {
 	// flag until the deprovisioning operation is completed.
 	Delete() (result Result, err error)
 
+	// Detach removes the host from the provisioning system without
+	// deprovisioning it, so that it can be adopted again later. It
+	// may be called multiple times, and should return true for its
+	// dirty flag until the host is removed.
+	Detach() (result Result, err error)
+
 	// PowerOn ensures the server is powered on independently of any image
 	// provisioning operation.
 	PowerOn() (result Result, err error)
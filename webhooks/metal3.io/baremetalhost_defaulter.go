@@ -0,0 +1,115 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+)
+
+const defaultHostPath = "/mutate-metal3-io-v1alpha1-baremetalhost"
+
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-metal3-io-v1alpha1-baremetalhost,mutating=true,failurePolicy=fail,groups=metal3.io,resources=baremetalhosts,versions=v1alpha1,name=mbaremetalhost.metal3.io,matchPolicy=Equivalent,sideEffects=None,webhookVersions=v1beta1
+
+// BareMetalHostDefaulter fills in the default values of optional
+// BareMetalHost settings, so that the stored spec shows the values
+// the controller acts on.
+type BareMetalHostDefaulter struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// SetupWithManager registers the defaulter with the webhook server
+// of the manager.
+func (d *BareMetalHostDefaulter) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(defaultHostPath, &webhook.Admission{Handler: d})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (d *BareMetalHostDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (d *BareMetalHostDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := d.decoder.Decode(req, host); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !defaultHost(host) {
+		return admission.Allowed("")
+	}
+
+	d.Log.Info("setting defaults", "host", req.Name, "namespace", req.Namespace)
+	marshaled, err := json.Marshal(host)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// defaultHost sets the values that the controller otherwise assumes
+// when optional settings are left empty. It returns true if the host
+// was modified.
+func defaultHost(host *metal3v1alpha1.BareMetalHost) (dirty bool) {
+	if !host.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	spec := &host.Spec
+
+	if spec.BootMode == "" {
+		spec.BootMode = metal3v1alpha1.DefaultBootMode
+		dirty = true
+	}
+
+	if spec.AutomatedCleaningMode == "" {
+		spec.AutomatedCleaningMode = metal3v1alpha1.DefaultCleaningMode
+		dirty = true
+	}
+
+	// The algorithm of a checksum read from a checksum file is guessed
+	// from its length.
+	if spec.Image != nil && spec.Image.Checksum != "" && spec.Image.ChecksumType == "" &&
+		!spec.Image.HasChecksumURL() {
+		spec.Image.ChecksumType = metal3v1alpha1.MD5
+		dirty = true
+	}
+
+	if spec.BMC.Address != "" {
+		// An address that cannot be parsed is left for the validating
+		// webhook to reject.
+		if address, err := bmc.DefaultAddress(spec.BMC.Address); err == nil && address != spec.BMC.Address {
+			spec.BMC.Address = address
+			dirty = true
+		}
+	}
+
+	return dirty
+}
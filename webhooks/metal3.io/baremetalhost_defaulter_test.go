@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestDefaultHost(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Spec     metal3v1alpha1.BareMetalHostSpec
+		Expected metal3v1alpha1.BareMetalHostSpec
+		Dirty    bool
+	}{
+		{
+			Scenario: "empty",
+			Spec:     metal3v1alpha1.BareMetalHostSpec{},
+			Expected: metal3v1alpha1.BareMetalHostSpec{
+				BootMode:              metal3v1alpha1.UEFI,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeMetadata,
+			},
+			Dirty: true,
+		},
+		{
+			Scenario: "all defaults",
+			Spec: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address: "192.168.122.1:6233",
+				},
+				Image: &metal3v1alpha1.Image{
+					URL:      "http://example.com/image.qcow2",
+					Checksum: "abcd",
+				},
+			},
+			Expected: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address: "ipmi://192.168.122.1:6233",
+				},
+				BootMode:              metal3v1alpha1.UEFI,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeMetadata,
+				Image: &metal3v1alpha1.Image{
+					URL:          "http://example.com/image.qcow2",
+					Checksum:     "abcd",
+					ChecksumType: metal3v1alpha1.MD5,
+				},
+			},
+			Dirty: true,
+		},
+		{
+			Scenario: "no checksum",
+			Spec: metal3v1alpha1.BareMetalHostSpec{
+				BootMode:              metal3v1alpha1.Legacy,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeFull,
+				Image: &metal3v1alpha1.Image{
+					URL: "http://example.com/image.qcow2",
+				},
+			},
+			Expected: metal3v1alpha1.BareMetalHostSpec{
+				BootMode:              metal3v1alpha1.Legacy,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeFull,
+				Image: &metal3v1alpha1.Image{
+					URL: "http://example.com/image.qcow2",
+				},
+			},
+			Dirty: false,
+		},
+		{
+			Scenario: "checksum URL",
+			Spec: metal3v1alpha1.BareMetalHostSpec{
+				BootMode:              metal3v1alpha1.Legacy,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeFull,
+				Image: &metal3v1alpha1.Image{
+					URL:      "http://example.com/image.qcow2",
+					Checksum: "http://example.com/SHA256SUMS",
+				},
+			},
+			Expected: metal3v1alpha1.BareMetalHostSpec{
+				BootMode:              metal3v1alpha1.Legacy,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeFull,
+				Image: &metal3v1alpha1.Image{
+					URL:      "http://example.com/image.qcow2",
+					Checksum: "http://example.com/SHA256SUMS",
+				},
+			},
+			Dirty: false,
+		},
+		{
+			Scenario: "explicit values",
+			Spec: func() metal3v1alpha1.BareMetalHostSpec {
+				s := validSpec()
+				s.BootMode = metal3v1alpha1.Legacy
+				s.AutomatedCleaningMode = metal3v1alpha1.CleaningModeDisabled
+				return s
+			}(),
+			Expected: func() metal3v1alpha1.BareMetalHostSpec {
+				s := validSpec()
+				s.BootMode = metal3v1alpha1.Legacy
+				s.AutomatedCleaningMode = metal3v1alpha1.CleaningModeDisabled
+				return s
+			}(),
+			Dirty: false,
+		},
+		{
+			Scenario: "invalid address",
+			Spec: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address: "[fe80::1",
+				},
+				BootMode:              metal3v1alpha1.UEFI,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeMetadata,
+			},
+			Expected: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address: "[fe80::1",
+				},
+				BootMode:              metal3v1alpha1.UEFI,
+				AutomatedCleaningMode: metal3v1alpha1.CleaningModeMetadata,
+			},
+			Dirty: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newHost(tc.Spec)
+			dirty := defaultHost(host)
+			assert.Equal(t, tc.Dirty, dirty)
+			assert.Equal(t, tc.Expected, host.Spec)
+		})
+	}
+}
+
+func TestDefaultHostDeleting(t *testing.T) {
+	host := newHost(metal3v1alpha1.BareMetalHostSpec{})
+	now := metav1.Now()
+	host.DeletionTimestamp = &now
+
+	assert.False(t, defaultHost(host))
+	assert.Equal(t, metal3v1alpha1.BootMode(""), host.Spec.BootMode)
+}
+
+func TestDefaulterHandle(t *testing.T) {
+	d := &BareMetalHostDefaulter{Log: zap.New(zap.UseDevMode(true))}
+	if err := d.InjectDecoder(newDecoder(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := validSpec()
+	spec.BootMode = metal3v1alpha1.UEFI
+	spec.AutomatedCleaningMode = metal3v1alpha1.CleaningModeMetadata
+	resp := d.Handle(context.TODO(), request(admissionv1beta1.Create, newHost(spec), nil))
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+
+	spec.BootMode = ""
+	spec.AutomatedCleaningMode = ""
+	spec.Image.ChecksumType = ""
+	resp = d.Handle(context.TODO(), request(admissionv1beta1.Create, newHost(spec), nil))
+	assert.True(t, resp.Allowed)
+	paths := []string{}
+	for _, p := range resp.Patches {
+		paths = append(paths, p.Path)
+	}
+	assert.ElementsMatch(t, []string{"/spec/bootMode", "/spec/automatedCleaningMode", "/spec/image/checksumType"}, paths)
+}
@@ -0,0 +1,266 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/go-logr/logr"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+)
+
+const validateHostPath = "/validate-metal3-io-v1alpha1-baremetalhost"
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-metal3-io-v1alpha1-baremetalhost,mutating=false,failurePolicy=fail,groups=metal3.io,resources=baremetalhosts,versions=v1alpha1,name=vbaremetalhost.metal3.io,matchPolicy=Equivalent,sideEffects=None,webhookVersions=v1beta1
+
+// BareMetalHostValidator rejects BareMetalHost resources with
+// settings that the controller would otherwise only discover while
+// reconciling the host.
+type BareMetalHostValidator struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// SetupWithManager registers the validator with the webhook server
+// of the manager.
+func (v *BareMetalHostValidator) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(validateHostPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *BareMetalHostValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *BareMetalHostValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	host := &metal3v1alpha1.BareMetalHost{}
+	if err := v.decoder.Decode(req, host); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var oldHost *metal3v1alpha1.BareMetalHost
+	if req.Operation == admissionv1beta1.Update {
+		oldHost = &metal3v1alpha1.BareMetalHost{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldHost); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	errs := validateHost(host, oldHost)
+	if len(errs) != 0 {
+		v.Log.Info("rejecting host", "host", req.Name, "namespace", req.Namespace,
+			"operation", req.Operation, "errors", errs.ToAggregate().Error())
+		return admission.Denied(errs.ToAggregate().Error())
+	}
+	return admission.Allowed("")
+}
+
+// validateHost checks the settings of a host being created (when
+// oldHost is nil) or updated. When a host is updated, only the
+// settings that have changed are checked so that hosts created before
+// the validation existed can still be updated and deleted.
+func validateHost(host, oldHost *metal3v1alpha1.BareMetalHost) (errs field.ErrorList) {
+	if !host.DeletionTimestamp.IsZero() {
+		// Never block removing the finalizer.
+		return nil
+	}
+
+	spec := &host.Spec
+	specPath := field.NewPath("spec")
+	var oldSpec *metal3v1alpha1.BareMetalHostSpec
+	if oldHost != nil {
+		oldSpec = &oldHost.Spec
+	}
+	changed := func(get func(*metal3v1alpha1.BareMetalHostSpec) interface{}) bool {
+		return oldSpec == nil || !reflect.DeepEqual(get(spec), get(oldSpec))
+	}
+
+	if spec.BMC.Address != "" && changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.BMC }) {
+		if _, err := bmc.NewAccessDetails(spec.BMC.Address, spec.BMC.DisableCertificateVerification); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("bmc", "address"),
+				spec.BMC.Address, err.Error()))
+		}
+	}
+
+	if spec.BootMACAddress != "" && changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.BootMACAddress }) {
+		if mac, err := net.ParseMAC(spec.BootMACAddress); err != nil || len(mac) != 6 {
+			errs = append(errs, field.Invalid(specPath.Child("bootMACAddress"),
+				spec.BootMACAddress, "not a valid MAC address"))
+		}
+	}
+
+	if changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.NICs }) {
+		for i, nic := range spec.NICs {
+			if mac, err := net.ParseMAC(nic.MACAddress); err != nil || len(mac) != 6 {
+				errs = append(errs, field.Invalid(specPath.Child("nics").Index(i).Child("macAddress"),
+					nic.MACAddress, "not a valid MAC address"))
+			}
+		}
+	}
+
+	if changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.PortGroups }) {
+		names := map[string]bool{}
+		members := map[string]bool{}
+		for i, group := range spec.PortGroups {
+			groupPath := specPath.Child("portGroups").Index(i)
+			if names[group.Name] {
+				errs = append(errs, field.Duplicate(groupPath.Child("name"), group.Name))
+			}
+			names[group.Name] = true
+			if len(group.MACAddresses) == 0 {
+				errs = append(errs, field.Required(groupPath.Child("macAddresses"),
+					"a port group needs at least one member"))
+			}
+			for j, address := range group.MACAddresses {
+				mac, err := net.ParseMAC(address)
+				if err != nil || len(mac) != 6 {
+					errs = append(errs, field.Invalid(groupPath.Child("macAddresses").Index(j),
+						address, "not a valid MAC address"))
+					continue
+				}
+				if members[mac.String()] {
+					errs = append(errs, field.Invalid(groupPath.Child("macAddresses").Index(j),
+						address, "the interface is a member of another port group"))
+				}
+				members[mac.String()] = true
+			}
+		}
+	}
+
+	if spec.HardwareProfile != "" && changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.HardwareProfile }) {
+		if _, err := hardware.GetProfile(spec.HardwareProfile); err != nil {
+			errs = append(errs, field.NotFound(specPath.Child("hardwareProfile"),
+				spec.HardwareProfile))
+		}
+	}
+
+	if spec.Image != nil && spec.Image.Checksum != "" && changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.Image }) {
+		if _, _, ok := host.GetImageChecksum(); !ok {
+			errs = append(errs, field.NotSupported(specPath.Child("image", "checksumType"),
+				spec.Image.ChecksumType,
+				[]string{string(metal3v1alpha1.MD5), string(metal3v1alpha1.SHA256), string(metal3v1alpha1.SHA512)}))
+		}
+		if spec.Image.HasChecksumURL() {
+			if u, err := url.ParseRequestURI(spec.Image.Checksum); err != nil || u.Host == "" {
+				errs = append(errs, field.Invalid(specPath.Child("image", "checksum"),
+					spec.Image.Checksum, "not a valid checksum file URL"))
+			}
+		}
+	}
+
+	if spec.Image.IsPartitionImage() && changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.Image }) {
+		if spec.Image.Kernel == "" {
+			errs = append(errs, field.Required(specPath.Child("image", "kernel"),
+				"partition images need both a kernel and a ramdisk"))
+		}
+		if spec.Image.Ramdisk == "" {
+			errs = append(errs, field.Required(specPath.Child("image", "ramdisk"),
+				"partition images need both a kernel and a ramdisk"))
+		}
+		if spec.Image.IsLiveISO() {
+			errs = append(errs, field.Invalid(specPath.Child("image", "format"),
+				*spec.Image.DiskFormat, "live-iso images cannot have a kernel and ramdisk"))
+		}
+	}
+
+	if spec.Firmware != nil && spec.BMC.Address != "" &&
+		(changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.Firmware }) ||
+			changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.BMC })) {
+		// An invalid address is reported above.
+		if accessDetails, err := bmc.NewAccessDetails(spec.BMC.Address, spec.BMC.DisableCertificateVerification); err == nil {
+			if _, err := accessDetails.BuildBIOSSettings(spec.Firmware); err != nil {
+				errs = append(errs, field.Invalid(specPath.Child("firmware"),
+					spec.Firmware, err.Error()))
+			}
+		}
+	}
+
+	if value, ok := host.Annotations[metal3v1alpha1.HardwareDetailsAnnotation]; ok &&
+		(oldHost == nil || oldHost.Annotations[metal3v1alpha1.HardwareDetailsAnnotation] != value) {
+		if _, err := host.GetHardwareDetailsFromAnnotation(); err != nil {
+			errs = append(errs, field.Invalid(
+				field.NewPath("metadata", "annotations").Key(metal3v1alpha1.HardwareDetailsAnnotation),
+				value, err.Error()))
+		}
+	}
+
+	// The URL of the metadata service is passed on the kernel command
+	// line, which whole-disk images are not booted with.
+	if host.UsesMetadataService() && spec.Image != nil && spec.Image.URL != "" &&
+		!spec.Image.BootsWithKernelParams() &&
+		(oldHost == nil || !oldHost.UsesMetadataService() ||
+			changed(func(s *metal3v1alpha1.BareMetalHostSpec) interface{} { return s.Image })) {
+		errs = append(errs, field.Invalid(
+			field.NewPath("metadata", "annotations").Key(metal3v1alpha1.ConfigDataAnnotation),
+			metal3v1alpha1.ConfigDataMetadataService,
+			"whole-disk images cannot use the metadata service, only live-iso and partition images"))
+	}
+
+	if oldHost != nil {
+		errs = append(errs, validateHostTransition(host, oldHost)...)
+	}
+
+	return errs
+}
+
+// validateHostTransition checks for changes to the spec that are not
+// allowed given the current state of the host.
+func validateHostTransition(host, oldHost *metal3v1alpha1.BareMetalHost) (errs field.ErrorList) {
+	imagePath := field.NewPath("spec", "image")
+
+	switch oldHost.Status.Provisioning.State {
+	case metal3v1alpha1.StateProvisioning:
+		// Removing the image cancels provisioning, but replacing it
+		// with a different one is not supported until the host has
+		// been deprovisioned.
+		if host.Spec.Image != nil && host.Spec.Image.URL != "" &&
+			oldHost.Spec.Image != nil &&
+			!reflect.DeepEqual(host.Spec.Image, oldHost.Spec.Image) {
+			errs = append(errs, field.Forbidden(imagePath,
+				"the image cannot be changed while the host is provisioning; remove it to cancel provisioning"))
+		}
+	}
+
+	switch oldHost.Status.Provisioning.State {
+	case metal3v1alpha1.StateProvisioning,
+		metal3v1alpha1.StateProvisioned,
+		metal3v1alpha1.StateExternallyProvisioned:
+		// Inspecting the host would reboot it.
+		if host.HasInspectAnnotation() && !oldHost.HasInspectAnnotation() {
+			errs = append(errs, field.Forbidden(
+				field.NewPath("metadata", "annotations").Key(metal3v1alpha1.InspectAnnotation),
+				"the host cannot be inspected while it is provisioned"))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,474 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+)
+
+func init() {
+	// Make the default hardware profiles available
+	hardware.SetProfileReader(hardware.NewFakeProfileReader())
+}
+
+func newHost(spec metal3v1alpha1.BareMetalHostSpec) *metal3v1alpha1.BareMetalHost {
+	return &metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+		},
+		Spec: spec,
+	}
+}
+
+func validSpec() metal3v1alpha1.BareMetalHostSpec {
+	return metal3v1alpha1.BareMetalHostSpec{
+		BMC: metal3v1alpha1.BMCDetails{
+			Address:         "ipmi://192.168.122.1:6233",
+			CredentialsName: "bmc-creds",
+		},
+		BootMACAddress: "00:11:22:33:44:55",
+		Image: &metal3v1alpha1.Image{
+			URL:          "http://example.com/image.qcow2",
+			Checksum:     "abcd",
+			ChecksumType: metal3v1alpha1.SHA256,
+		},
+	}
+}
+
+func TestValidateHostCreate(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Mutate   func(*metal3v1alpha1.BareMetalHostSpec)
+		Fields   []string
+	}{
+		{
+			Scenario: "valid",
+			Mutate:   func(*metal3v1alpha1.BareMetalHostSpec) {},
+		},
+		{
+			Scenario: "empty",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				*s = metal3v1alpha1.BareMetalHostSpec{}
+			},
+		},
+		{
+			Scenario: "unknown BMC type",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.BMC.Address = "foo://192.168.122.1"
+			},
+			Fields: []string{"spec.bmc.address"},
+		},
+		{
+			Scenario: "invalid MAC",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.BootMACAddress = "00:11:22"
+			},
+			Fields: []string{"spec.bootMACAddress"},
+		},
+		{
+			Scenario: "NICs and port groups",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.NICs = []metal3v1alpha1.NICConfig{{MACAddress: "00:11:22:33:44:66"}}
+				s.PortGroups = []metal3v1alpha1.PortGroup{
+					{
+						Name:         "bond0",
+						Mode:         metal3v1alpha1.BondModeLACP,
+						MACAddresses: []string{"00:11:22:33:44:55", "00:11:22:33:44:77"},
+					},
+				}
+			},
+		},
+		{
+			Scenario: "invalid NIC MAC",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.NICs = []metal3v1alpha1.NICConfig{{MACAddress: "00:11:22"}}
+			},
+			Fields: []string{"spec.nics[0].macAddress"},
+		},
+		{
+			Scenario: "invalid port groups",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.PortGroups = []metal3v1alpha1.PortGroup{
+					{Name: "bond0", MACAddresses: []string{"00:11:22:33:44:55", "00:11:22"}},
+					{Name: "bond0", MACAddresses: []string{"00:11:22:33:44:55"}},
+					{Name: "bond1"},
+				}
+			},
+			Fields: []string{
+				"spec.portGroups[0].macAddresses[1]",
+				"spec.portGroups[1].name",
+				"spec.portGroups[1].macAddresses[0]",
+				"spec.portGroups[2].macAddresses",
+			},
+		},
+		{
+			Scenario: "unknown profile",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.HardwareProfile = "no-such-profile"
+			},
+			Fields: []string{"spec.hardwareProfile"},
+		},
+		{
+			Scenario: "known profile",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.HardwareProfile = "libvirt"
+			},
+		},
+		{
+			Scenario: "unsupported checksum type",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Image.ChecksumType = "crc32"
+			},
+			Fields: []string{"spec.image.checksumType"},
+		},
+		{
+			Scenario: "checksum URL",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Image.Checksum = "http://example.com/SHA256SUMS"
+			},
+		},
+		{
+			Scenario: "partition image",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Image.Kernel = "http://example.com/vmlinuz"
+				s.Image.Ramdisk = "http://example.com/initrd.img"
+				s.Image.RootGB = 20
+			},
+		},
+		{
+			Scenario: "partition image without ramdisk",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Image.Kernel = "http://example.com/vmlinuz"
+			},
+			Fields: []string{"spec.image.ramdisk"},
+		},
+		{
+			Scenario: "live-iso partition image",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Image.Kernel = "http://example.com/vmlinuz"
+				s.Image.Ramdisk = "http://example.com/initrd.img"
+				s.Image.DiskFormat = pointer.StringPtr(metal3v1alpha1.LiveISODiskFormat)
+			},
+			Fields: []string{"spec.image.format"},
+		},
+		{
+			Scenario: "invalid checksum URL",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Image.Checksum = "http://"
+			},
+			Fields: []string{"spec.image.checksum"},
+		},
+		{
+			Scenario: "well-known firmware setting unsupported by driver",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				enabled := true
+				s.Firmware = &metal3v1alpha1.FirmwareConfig{VirtualizationEnabled: &enabled}
+			},
+			Fields: []string{"spec.firmware"},
+		},
+		{
+			Scenario: "well-known firmware setting",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				enabled := true
+				s.BMC.Address = "idrac://192.168.122.1"
+				s.Firmware = &metal3v1alpha1.FirmwareConfig{VirtualizationEnabled: &enabled}
+			},
+		},
+		{
+			Scenario: "vendor specific firmware setting",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.Firmware = &metal3v1alpha1.FirmwareConfig{
+					Settings: map[string]string{"BootMode": "Uefi"},
+				}
+			},
+		},
+		{
+			Scenario: "vendor specific firmware setting conflicting with a well-known setting",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				enabled := true
+				s.BMC.Address = "idrac://192.168.122.1"
+				s.Firmware = &metal3v1alpha1.FirmwareConfig{
+					SriovEnabled: &enabled,
+					Settings:     map[string]string{"SriovGlobalEnable": "Disabled"},
+				}
+			},
+			Fields: []string{"spec.firmware"},
+		},
+		{
+			Scenario: "multiple errors",
+			Mutate: func(s *metal3v1alpha1.BareMetalHostSpec) {
+				s.BMC.Address = "foo://192.168.122.1"
+				s.BootMACAddress = "bad"
+			},
+			Fields: []string{"spec.bmc.address", "spec.bootMACAddress"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			spec := validSpec()
+			tc.Mutate(&spec)
+
+			errs := validateHost(newHost(spec), nil)
+
+			fields := []string{}
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if tc.Fields == nil {
+				tc.Fields = []string{}
+			}
+			assert.Equal(t, tc.Fields, fields)
+		})
+	}
+}
+
+func TestValidateHostUpdate(t *testing.T) {
+	invalid := validSpec()
+	invalid.BMC.Address = "foo://192.168.122.1"
+
+	testCases := []struct {
+		Scenario string
+		Old      *metal3v1alpha1.BareMetalHost
+		New      *metal3v1alpha1.BareMetalHost
+		Valid    bool
+	}{
+		{
+			Scenario: "unchanged invalid field",
+			Old:      newHost(invalid),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(invalid)
+				h.Finalizers = []string{metal3v1alpha1.BareMetalHostFinalizer}
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "changed to invalid field",
+			Old:      newHost(validSpec()),
+			New:      newHost(invalid),
+			Valid:    false,
+		},
+		{
+			Scenario: "deleting",
+			Old:      newHost(validSpec()),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(invalid)
+				now := metav1.Now()
+				h.DeletionTimestamp = &now
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "replace image while provisioning",
+			Old: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Status.Provisioning.State = metal3v1alpha1.StateProvisioning
+				return h
+			}(),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Spec.Image.URL = "http://example.com/other.qcow2"
+				return h
+			}(),
+			Valid: false,
+		},
+		{
+			Scenario: "remove image while provisioning",
+			Old: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Status.Provisioning.State = metal3v1alpha1.StateProvisioning
+				return h
+			}(),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Spec.Image = nil
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "replace image while provisioned",
+			Old: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+				return h
+			}(),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Spec.Image.URL = "http://example.com/other.qcow2"
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "inspect while ready",
+			Old: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Status.Provisioning.State = metal3v1alpha1.StateReady
+				return h
+			}(),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{metal3v1alpha1.InspectAnnotation: ""}
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "inspect while provisioned",
+			Old: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+				return h
+			}(),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{metal3v1alpha1.InspectAnnotation: ""}
+				return h
+			}(),
+			Valid: false,
+		},
+		{
+			Scenario: "disable inspection while provisioned",
+			Old: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+				return h
+			}(),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{
+					metal3v1alpha1.InspectAnnotation: metal3v1alpha1.InspectAnnotationDisabled,
+				}
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "valid hardware details",
+			Old:      newHost(validSpec()),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{
+					metal3v1alpha1.HardwareDetailsAnnotation: `{"hostname": "node-0"}`,
+				}
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "invalid hardware details",
+			Old:      newHost(validSpec()),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{
+					metal3v1alpha1.HardwareDetailsAnnotation: `{"hostname": 0}`,
+				}
+				return h
+			}(),
+			Valid: false,
+		},
+		{
+			Scenario: "metadata service with a whole-disk image",
+			Old:      newHost(validSpec()),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{
+					metal3v1alpha1.ConfigDataAnnotation: metal3v1alpha1.ConfigDataMetadataService,
+				}
+				return h
+			}(),
+			Valid: false,
+		},
+		{
+			Scenario: "metadata service with a partition image",
+			Old:      newHost(validSpec()),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{
+					metal3v1alpha1.ConfigDataAnnotation: metal3v1alpha1.ConfigDataMetadataService,
+				}
+				h.Spec.Image.Kernel = "http://example.com/vmlinuz"
+				h.Spec.Image.Ramdisk = "http://example.com/initrd"
+				return h
+			}(),
+			Valid: true,
+		},
+		{
+			Scenario: "metadata service without an image",
+			Old:      newHost(validSpec()),
+			New: func() *metal3v1alpha1.BareMetalHost {
+				h := newHost(validSpec())
+				h.Annotations = map[string]string{
+					metal3v1alpha1.ConfigDataAnnotation: metal3v1alpha1.ConfigDataMetadataService,
+				}
+				h.Spec.Image = nil
+				return h
+			}(),
+			Valid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			errs := validateHost(tc.New, tc.Old)
+			assert.Equal(t, tc.Valid, len(errs) == 0, errs.ToAggregate())
+		})
+	}
+}
+
+func newDecoder(t *testing.T) *admission.Decoder {
+	scheme := runtime.NewScheme()
+	if err := metal3v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoder
+}
+
+func request(op admissionv1beta1.Operation, host, old *metal3v1alpha1.BareMetalHost) admission.Request {
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{Operation: op}}
+	req.Object.Raw, _ = json.Marshal(host)
+	if old != nil {
+		req.OldObject.Raw, _ = json.Marshal(old)
+	}
+	return req
+}
+
+func TestValidatorHandle(t *testing.T) {
+	v := &BareMetalHostValidator{Log: zap.New(zap.UseDevMode(true))}
+	if err := v.InjectDecoder(newDecoder(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := validSpec()
+	invalid.BootMACAddress = "bad"
+
+	resp := v.Handle(context.TODO(), request(admissionv1beta1.Create, newHost(validSpec()), nil))
+	assert.True(t, resp.Allowed)
+
+	resp = v.Handle(context.TODO(), request(admissionv1beta1.Create, newHost(invalid), nil))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, string(resp.Result.Reason), "spec.bootMACAddress")
+
+	resp = v.Handle(context.TODO(), request(admissionv1beta1.Update, newHost(invalid), newHost(invalid)))
+	assert.True(t, resp.Allowed)
+}